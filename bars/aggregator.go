@@ -0,0 +1,408 @@
+package bars
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+// Bar is an OHLCV bar.
+type Bar struct {
+	// Start and End are the times of the bar in milliseconds. The bar of a time interval
+	// covers [Start, End). Other bars start at their first trade and end at their last.
+	Start int64
+	End   int64
+
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+
+	Volume     float64
+	BuyVolume  float64
+	SellVolume float64
+	// Notional is the sum of the price times the amount of each trade.
+	Notional float64
+	// VWAP is the volume weighted average price, Notional/Volume. The VWAP of a bar
+	// without trades is its close.
+	VWAP float64
+	// Count is the number of trades in the bar. A trade split between volume or dollar
+	// bars is counted in each of them.
+	Count int
+}
+
+// Kind is the kind of bars built by an [Aggregator].
+type Kind int
+
+const (
+	// TimeBars close at the end of each time interval.
+	TimeBars Kind = iota
+	// TickBars close after a number of trades.
+	TickBars
+	// VolumeBars close once their volume reaches a size.
+	VolumeBars
+	// DollarBars close once their notional reaches a size.
+	DollarBars
+)
+
+func (k Kind) String() string {
+	switch k {
+	case TimeBars:
+		return "time"
+	case TickBars:
+		return "tick"
+	case VolumeBars:
+		return "volume"
+	case DollarBars:
+		return "dollar"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// maxAdvanceInterval is the longest interval at which Run closes time bars when no trades
+// arrive.
+const maxAdvanceInterval = time.Second
+
+// Aggregator builds bars from trades. Closed bars are produced on the Bars channel,
+// which must be read, otherwise updates will block once its buffer is full. An
+// Aggregator isn't safe for concurrent use.
+//
+// Time bars are aligned to UTC: a bar starts at a multiple of its interval since the Unix
+// epoch, so hourly bars start on the hour and daily bars at midnight UTC. Trades may
+// arrive out of order. A time bar closes once a trade arrives which is later than its end
+// by more than the allowed lateness, or when AdvanceTo moves the time past it. A trade
+// for a bar which has already closed is late, and dropped. By default, intervals without
+// trades have no bar. With SetGapFill, they have a bar at the previous close with no
+// volume.
+//
+// Tick, volume and dollar bars are built from trades in the order they arrive. A trade
+// which overflows a volume or dollar bar is split, so that each bar has exactly the
+// bar's size.
+type Aggregator struct {
+	kind     Kind
+	interval int64
+	size     float64
+	bars     chan Bar
+
+	lateness int64
+	gapFill  bool
+
+	// current is the open bar of tick, volume and dollar bars.
+	current *builder
+	// open are the open time bars by their start time.
+	open map[int64]*builder
+	// next is the start of the earliest time bar which hasn't closed.
+	next    int64
+	started bool
+	maxTime int64
+	late    int
+
+	lastClose float64
+	hasClose  bool
+}
+
+// builder accumulates the trades of a bar.
+type builder struct {
+	bar       Bar
+	openTime  int64
+	closeTime int64
+}
+
+func (b *builder) add(t Trade, amount float64) {
+	if b.bar.Count == 0 {
+		b.bar.Open, b.bar.High, b.bar.Low, b.bar.Close = t.Price, t.Price, t.Price, t.Price
+		b.openTime, b.closeTime = t.Timestamp, t.Timestamp
+	} else {
+		if t.Timestamp < b.openTime {
+			b.bar.Open, b.openTime = t.Price, t.Timestamp
+		}
+		if t.Timestamp >= b.closeTime {
+			b.bar.Close, b.closeTime = t.Price, t.Timestamp
+		}
+		b.bar.High = math.Max(b.bar.High, t.Price)
+		b.bar.Low = math.Min(b.bar.Low, t.Price)
+	}
+	b.bar.Volume += amount
+	if t.Buy {
+		b.bar.BuyVolume += amount
+	} else {
+		b.bar.SellVolume += amount
+	}
+	b.bar.Notional += t.Price * amount
+	b.bar.Count++
+}
+
+func (b *builder) result() Bar {
+	bar := b.bar
+	if bar.Volume > 0 {
+		bar.VWAP = bar.Notional / bar.Volume
+	} else {
+		bar.VWAP = bar.Close
+	}
+	return bar
+}
+
+func newAggregator(kind Kind, paramFuncs []tk.Param) *Aggregator {
+	p := tk.ApplyParams(paramFuncs)
+	return &Aggregator{
+		kind:    kind,
+		bars:    make(chan Bar, p.ChannelBufferSize),
+		open:    make(map[int64]*builder),
+		maxTime: math.MinInt64,
+	}
+}
+
+// NewTimeAggregator creates an Aggregator of time bars with the given interval, which
+// must be a whole number of milliseconds. The buffer size of the Bars channel may be set
+// with tk.WithChannelBufferSize.
+func NewTimeAggregator(interval time.Duration, paramFuncs ...tk.Param) *Aggregator {
+	if interval < time.Millisecond || interval%time.Millisecond != 0 {
+		panic(fmt.Sprintf("bars: invalid interval %s", interval))
+	}
+	a := newAggregator(TimeBars, paramFuncs)
+	a.interval = interval.Milliseconds()
+	return a
+}
+
+// NewTickAggregator creates an Aggregator of bars of n trades.
+func NewTickAggregator(n int, paramFuncs ...tk.Param) *Aggregator {
+	if n <= 0 {
+		panic(fmt.Sprintf("bars: invalid number of trades %d", n))
+	}
+	a := newAggregator(TickBars, paramFuncs)
+	a.size = float64(n)
+	return a
+}
+
+// NewVolumeAggregator creates an Aggregator of bars with the given volume.
+func NewVolumeAggregator(volume float64, paramFuncs ...tk.Param) *Aggregator {
+	if !(volume > 0) {
+		panic(fmt.Sprintf("bars: invalid volume %v", volume))
+	}
+	a := newAggregator(VolumeBars, paramFuncs)
+	a.size = volume
+	return a
+}
+
+// NewDollarAggregator creates an Aggregator of bars with the given notional.
+func NewDollarAggregator(notional float64, paramFuncs ...tk.Param) *Aggregator {
+	if !(notional > 0) {
+		panic(fmt.Sprintf("bars: invalid notional %v", notional))
+	}
+	a := newAggregator(DollarBars, paramFuncs)
+	a.size = notional
+	return a
+}
+
+// Kind returns the kind of bars built by the Aggregator.
+func (a *Aggregator) Kind() Kind {
+	return a.kind
+}
+
+// Bars returns a channel which produces bars as they close.
+func (a *Aggregator) Bars() <-chan Bar {
+	return a.bars
+}
+
+// SetAllowedLateness sets how long time bars stay open after their end for trades which
+// arrive out of order. It's zero by default.
+func (a *Aggregator) SetAllowedLateness(d time.Duration) {
+	a.lateness = d.Milliseconds()
+}
+
+// SetGapFill sets whether time intervals without trades have a bar. A gap is only filled
+// after the first bar.
+func (a *Aggregator) SetGapFill(gapFill bool) {
+	a.gapFill = gapFill
+}
+
+// Late returns the number of trades which were dropped as they arrived after their bar
+// had closed.
+func (a *Aggregator) Late() int {
+	return a.late
+}
+
+// Update adds a trade to its bar, and emits the bars it closes.
+func (a *Aggregator) Update(t Trade) {
+	switch a.kind {
+	case TimeBars:
+		a.updateTime(t)
+	case TickBars:
+		a.currentBar().add(t, t.Amount)
+		if a.current.bar.Count >= int(a.size) {
+			a.emitCurrent()
+		}
+	case VolumeBars, DollarBars:
+		a.updateSize(t)
+	}
+}
+
+func (a *Aggregator) updateTime(t Trade) {
+	start := a.align(t.Timestamp)
+	if a.started && start < a.next {
+		a.late++
+		return
+	}
+	b, ok := a.open[start]
+	if !ok {
+		b = &builder{bar: Bar{Start: start, End: start + a.interval}}
+		a.open[start] = b
+	}
+	b.add(t, t.Amount)
+	a.AdvanceTo(t.Timestamp)
+}
+
+// updateSize adds a trade to volume or dollar bars, splitting it over as many bars as it
+// fills.
+func (a *Aggregator) updateSize(t Trade) {
+	amount := t.Amount
+	for amount > 0 {
+		b := a.currentBar()
+		var room float64
+		if a.kind == VolumeBars {
+			room = a.size - b.bar.Volume
+		} else {
+			room = (a.size - b.bar.Notional) / t.Price
+		}
+		// The tolerance avoids an extra bar from floating-point error.
+		if amount < room*(1-1e-12) {
+			b.add(t, amount)
+			return
+		}
+		b.add(t, room)
+		a.emitCurrent()
+		amount -= room
+		if amount <= t.Amount*1e-12 {
+			return
+		}
+	}
+}
+
+// AdvanceTo closes the time bars which end before a timestamp in milliseconds, less the
+// allowed lateness. It lets time bars close when no trades arrive, from a clock or a
+// venue's heartbeat. It has no effect on other kinds of bars.
+func (a *Aggregator) AdvanceTo(ts int64) {
+	if a.kind != TimeBars || ts <= a.maxTime {
+		return
+	}
+	a.maxTime = ts
+	a.closeBefore(a.align(ts - a.lateness))
+}
+
+// Flush emits the open bars. Later trades of a flushed time bar are late.
+func (a *Aggregator) Flush() {
+	if a.kind != TimeBars {
+		if a.current != nil {
+			a.emitCurrent()
+		}
+		return
+	}
+	var end int64
+	for start := range a.open {
+		if start+a.interval > end {
+			end = start + a.interval
+		}
+	}
+	if len(a.open) > 0 {
+		a.closeBefore(end)
+	}
+}
+
+// closeBefore emits the time bars which start before the given time, filling gaps if
+// required.
+func (a *Aggregator) closeBefore(end int64) {
+	if !a.started {
+		a.started = true
+		a.next = end
+		for start := range a.open {
+			if start < a.next {
+				a.next = start
+			}
+		}
+	}
+	for a.next < end {
+		if b, ok := a.open[a.next]; ok {
+			delete(a.open, a.next)
+			a.emit(b.result())
+			a.next += a.interval
+			continue
+		}
+		if a.gapFill && a.hasClose {
+			c := a.lastClose
+			a.emit(Bar{Start: a.next, End: a.next + a.interval, Open: c, High: c, Low: c, Close: c, VWAP: c})
+			a.next += a.interval
+			continue
+		}
+		// Skip to the next open bar.
+		next := end
+		for start := range a.open {
+			if start > a.next && start < next {
+				next = start
+			}
+		}
+		a.next = next
+	}
+}
+
+// Run updates the Aggregator with trades until the context is done or the channel of
+// trades is closed, when the open bars are flushed. Time bars are closed on time with
+// the local clock when no trades arrive, so the allowed lateness should cover the delay
+// of trades and any difference between the venue's clock and the local clock.
+func (a *Aggregator) Run(ctx context.Context, trades <-chan Trade) {
+	var tick <-chan time.Time
+	if a.kind == TimeBars {
+		d := time.Duration(a.interval) * time.Millisecond
+		if d > maxAdvanceInterval {
+			d = maxAdvanceInterval
+		}
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t, ok := <-trades:
+			if !ok {
+				a.Flush()
+				return
+			}
+			a.Update(t)
+		case now := <-tick:
+			a.AdvanceTo(now.UnixMilli())
+		}
+	}
+}
+
+func (a *Aggregator) currentBar() *builder {
+	if a.current == nil {
+		a.current = &builder{}
+	}
+	return a.current
+}
+
+func (a *Aggregator) emitCurrent() {
+	b := a.current
+	a.current = nil
+	b.bar.Start, b.bar.End = b.openTime, b.closeTime
+	a.emit(b.result())
+}
+
+func (a *Aggregator) emit(bar Bar) {
+	a.lastClose, a.hasClose = bar.Close, true
+	a.bars <- bar
+}
+
+// align returns the start of the time bar of a timestamp.
+func (a *Aggregator) align(ts int64) int64 {
+	start := ts - ts%a.interval
+	if start > ts {
+		start -= a.interval
+	}
+	return start
+}
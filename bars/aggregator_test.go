@@ -0,0 +1,169 @@
+package bars
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/binance"
+	"github.com/bogdanovich/tradekit/bybit"
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(a *Aggregator) []Bar {
+	var bars []Bar
+	for len(a.Bars()) > 0 {
+		bars = append(bars, <-a.Bars())
+	}
+	return bars
+}
+
+func TestTimeBars(t *testing.T) {
+	a := NewTimeAggregator(time.Minute, tk.WithChannelBufferSize(100))
+	a.SetAllowedLateness(5 * time.Second)
+	base := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC).UnixMilli()
+	second := int64(1000)
+
+	a.Update(Trade{Timestamp: base + 10*second, Price: 100, Amount: 1, Buy: true})
+	a.Update(Trade{Timestamp: base + 30*second, Price: 103, Amount: 2})
+	// Out of order trades in the same bar.
+	a.Update(Trade{Timestamp: base + 5*second, Price: 101, Amount: 1, Buy: true})
+	a.Update(Trade{Timestamp: base + 50*second, Price: 99, Amount: 1})
+	a.Update(Trade{Timestamp: base + 62*second, Price: 102, Amount: 1, Buy: true})
+	assert.Empty(t, drain(a))
+	// The first bar stays open for trades within the allowed lateness.
+	a.Update(Trade{Timestamp: base + 59*second, Price: 98, Amount: 1})
+	assert.Empty(t, drain(a))
+
+	a.Update(Trade{Timestamp: base + 66*second, Price: 104, Amount: 1})
+	bars := drain(a)
+	assert.Equal(t, []Bar{{
+		Start:      base,
+		End:        base + 60*second,
+		Open:       101,
+		High:       103,
+		Low:        98,
+		Close:      98,
+		Volume:     6,
+		BuyVolume:  2,
+		SellVolume: 4,
+		Notional:   100 + 206 + 101 + 99 + 98,
+		VWAP:       604.0 / 6,
+		Count:      5,
+	}}, bars)
+
+	a.Update(Trade{Timestamp: base + 58*second, Price: 98, Amount: 1})
+	assert.Equal(t, 1, a.Late())
+
+	// An interval without trades has no bar.
+	a.Update(Trade{Timestamp: base + 190*second, Price: 105, Amount: 1})
+	bars = drain(a)
+	assert.Len(t, bars, 1)
+	assert.Equal(t, base+60*second, bars[0].Start)
+	assert.Equal(t, 104.0, bars[0].Close)
+
+	a.Flush()
+	bars = drain(a)
+	assert.Len(t, bars, 1)
+	assert.Equal(t, base+180*second, bars[0].Start)
+	assert.Equal(t, 1, bars[0].Count)
+}
+
+func TestTimeBarsGapFill(t *testing.T) {
+	a := NewTimeAggregator(time.Hour, tk.WithChannelBufferSize(100))
+	a.SetGapFill(true)
+	day := time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC)
+	hour := time.Hour.Milliseconds()
+
+	a.Update(Trade{Timestamp: day.Add(90 * time.Minute).UnixMilli(), Price: 100, Amount: 1})
+	a.Update(Trade{Timestamp: day.Add(270 * time.Minute).UnixMilli(), Price: 110, Amount: 1})
+	bars := drain(a)
+	assert.Len(t, bars, 3)
+	// Bars are aligned to the hour, and gaps are filled at the previous close.
+	assert.Equal(t, day.Add(time.Hour).UnixMilli(), bars[0].Start)
+	for i, bar := range bars[1:] {
+		assert.Equal(t, Bar{Start: bars[0].Start + int64(i+1)*hour, End: bars[0].Start + int64(i+2)*hour, Open: 100, High: 100, Low: 100, Close: 100, VWAP: 100}, bar)
+	}
+
+	// Bars close as time passes without trades.
+	a.AdvanceTo(day.Add(6 * time.Hour).UnixMilli())
+	bars = drain(a)
+	assert.Len(t, bars, 2)
+	assert.Equal(t, 110.0, bars[0].Close)
+	assert.Equal(t, 110.0, bars[1].Open)
+	assert.Equal(t, day.Add(6*time.Hour).UnixMilli(), bars[1].End)
+}
+
+func TestTickBars(t *testing.T) {
+	a := NewTickAggregator(2)
+	a.Update(Trade{Timestamp: 1, Price: 100, Amount: 1, Buy: true})
+	a.Update(Trade{Timestamp: 2, Price: 101, Amount: 3})
+	a.Update(Trade{Timestamp: 3, Price: 102, Amount: 1})
+	bars := drain(a)
+	assert.Equal(t, []Bar{{
+		Start:      1,
+		End:        2,
+		Open:       100,
+		High:       101,
+		Low:        100,
+		Close:      101,
+		Volume:     4,
+		BuyVolume:  1,
+		SellVolume: 3,
+		Notional:   403,
+		VWAP:       403.0 / 4,
+		Count:      2,
+	}}, bars)
+	a.Flush()
+	assert.Equal(t, 102.0, (<-a.Bars()).Close)
+}
+
+func TestVolumeBars(t *testing.T) {
+	a := NewVolumeAggregator(10)
+	a.Update(Trade{Timestamp: 1, Price: 100, Amount: 4, Buy: true})
+	// The trade fills the first bar, a whole bar, and part of the third.
+	a.Update(Trade{Timestamp: 2, Price: 102, Amount: 18})
+	bars := drain(a)
+	assert.Len(t, bars, 2)
+	assert.Equal(t, 10.0, bars[0].Volume)
+	assert.Equal(t, 4.0, bars[0].BuyVolume)
+	assert.Equal(t, 6.0, bars[0].SellVolume)
+	assert.InDelta(t, (400+612)/10.0, bars[0].VWAP, 1e-12)
+	assert.Equal(t, Bar{Start: 2, End: 2, Open: 102, High: 102, Low: 102, Close: 102, Volume: 10, SellVolume: 10, Notional: 1020, VWAP: 102, Count: 1}, bars[1])
+
+	a.Update(Trade{Timestamp: 3, Price: 101, Amount: 8})
+	bar := <-a.Bars()
+	assert.Equal(t, 10.0, bar.Volume)
+	assert.Equal(t, 2, bar.Count)
+	assert.Empty(t, drain(a))
+}
+
+func TestDollarBars(t *testing.T) {
+	a := NewDollarAggregator(1000)
+	a.Update(Trade{Timestamp: 1, Price: 100, Amount: 3})
+	a.Update(Trade{Timestamp: 2, Price: 200, Amount: 5})
+	bars := drain(a)
+	assert.Len(t, bars, 1)
+	assert.InDelta(t, 1000, bars[0].Notional, 1e-9)
+	assert.InDelta(t, 6.5, bars[0].Volume, 1e-12)
+	assert.InDelta(t, 1000/6.5, bars[0].VWAP, 1e-9)
+}
+
+func TestRun(t *testing.T) {
+	a := NewTickAggregator(1)
+	trades := make(chan Trade, 3)
+	trades <- FromDeribit(deribit.PublicTrade{Timestamp: 1, Price: 100, Amount: 10, Direction: "buy"})
+	trades <- FromBybit(bybit.Trade{Timestamp: 2, Price: 101, Amount: 1, Direction: "Sell"})
+	trades <- FromBinanceAgg(binance.AggTrade{Timestamp: 3, Price: 102, Quantity: 2, IsBuyerMaker: true})
+	close(trades)
+	a.Run(context.Background(), trades)
+
+	bars := drain(a)
+	assert.Len(t, bars, 3)
+	assert.Equal(t, 10.0, bars[0].BuyVolume)
+	assert.Equal(t, 1.0, bars[1].SellVolume)
+	assert.Equal(t, 2.0, bars[2].SellVolume)
+	assert.Equal(t, int64(3), bars[2].Start)
+}
@@ -0,0 +1,49 @@
+// Package bars builds OHLCV bars from the trades of Deribit, Bybit or Binance. Bars
+// include the volume weighted average price, the volume of buy and sell trades, and the
+// number of trades.
+//
+// An [Aggregator] builds time bars aligned to UTC boundaries, tick bars, volume bars or
+// dollar bars from trades converted with FromDeribit, FromBybit, FromBinance or
+// FromBinanceAgg, and emits each bar on a channel when it closes.
+package bars
+
+import (
+	"strings"
+
+	"github.com/bogdanovich/tradekit/binance"
+	"github.com/bogdanovich/tradekit/bybit"
+	"github.com/bogdanovich/tradekit/deribit"
+)
+
+// Trade is a trade of any venue.
+type Trade struct {
+	// Timestamp is the time of the trade in milliseconds.
+	Timestamp int64
+	Price     float64
+	// Amount is the size of the trade in the venue's units, such as USD for Deribit's
+	// inverse futures and the base currency for its options.
+	Amount float64
+	// Buy is true if the taker of the trade was the buyer.
+	Buy bool
+}
+
+// FromDeribit converts a Deribit trade.
+func FromDeribit(t deribit.PublicTrade) Trade {
+	return Trade{Timestamp: t.Timestamp, Price: t.Price, Amount: t.Amount, Buy: t.Direction == "buy"}
+}
+
+// FromBybit converts a Bybit trade.
+func FromBybit(t bybit.Trade) Trade {
+	return Trade{Timestamp: t.Timestamp, Price: t.Price, Amount: t.Amount, Buy: strings.EqualFold(t.Direction, "buy")}
+}
+
+// FromBinance converts a Binance trade. The trade's time is its execution time, rather
+// than the time of the event.
+func FromBinance(t binance.Trade) Trade {
+	return Trade{Timestamp: t.TradeTime, Price: t.Price, Amount: t.Quantity, Buy: !t.IsBuyerMaker}
+}
+
+// FromBinanceAgg converts a Binance aggregate trade.
+func FromBinanceAgg(t binance.AggTrade) Trade {
+	return Trade{Timestamp: t.Timestamp, Price: t.Price, Amount: t.Quantity, Buy: !t.IsBuyerMaker}
+}
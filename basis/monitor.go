@@ -0,0 +1,465 @@
+// Package basis monitors the term structure of futures prices across Deribit, Bybit and
+// Binance: the annualised basis of each dated future to its index, the forward rates
+// implied between expiries, and the annualised funding rates of perpetual futures.
+//
+// A [Monitor] is fed with tickers and mark prices, either from streams with Run or with
+// its Update methods, and emits the term structure after each update and alerts when a
+// basis or funding rate crosses a threshold.
+package basis
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bogdanovich/tradekit/binance"
+	"github.com/bogdanovich/tradekit/bybit"
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+// Venue is the exchange of an instrument.
+type Venue string
+
+const (
+	Deribit Venue = "deribit"
+	Bybit   Venue = "bybit"
+	Binance Venue = "binance"
+)
+
+// year is the length of a year used to annualise rates.
+const year = 365 * 24 * time.Hour
+
+// defaultFundingInterval is the funding interval of perpetuals unless it's set with
+// SetFundingInterval.
+const defaultFundingInterval = 8 * time.Hour
+
+// deliveryHour is the hour, in UTC, at which Binance delivery futures expire.
+const deliveryHour = 8
+
+// FutureBasis is the basis of a dated future to its index.
+type FutureBasis struct {
+	Venue      Venue
+	Instrument string
+	Expiry     time.Time
+	// Timestamp is the time of the update in milliseconds.
+	Timestamp  int64
+	Price      float64
+	IndexPrice float64
+	// Basis is the relative difference of the future's price to the index, (F-S)/S.
+	Basis float64
+	// AnnualisedBasis is the basis divided by the time to expiry in years.
+	AnnualisedBasis float64
+	// ImpliedRate is the continuously compounded annual rate implied by the future,
+	// ln(F/S)/T.
+	ImpliedRate float64
+}
+
+// Funding is the funding rate of a perpetual future.
+type Funding struct {
+	Venue      Venue
+	Instrument string
+	// Timestamp is the time of the update in milliseconds.
+	Timestamp int64
+	// Rate is the funding rate of each funding interval.
+	Rate     float64
+	Interval time.Duration
+	// Annualised is the funding rate over a year of funding intervals.
+	Annualised float64
+}
+
+// ForwardRate is the continuously compounded annual rate implied between the expiries of
+// two consecutive futures of a venue, ln(F2/F1)/(T2-T1).
+type ForwardRate struct {
+	Venue Venue
+	Start time.Time
+	End   time.Time
+	Rate  float64
+}
+
+// TermStructure is a snapshot of the futures monitored by a [Monitor]. Futures and
+// forward rates are ordered by venue and expiry, and funding rates by venue and
+// instrument.
+type TermStructure struct {
+	Futures      []FutureBasis
+	ForwardRates []ForwardRate
+	Funding      []Funding
+}
+
+// AlertType is the type of an [Alert].
+type AlertType string
+
+const (
+	// BasisAbove fires when the annualised basis of a future rises above a level.
+	BasisAbove AlertType = "basis_above"
+	// BasisBelow fires when the annualised basis of a future falls below a level.
+	BasisBelow AlertType = "basis_below"
+	// FundingAbove fires when the annualised funding rate of a perpetual rises above a
+	// level.
+	FundingAbove AlertType = "funding_above"
+	// FundingBelow fires when the annualised funding rate of a perpetual falls below a
+	// level.
+	FundingBelow AlertType = "funding_below"
+)
+
+// Threshold is a level of annualised basis or funding at which an alert fires, such as
+// {Type: FundingAbove, Level: 0.3} for funding above 30% a year.
+type Threshold struct {
+	Type  AlertType
+	Level float64
+}
+
+// Alert is produced when the annualised basis or funding of an instrument crosses a
+// threshold. An alert fires once when its threshold is crossed, and again only after the
+// value has crossed back.
+type Alert struct {
+	Type       AlertType
+	Venue      Venue
+	Instrument string
+	Level      float64
+	Value      float64
+	// Timestamp is the time of the update in milliseconds.
+	Timestamp int64
+}
+
+type instrumentKey struct {
+	venue      Venue
+	instrument string
+}
+
+type alertKey struct {
+	instrumentKey
+	threshold Threshold
+}
+
+// Monitor monitors the basis of dated futures and the funding of perpetual futures. The
+// futures fed to a Monitor from each venue should be on a single underlying, as forward
+// rates are implied between consecutive expiries of a venue. A Monitor is safe for
+// concurrent use.
+type Monitor struct {
+	currency   string
+	thresholds []Threshold
+	updates    chan TermStructure
+	alerts     chan Alert
+
+	mu        sync.Mutex
+	futures   map[instrumentKey]FutureBasis
+	funding   map[instrumentKey]Funding
+	intervals map[instrumentKey]time.Duration
+	crossed   map[alertKey]bool
+}
+
+// NewMonitor creates a Monitor of the Deribit futures on a currency, such as BTC, and the
+// Bybit and Binance futures it's fed. Alerts fire when the annualised basis or funding
+// of any instrument crosses one of the thresholds. The buffer size of the Updates and
+// Alerts channels may be set with tk.WithChannelBufferSize.
+func NewMonitor(currency string, thresholds []Threshold, paramFuncs ...tk.Param) *Monitor {
+	p := tk.ApplyParams(paramFuncs)
+	return &Monitor{
+		currency:   currency,
+		thresholds: thresholds,
+		updates:    make(chan TermStructure, p.ChannelBufferSize),
+		alerts:     make(chan Alert, p.ChannelBufferSize),
+		futures:    make(map[instrumentKey]FutureBasis),
+		funding:    make(map[instrumentKey]Funding),
+		intervals:  make(map[instrumentKey]time.Duration),
+		crossed:    make(map[alertKey]bool),
+	}
+}
+
+// Updates returns a channel which produces the term structure after each update. If the
+// channel's buffer is full the update is dropped, as the next update supersedes it.
+func (m *Monitor) Updates() <-chan TermStructure {
+	return m.updates
+}
+
+// Alerts returns a channel which produces alerts when thresholds are crossed. If the
+// Monitor has thresholds, the channel must be read, otherwise updates will block once
+// its buffer is full.
+func (m *Monitor) Alerts() <-chan Alert {
+	return m.alerts
+}
+
+// SetFundingInterval sets the funding interval of a perpetual, which is 8 hours by
+// default.
+func (m *Monitor) SetFundingInterval(venue Venue, instrument string, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.intervals[instrumentKey{venue, instrument}] = interval
+}
+
+// Sources are the streams a Monitor reads with Run. Any of them may be nil.
+type Sources struct {
+	// DeribitTickers are the tickers of Deribit futures, from deribit.NewTickerStream.
+	DeribitTickers deribit.Stream[deribit.DeribitTicker, deribit.DeribitTickerSub]
+	// BybitTickers are the tickers of Bybit futures, from bybit.NewFuturesTickerStream.
+	BybitTickers bybit.Stream[bybit.FuturesTicker]
+	// BinanceMarkPrices are the mark prices of Binance futures, from
+	// binance.NewMarkPriceStream.
+	BinanceMarkPrices binance.Stream[binance.MarkPrice, binance.MarkPriceSub]
+}
+
+// Run starts the streams of the sources and updates the Monitor with their messages. It
+// blocks until the context is done, returning nil, or a stream fails, returning its
+// error.
+func (m *Monitor) Run(ctx context.Context, s Sources) error {
+	var (
+		deribitMsgs <-chan deribit.DeribitTicker
+		deribitErrs <-chan error
+		bybitMsgs   <-chan bybit.FuturesTicker
+		bybitErrs   <-chan error
+		binanceMsgs <-chan binance.MarkPrice
+		binanceErrs <-chan error
+	)
+	if s.DeribitTickers != nil {
+		if err := s.DeribitTickers.Start(ctx); err != nil {
+			return err
+		}
+		deribitMsgs, deribitErrs = s.DeribitTickers.Messages(), s.DeribitTickers.Err()
+	}
+	if s.BybitTickers != nil {
+		if err := s.BybitTickers.Start(ctx); err != nil {
+			return err
+		}
+		bybitMsgs, bybitErrs = s.BybitTickers.Messages(), s.BybitTickers.Err()
+	}
+	if s.BinanceMarkPrices != nil {
+		if err := s.BinanceMarkPrices.Start(ctx); err != nil {
+			return err
+		}
+		binanceMsgs, binanceErrs = s.BinanceMarkPrices.Messages(), s.BinanceMarkPrices.Err()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t, ok := <-deribitMsgs:
+			if !ok {
+				return nil
+			}
+			m.UpdateDeribitTicker(t)
+		case t, ok := <-bybitMsgs:
+			if !ok {
+				return nil
+			}
+			m.UpdateBybitTicker(t)
+		case p, ok := <-binanceMsgs:
+			if !ok {
+				return nil
+			}
+			m.UpdateBinanceMarkPrice(p)
+		case err := <-deribitErrs:
+			return err
+		case err := <-bybitErrs:
+			return err
+		case err := <-binanceErrs:
+			return err
+		}
+	}
+}
+
+// UpdateDeribitTicker updates the Monitor with the ticker of a Deribit future. Tickers of
+// other currencies and instruments are ignored.
+func (m *Monitor) UpdateDeribitTicker(t deribit.DeribitTicker) {
+	var funding8h float64
+	if t.Funding8h != nil {
+		funding8h = *t.Funding8h
+	}
+	m.updateDeribit(t.Instrument, t.Timestamp, t.MarkPrice, t.IndexPrice, funding8h)
+}
+
+// UpdateDeribitBookSummaries updates the Monitor with the book summaries of Deribit
+// futures, from [deribit.Api.GetBookSummaryByCurrency].
+func (m *Monitor) UpdateDeribitBookSummaries(summaries []deribit.BookSummary) {
+	for _, s := range summaries {
+		// The estimated delivery price of a future is its index price.
+		m.updateDeribit(s.InstrumentName, s.CreationTimestamp, s.MarkPrice, s.EstDeliveryPrice, s.Funding8h)
+	}
+}
+
+func (m *Monitor) updateDeribit(instrument string, ts int64, price, index, funding8h float64) {
+	name, err := deribit.ParseInstrumentName(instrument)
+	if err != nil || name.Kind != deribit.FutureInstrument || name.Base != m.currency {
+		return
+	}
+	if name.Perpetual {
+		m.updateFunding(Deribit, instrument, ts, funding8h, 8*time.Hour)
+	} else {
+		m.updateFuture(Deribit, instrument, name.Expiry, ts, price, index)
+	}
+}
+
+// UpdateBybitTicker updates the Monitor with the ticker of a Bybit future or perpetual.
+func (m *Monitor) UpdateBybitTicker(t bybit.FuturesTicker) {
+	d := t.Data
+	if d.DeliveryTime == "" {
+		m.updateFunding(Bybit, d.Symbol, t.Timestamp, d.FundingRate, 0)
+		return
+	}
+	expiry, err := time.Parse(time.RFC3339, d.DeliveryTime)
+	if err != nil {
+		return
+	}
+	m.updateFuture(Bybit, d.Symbol, expiry, t.Timestamp, d.MarkPrice, d.IndexPrice)
+}
+
+// UpdateBinanceMarkPrice updates the Monitor with the mark price of a Binance perpetual,
+// or of a delivery future such as BTCUSD_250328.
+func (m *Monitor) UpdateBinanceMarkPrice(p binance.MarkPrice) {
+	_, suffix, ok := strings.Cut(p.Symbol, "_")
+	if !ok || suffix == "PERP" {
+		m.updateFunding(Binance, p.Symbol, p.EventTime, p.FundingRate, 0)
+		return
+	}
+	expiry, err := time.Parse("060102", suffix)
+	if err != nil {
+		return
+	}
+	m.updateFuture(Binance, p.Symbol, expiry.Add(deliveryHour*time.Hour), p.EventTime, p.MarkPrice, p.IndexPrice)
+}
+
+func (m *Monitor) updateFuture(venue Venue, instrument string, expiry time.Time, ts int64, price, index float64) {
+	if price <= 0 || index <= 0 {
+		return
+	}
+	key := instrumentKey{venue, instrument}
+	t := float64(expiry.Sub(time.UnixMilli(ts))) / float64(year)
+	m.mu.Lock()
+	if t <= 0 {
+		delete(m.futures, key)
+		m.mu.Unlock()
+		return
+	}
+	b := FutureBasis{
+		Venue:       venue,
+		Instrument:  instrument,
+		Expiry:      expiry,
+		Timestamp:   ts,
+		Price:       price,
+		IndexPrice:  index,
+		Basis:       price/index - 1,
+		ImpliedRate: math.Log(price/index) / t,
+	}
+	b.AnnualisedBasis = b.Basis / t
+	m.futures[key] = b
+	alerts := m.check(key, BasisAbove, BasisBelow, b.AnnualisedBasis, ts)
+	s := m.snapshot()
+	m.mu.Unlock()
+	m.emit(s, alerts)
+}
+
+func (m *Monitor) updateFunding(venue Venue, instrument string, ts int64, rate float64, interval time.Duration) {
+	key := instrumentKey{venue, instrument}
+	m.mu.Lock()
+	if i, ok := m.intervals[key]; ok {
+		interval = i
+	} else if interval == 0 {
+		interval = defaultFundingInterval
+	}
+	f := Funding{
+		Venue:      venue,
+		Instrument: instrument,
+		Timestamp:  ts,
+		Rate:       rate,
+		Interval:   interval,
+		Annualised: rate * float64(year) / float64(interval),
+	}
+	m.funding[key] = f
+	alerts := m.check(key, FundingAbove, FundingBelow, f.Annualised, ts)
+	s := m.snapshot()
+	m.mu.Unlock()
+	m.emit(s, alerts)
+}
+
+// check returns the alerts of thresholds of the above and below types which the value
+// has crossed. The mutex must be held.
+func (m *Monitor) check(key instrumentKey, above, below AlertType, value float64, ts int64) []Alert {
+	var alerts []Alert
+	for _, th := range m.thresholds {
+		var beyond bool
+		switch th.Type {
+		case above:
+			beyond = value > th.Level
+		case below:
+			beyond = value < th.Level
+		default:
+			continue
+		}
+		ak := alertKey{key, th}
+		if beyond && !m.crossed[ak] {
+			alerts = append(alerts, Alert{
+				Type:       th.Type,
+				Venue:      key.venue,
+				Instrument: key.instrument,
+				Level:      th.Level,
+				Value:      value,
+				Timestamp:  ts,
+			})
+		}
+		m.crossed[ak] = beyond
+	}
+	return alerts
+}
+
+func (m *Monitor) emit(s TermStructure, alerts []Alert) {
+	for _, a := range alerts {
+		m.alerts <- a
+	}
+	select {
+	case m.updates <- s:
+	default:
+	}
+}
+
+// Snapshot returns the current term structure.
+func (m *Monitor) Snapshot() TermStructure {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot()
+}
+
+// snapshot returns the current term structure. The mutex must be held.
+func (m *Monitor) snapshot() TermStructure {
+	var ts TermStructure
+	ts.Futures = make([]FutureBasis, 0, len(m.futures))
+	for _, b := range m.futures {
+		ts.Futures = append(ts.Futures, b)
+	}
+	sort.Slice(ts.Futures, func(i, j int) bool {
+		a, b := ts.Futures[i], ts.Futures[j]
+		if a.Venue != b.Venue {
+			return a.Venue < b.Venue
+		}
+		return a.Expiry.Before(b.Expiry)
+	})
+	for i := 1; i < len(ts.Futures); i++ {
+		prev, cur := ts.Futures[i-1], ts.Futures[i]
+		if prev.Venue != cur.Venue || !cur.Expiry.After(prev.Expiry) {
+			continue
+		}
+		dt := float64(cur.Expiry.Sub(prev.Expiry)) / float64(year)
+		ts.ForwardRates = append(ts.ForwardRates, ForwardRate{
+			Venue: cur.Venue,
+			Start: prev.Expiry,
+			End:   cur.Expiry,
+			Rate:  math.Log(cur.Price/prev.Price) / dt,
+		})
+	}
+
+	ts.Funding = make([]Funding, 0, len(m.funding))
+	for _, f := range m.funding {
+		ts.Funding = append(ts.Funding, f)
+	}
+	sort.Slice(ts.Funding, func(i, j int) bool {
+		a, b := ts.Funding[i], ts.Funding[j]
+		if a.Venue != b.Venue {
+			return a.Venue < b.Venue
+		}
+		return a.Instrument < b.Instrument
+	})
+	return ts
+}
@@ -0,0 +1,124 @@
+package basis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/binance"
+	"github.com/bogdanovich/tradekit/bybit"
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorBasis(t *testing.T) {
+	m := NewMonitor("BTC", nil)
+	now := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC)
+	dec := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	ts := now.UnixMilli()
+
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: ts, Instrument: "BTC-27DEC24", MarkPrice: 91000, IndexPrice: 90000})
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: ts, Instrument: "BTC-28MAR25", MarkPrice: 93000, IndexPrice: 90000})
+	// Other currencies and options are ignored.
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: ts, Instrument: "ETH-27DEC24", MarkPrice: 3100, IndexPrice: 3000})
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: ts, Instrument: "BTC-27DEC24-90000-C", MarkPrice: 0.05, IndexPrice: 90000})
+
+	m.UpdateBinanceMarkPrice(binance.MarkPrice{EventTime: ts, Symbol: "BTCUSD_250328", MarkPrice: 92800, IndexPrice: 90010})
+	m.UpdateBybitTicker(bybit.FuturesTicker{Timestamp: ts, Data: bybit.FuturesTickerData{
+		Symbol: "BTC-27DEC24", MarkPrice: 90900, IndexPrice: 89990, DeliveryTime: "2024-12-27T08:00:00Z",
+	}})
+
+	s := m.Snapshot()
+	assert.Len(t, s.Futures, 4)
+	assert.Equal(t, []Venue{Binance, Bybit, Deribit, Deribit}, []Venue{s.Futures[0].Venue, s.Futures[1].Venue, s.Futures[2].Venue, s.Futures[3].Venue})
+	assert.Equal(t, mar, s.Futures[0].Expiry)
+	assert.Equal(t, dec, s.Futures[1].Expiry)
+
+	decT := 42.0 / 365
+	b := s.Futures[2]
+	assert.Equal(t, "BTC-27DEC24", b.Instrument)
+	assert.InDelta(t, 1000.0/90000, b.Basis, 1e-12)
+	assert.InDelta(t, 1000.0/90000/decT, b.AnnualisedBasis, 1e-12)
+	assert.InDelta(t, math.Log(91000.0/90000)/decT, b.ImpliedRate, 1e-12)
+
+	assert.Equal(t, []ForwardRate{{
+		Venue: Deribit,
+		Start: dec,
+		End:   mar,
+		Rate:  math.Log(93000.0/91000) / (91.0 / 365),
+	}}, s.ForwardRates)
+
+	// Expired futures are removed.
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: dec.UnixMilli(), Instrument: "BTC-27DEC24", MarkPrice: 91000, IndexPrice: 91000})
+	assert.Len(t, m.Snapshot().Futures, 3)
+}
+
+func TestMonitorFunding(t *testing.T) {
+	m := NewMonitor("BTC", nil)
+	m.SetFundingInterval(Binance, "BTCUSDT", 4*time.Hour)
+
+	m.UpdateDeribitTicker(deribit.DeribitTicker{Timestamp: 1, Instrument: "BTC-PERPETUAL", Funding8h: ptr(0.0001)})
+	m.UpdateBybitTicker(bybit.FuturesTicker{Timestamp: 2, Data: bybit.FuturesTickerData{Symbol: "BTCUSDT", FundingRate: 0.0002}})
+	m.UpdateBinanceMarkPrice(binance.MarkPrice{EventTime: 3, Symbol: "BTCUSDT", FundingRate: 0.0001})
+	m.UpdateBinanceMarkPrice(binance.MarkPrice{EventTime: 4, Symbol: "BTCUSD_PERP", FundingRate: -0.0001})
+
+	s := m.Snapshot()
+	assert.Empty(t, s.Futures)
+	assert.Equal(t, []Funding{
+		{Venue: Binance, Instrument: "BTCUSDT", Timestamp: 3, Rate: 0.0001, Interval: 4 * time.Hour, Annualised: 0.0001 * 6 * 365},
+		{Venue: Binance, Instrument: "BTCUSD_PERP", Timestamp: 4, Rate: -0.0001, Interval: 8 * time.Hour, Annualised: -0.0001 * 3 * 365},
+		{Venue: Bybit, Instrument: "BTCUSDT", Timestamp: 2, Rate: 0.0002, Interval: 8 * time.Hour, Annualised: 0.0002 * 3 * 365},
+		{Venue: Deribit, Instrument: "BTC-PERPETUAL", Timestamp: 1, Rate: 0.0001, Interval: 8 * time.Hour, Annualised: 0.0001 * 3 * 365},
+	}, s.Funding)
+}
+
+func TestMonitorAlerts(t *testing.T) {
+	m := NewMonitor("BTC", []Threshold{
+		{Type: FundingAbove, Level: 0.3},
+		{Type: FundingBelow, Level: -0.1},
+		{Type: BasisBelow, Level: 0},
+	})
+	funding := func(ts int64, rate float64) {
+		m.UpdateBybitTicker(bybit.FuturesTicker{Timestamp: ts, Data: bybit.FuturesTickerData{Symbol: "BTCUSDT", FundingRate: rate}})
+	}
+
+	funding(1, 0.0001)
+	funding(2, 0.0003)
+	// The alert doesn't fire again until the funding falls back below the threshold.
+	funding(3, 0.0004)
+	funding(4, 0.0001)
+	funding(5, 0.0003)
+	funding(6, -0.0001)
+
+	// Backwardation.
+	expiry := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	m.UpdateDeribitTicker(deribit.DeribitTicker{
+		Timestamp:  expiry.Add(-30 * 24 * time.Hour).UnixMilli(),
+		Instrument: "BTC-27DEC24",
+		MarkPrice:  89000,
+		IndexPrice: 90000,
+	})
+
+	var alerts []Alert
+	for len(m.Alerts()) > 0 {
+		alerts = append(alerts, <-m.Alerts())
+	}
+	assert.Len(t, alerts, 4)
+	assert.Equal(t, Alert{Type: FundingAbove, Venue: Bybit, Instrument: "BTCUSDT", Level: 0.3, Value: 0.0003 * 3 * 365, Timestamp: 2}, alerts[0])
+	assert.Equal(t, int64(5), alerts[1].Timestamp)
+	assert.Equal(t, FundingBelow, alerts[2].Type)
+	assert.Equal(t, int64(6), alerts[2].Timestamp)
+	assert.Equal(t, BasisBelow, alerts[3].Type)
+	assert.Equal(t, Deribit, alerts[3].Venue)
+	assert.InDelta(t, -1000.0/90000/(30.0/365), alerts[3].Value, 1e-12)
+
+	// The latest term structure is available on the Updates channel.
+	assert.NotEmpty(t, m.Updates())
+	update := <-m.Updates()
+	assert.Len(t, update.Funding, 1)
+}
+
+func ptr(x float64) *float64 {
+	return &x
+}
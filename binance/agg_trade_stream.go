@@ -2,15 +2,16 @@ package binance
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
-	"github.com/bogdanovich/tradekit/internal/websocket"
+	"github.com/bogdanovich/tradekit/lib/tk"
 	"github.com/valyala/fastjson"
 )
 
+// AggTrade is the type produced by a Binance aggregate trade stream.
 type AggTrade struct {
 	EventTime    int64   `json:"E"`
 	Symbol       string  `json:"s"`
@@ -24,51 +25,44 @@ type AggTrade struct {
 	M            bool    `json:"M"`
 }
 
-// AggTradesStream connects to the Binance Aggregate Trade Streams websocket.
-type AggTradeStream struct {
-	url     string
-	symbols []string
-	msgs    chan AggTrade
-	errc    chan error
-	p       fastjson.Parser
-	subIds  map[int64]struct{}
+// AggTradeSub represents a subscription to the aggregate trades of a symbol. See
+// [NewAggTradeStream].
+type AggTradeSub struct {
+	Symbol string
 }
 
-func NewAggTradeStream(url string, symbols ...string) *AggTradeStream {
-	errc := make(chan error, 1)
-	msgs := make(chan AggTrade, 10)
-	subIds := make(map[int64]struct{})
-	return &AggTradeStream{url: url, symbols: symbols, msgs: msgs, errc: errc, subIds: subIds}
+func (s AggTradeSub) channel() string {
+	return fmt.Sprintf("%s@aggTrade", strings.ToLower(s.Symbol))
 }
 
-func (s *AggTradeStream) parseAggTradeMsg(msg websocket.Message) (AggTrade, error) {
-	defer msg.Release()
-	v, err := s.p.ParseBytes(msg.Data())
-	if err != nil {
-		return AggTrade{}, streamParseErr("aggTrade", msg.Data(), err)
-	}
-
-	if id := v.GetInt64("id"); id != 0 {
-		// This should be a subscription response message
-		if _, ok := s.subIds[id]; ok {
-			delete(s.subIds, id)
-			return AggTrade{}, nil
-		}
-		return AggTrade{}, streamParseErr("aggTrade", msg.Data(), errors.New("expected a agg trade message"))
+// NewAggTradeStream creates a new [Stream] which produces a stream of aggregated trades.
+// Updates on a 100ms interval. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#aggregate-trade-streams
+//   - https://binance-docs.github.io/apidocs/futures/en/#aggregate-trade-streams
+func NewAggTradeStream(wsUrl string, subs []AggTradeSub, paramFuncs ...tk.Param) Stream[AggTrade, AggTradeSub] {
+	p := streamParams[AggTrade, AggTradeSub]{
+		name:         "AggTradeStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseAggTrade),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
 	}
+	return newStream(p)
+}
 
+func parseAggTrade(v *fastjson.Value) (AggTrade, error) {
 	eventType := v.GetStringBytes("e")
 	if !bytes.Equal(eventType, []byte("aggTrade")) {
-		return AggTrade{}, streamParseErr("trade", msg.Data(), errors.New("expected aggTrade type"))
+		return AggTrade{}, errors.New("expected aggTrade type")
 	}
 
 	price, err := strconv.ParseFloat(string(v.GetStringBytes("p")), 64)
 	if err != nil {
-		return AggTrade{}, streamParseErr("aggTrade", msg.Data(), errors.New("invalid price"))
+		return AggTrade{}, errors.New("invalid price")
 	}
 	quantity, err := strconv.ParseFloat(string(v.GetStringBytes("q")), 64)
 	if err != nil {
-		return AggTrade{}, streamParseErr("aggTrade", msg.Data(), errors.New("invalid quanity"))
+		return AggTrade{}, errors.New("invalid quantity")
 	}
 
 	return AggTrade{
@@ -84,55 +78,3 @@ func (s *AggTradeStream) parseAggTradeMsg(msg websocket.Message) (AggTrade, erro
 		M:            v.GetBool("M"),
 	}, nil
 }
-
-func (s *AggTradeStream) Start(ctx context.Context) error {
-	// Websocket setup
-	ws := websocket.New(s.url, nil)
-	ws.OnConnect = func() error {
-		channels := make([]string, len(s.symbols))
-		for i, symbol := range s.symbols {
-			channels[i] = fmt.Sprintf("%s@aggTrade", symbol)
-		}
-		id := genId()
-		subMsg, err := newSubscribeMsg(id, channels)
-		if err != nil {
-			return streamError("AggTrade", err)
-		}
-		s.subIds[id] = struct{}{}
-		ws.Send(subMsg)
-		return nil
-	}
-	if err := ws.Start(ctx); err != nil {
-		return streamError("AggTradeStream", fmt.Errorf("websocket connect: %w", err))
-	}
-
-	go func() {
-		defer close(s.msgs)
-		for {
-			select {
-			case data := <-ws.Messages():
-				msg, err := s.parseAggTradeMsg(data)
-				if err != nil {
-					s.errc <- streamError("AggTradeStream", err)
-					return
-				}
-				if msg.Symbol != "" {
-					s.msgs <- msg
-				}
-			case err := <-ws.Err():
-				s.errc <- streamError("AggTradeStream", err)
-				return
-			}
-		}
-	}()
-
-	return nil
-}
-
-func (s *AggTradeStream) Messages() <-chan AggTrade {
-	return s.msgs
-}
-
-func (s *AggTradeStream) Err() <-chan error {
-	return s.errc
-}
@@ -1,13 +1,20 @@
 package binance
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/internal/rest"
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
 	"github.com/valyala/fastjson"
 )
 
@@ -25,6 +32,10 @@ type Api struct {
 	baseUrl *url.URL
 	market  Market
 	pools   map[string]*fastjson.ParserPool
+	signer  *signer
+
+	client      *rest.Client
+	weightLimit int
 }
 
 // Error is the type returned when the Binance API responds with an error.
@@ -41,14 +52,35 @@ func (e Error) Error() string {
 	return fmt.Sprintf("Binance API error (%d): %s [HTTP %d]", e.Code, e.Msg, e.HttpCode)
 }
 
+// Request weight limits per minute of each market.
+const (
+	spotWeightLimit    = 6000
+	futuresWeightLimit = 2400
+)
+
 // NewApi creates a new Binance Api. The provided Market, should match the baseUrl.
-func NewApi(baseUrl string, market Market) (*Api, error) {
+//
+// Requests are rate limited by the request weight limit of the market, which is kept in
+// sync with the used weight that Binance reports in each response. The HTTP client,
+// request timeout and number of retries may be configured with the params.
+func NewApi(baseUrl string, market Market, paramFuncs ...tk.Param) (*Api, error) {
 	u, err := url.Parse(baseUrl)
 	if err != nil {
 		return nil, fmt.Errorf("invalid apiUrl: %s", baseUrl)
 	}
+	weightLimit := futuresWeightLimit
+	if market == Spot {
+		weightLimit = spotWeightLimit
+	}
+	limiter := rest.NewLimiter(float64(weightLimit)/60, float64(weightLimit))
 	pools := make(map[string]*fastjson.ParserPool)
-	return &Api{baseUrl: u, market: market, pools: pools}, nil
+	return &Api{
+		baseUrl:     u,
+		market:      market,
+		pools:       pools,
+		client:      rest.NewClient(limiter, tk.ApplyParams(paramFuncs)),
+		weightLimit: weightLimit,
+	}, nil
 }
 
 // getParser returns a JSON parser for the provided endpoint.
@@ -79,7 +111,7 @@ type OrderbookResponse struct {
 
 // GetOrderbook gets a snapshot of an orderbook from the Binance API for a given
 // symbol and depth limit.
-func (api *Api) GetOrderbook(symbol string, limit int) (OrderbookResponse, error) {
+func (api *Api) GetOrderbook(ctx context.Context, symbol string, limit int) (OrderbookResponse, error) {
 	var endpoint string
 	switch api.market {
 	case Spot:
@@ -90,47 +122,592 @@ func (api *Api) GetOrderbook(symbol string, limit int) (OrderbookResponse, error
 		endpoint = "/dapi/v1/depth"
 	}
 
-	return apiGet[OrderbookResponse](api, endpoint, map[string]string{"symbol": symbol, "limit": strconv.Itoa(limit)})
+	params := map[string]string{"symbol": symbol, "limit": strconv.Itoa(limit)}
+	return apiGet[OrderbookResponse](ctx, api, endpoint, params, depthWeight(api.market, limit))
+}
+
+// depthWeight returns the request weight of an orderbook snapshot with a depth limit.
+func depthWeight(market Market, limit int) int {
+	if market == Spot {
+		switch {
+		case limit <= 100:
+			return 5
+		case limit <= 500:
+			return 25
+		case limit <= 1000:
+			return 50
+		default:
+			return 250
+		}
+	}
+	switch {
+	case limit <= 50:
+		return 2
+	case limit <= 100:
+		return 5
+	case limit <= 500:
+		return 10
+	default:
+		return 20
+	}
 }
 
-func urlWithParams(baseUrl *url.URL, path string, params map[string]string) string {
-	u := baseUrl.JoinPath(path)
+func encodeParams(params map[string]string) string {
 	values := url.Values{}
 	for k, v := range params {
 		values.Set(k, v)
 	}
-	u.RawQuery = values.Encode()
-	return u.String()
+	return values.Encode()
 }
 
 func apiErr(endpoint string, err error) error {
 	return fmt.Errorf("Binance API error %s: %w", endpoint, err)
 }
 
-func apiGet[T any](api *Api, endpoint string, params map[string]string) (T, error) {
-	u := urlWithParams(api.baseUrl, endpoint, params)
+func apiGet[T any](ctx context.Context, api *Api, endpoint string, params map[string]string, weight int) (T, error) {
+	return apiRequest[T](ctx, api, http.MethodGet, endpoint, params, securityNone, weight)
+}
 
-	r, err := http.Get(u)
-	if err != nil {
-		var zero T
-		return zero, apiErr(endpoint, err)
+// securityType describes the authentication required by an endpoint.
+type securityType int
+
+const (
+	securityNone securityType = iota
+	// securityApiKey endpoints require the API key header.
+	securityApiKey
+	// securitySigned endpoints require the API key header and a signature of the
+	// request parameters.
+	securitySigned
+)
+
+// apiRequest sends a request to an endpoint with the given request weight, which is its
+// cost towards the Api's weight limit.
+func apiRequest[T any](ctx context.Context, api *Api, method, endpoint string, params map[string]string, security securityType, weight int) (T, error) {
+	var zero T
+	if security != securityNone && api.signer == nil {
+		return zero, apiErr(endpoint, errors.New("credentials are required"))
 	}
-	defer r.Body.Close()
 
-	if r.StatusCode < 300 {
-		var resp T
-		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
-			var zero T
-			return zero, apiErr(endpoint, err)
+	newRequest := func() (*http.Request, error) {
+		u := api.baseUrl.JoinPath(endpoint)
+		if security == securitySigned {
+			u.RawQuery = api.signer.signedQuery(params, time.Now())
+		} else {
+			u.RawQuery = encodeParams(params)
+		}
+		req, err := http.NewRequest(method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if security != securityNone {
+			req.Header.Set("X-MBX-APIKEY", api.signer.apiKey)
+		}
+		return req, nil
+	}
+
+	var resp T
+	err := api.client.Do(ctx, float64(weight), newRequest, func(r *http.Response) error {
+		api.observeUsedWeight(r.Header)
+		if r.StatusCode < 300 {
+			return json.NewDecoder(r.Body).Decode(&resp)
 		}
-		return resp, nil
-	} else {
-		var zero T
 		var e Error
 		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
-			return zero, apiErr(endpoint, err)
+			return err
 		}
 		e.HttpCode = r.StatusCode
-		return zero, apiErr(endpoint, e)
+		return e
+	})
+	if err != nil {
+		return zero, apiErr(endpoint, err)
+	}
+	return resp, nil
+}
+
+// observeUsedWeight updates the Api's rate limiter with the request weight which Binance
+// reports has been used in the current minute.
+func (api *Api) observeUsedWeight(h http.Header) {
+	used, err := strconv.ParseFloat(h.Get("X-MBX-USED-WEIGHT-1M"), 64)
+	if err != nil {
+		return
+	}
+	api.client.Limiter().Observe(float64(api.weightLimit) - used)
+}
+
+// Iterator allows for iteration over paginated methods on the Binance API.
+type Iterator[T any] interface {
+	// Done returns true when there are no more results in the iterator.
+	Done() bool
+	// Next returns the next batch of items from the iterator. You should stop calling
+	// Next after Done returns true.
+	Next(ctx context.Context) (T, error)
+}
+
+// endpoint returns the endpoint for the Api's market. An empty path means the endpoint
+// is unavailable on that market.
+func (api *Api) endpoint(spot, perpetual, inversePerpetual string) (string, error) {
+	var endpoint string
+	switch api.market {
+	case Spot:
+		endpoint = spot
+	case Perpetual:
+		endpoint = perpetual
+	case InversePerpetual:
+		endpoint = inversePerpetual
+	}
+	if endpoint == "" {
+		return "", errors.New("endpoint is not available on this market")
+	}
+	return endpoint, nil
+}
+
+// weight returns the request weight of an endpoint on the Api's market.
+func (api *Api) weight(spot, perpetual, inversePerpetual int) int {
+	switch api.market {
+	case Spot:
+		return spot
+	case Perpetual:
+		return perpetual
+	default:
+		return inversePerpetual
+	}
+}
+
+// ExchangeInfo is the type returned by GetExchangeInfo.
+type ExchangeInfo struct {
+	Timezone   string       `json:"timezone"`
+	ServerTime int64        `json:"serverTime"`
+	Symbols    []SymbolInfo `json:"symbols"`
+}
+
+// Symbol returns the SymbolInfo of a trading symbol.
+func (e ExchangeInfo) Symbol(symbol string) (SymbolInfo, bool) {
+	for _, s := range e.Symbols {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return SymbolInfo{}, false
+}
+
+// SymbolInfo holds the trading rules of a symbol. The TickSize, StepSize and MinNotional
+// etc. fields are taken from the symbol's Filters.
+type SymbolInfo struct {
+	Symbol     string `json:"symbol"`
+	Pair       string `json:"pair"`
+	Status     string `json:"status"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+	// The following fields are only set on futures markets.
+	MarginAsset    string  `json:"marginAsset"`
+	ContractType   string  `json:"contractType"`
+	ContractStatus string  `json:"contractStatus"`
+	ContractSize   float64 `json:"contractSize"`
+	DeliveryDate   int64   `json:"deliveryDate"`
+	OnboardDate    int64   `json:"onboardDate"`
+
+	Filters []SymbolFilter `json:"filters"`
+
+	MinPrice    float64 `json:"-"`
+	MaxPrice    float64 `json:"-"`
+	TickSize    float64 `json:"-"`
+	MinQty      float64 `json:"-"`
+	MaxQty      float64 `json:"-"`
+	StepSize    float64 `json:"-"`
+	MinNotional float64 `json:"-"`
+}
+
+// SymbolFilter is a trading rule of a symbol. Only the fields relevant to the filter's
+// FilterType are set. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#filters
+type SymbolFilter struct {
+	FilterType string `json:"filterType"`
+	// PRICE_FILTER
+	MinPrice float64 `json:"minPrice,string"`
+	MaxPrice float64 `json:"maxPrice,string"`
+	TickSize float64 `json:"tickSize,string"`
+	// LOT_SIZE & MARKET_LOT_SIZE
+	MinQty   float64 `json:"minQty,string"`
+	MaxQty   float64 `json:"maxQty,string"`
+	StepSize float64 `json:"stepSize,string"`
+	// MIN_NOTIONAL & NOTIONAL. Futures markets use Notional rather than MinNotional.
+	MinNotional float64 `json:"minNotional,string"`
+	Notional    float64 `json:"notional,string"`
+}
+
+func (s *SymbolInfo) UnmarshalJSON(data []byte) error {
+	type alias SymbolInfo
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*s = SymbolInfo(a)
+	for _, f := range s.Filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			s.MinPrice = f.MinPrice
+			s.MaxPrice = f.MaxPrice
+			s.TickSize = f.TickSize
+		case "LOT_SIZE":
+			s.MinQty = f.MinQty
+			s.MaxQty = f.MaxQty
+			s.StepSize = f.StepSize
+		case "MIN_NOTIONAL", "NOTIONAL":
+			if f.MinNotional != 0 {
+				s.MinNotional = f.MinNotional
+			} else {
+				s.MinNotional = f.Notional
+			}
+		}
+	}
+	return nil
+}
+
+// GetExchangeInfo returns the trading rules and symbol information of the Api's market.
+// For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#exchange-information
+//   - https://binance-docs.github.io/apidocs/futures/en/#exchange-information
+func (api *Api) GetExchangeInfo(ctx context.Context) (ExchangeInfo, error) {
+	endpoint, _ := api.endpoint("/api/v3/exchangeInfo", "/fapi/v1/exchangeInfo", "/dapi/v1/exchangeInfo")
+	return apiGet[ExchangeInfo](ctx, api, endpoint, nil, api.weight(20, 1, 1))
+}
+
+// GetKlinesOptions define optional parameters for GetKlines.
+type GetKlinesOptions struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// Limit is the maximum number of klines to return. Defaults to 500.
+	Limit int
+}
+
+// klineRow decodes a kline from the array format returned by the klines endpoint.
+type klineRow Kline
+
+func (k *klineRow) UnmarshalJSON(data []byte) error {
+	var row []json.RawMessage
+	if err := json.Unmarshal(data, &row); err != nil {
+		return err
+	}
+	if len(row) < 11 {
+		return fmt.Errorf("invalid kline: %s", string(data))
+	}
+	ints := []*int64{&k.StartTime, &k.CloseTime, &k.NumTrades}
+	for i, idx := range []int{0, 6, 8} {
+		if err := json.Unmarshal(row[idx], ints[i]); err != nil {
+			return fmt.Errorf("invalid kline: %s", string(data))
+		}
+	}
+	floats := []*float64{&k.Open, &k.High, &k.Low, &k.Close, &k.Volume, &k.QuoteVolume, &k.TakerBuyVolume, &k.TakerBuyQuoteVolume}
+	for i, idx := range []int{1, 2, 3, 4, 5, 7, 9, 10} {
+		var s string
+		if err := json.Unmarshal(row[idx], &s); err != nil {
+			return fmt.Errorf("invalid kline: %s", string(data))
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid kline: %s", string(data))
+		}
+		*floats[i] = f
+	}
+	return nil
+}
+
+// klinesWeight returns the request weight of klines with a limit, where zero is the
+// default limit of 500.
+func klinesWeight(market Market, limit int) int {
+	if market == Spot {
+		return 2
+	}
+	if limit == 0 {
+		limit = 500
+	}
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// GetKlines returns klines (candlesticks) for a symbol at a given interval, in ascending
+// order of time. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#kline-candlestick-data
+//   - https://binance-docs.github.io/apidocs/futures/en/#kline-candlestick-data
+func (api *Api) GetKlines(ctx context.Context, symbol string, interval KlineInterval, opts *GetKlinesOptions) ([]Kline, error) {
+	endpoint, _ := api.endpoint("/api/v3/klines", "/fapi/v1/klines", "/dapi/v1/klines")
+	params := map[string]string{"symbol": symbol, "interval": string(interval)}
+	if opts != nil {
+		if !opts.StartTime.IsZero() {
+			params["startTime"] = strconv.FormatInt(opts.StartTime.UnixMilli(), 10)
+		}
+		if !opts.EndTime.IsZero() {
+			params["endTime"] = strconv.FormatInt(opts.EndTime.UnixMilli(), 10)
+		}
+		if opts.Limit != 0 {
+			params["limit"] = strconv.Itoa(opts.Limit)
+		}
+	}
+	limit := 0
+	if opts != nil {
+		limit = opts.Limit
+	}
+	rows, err := apiGet[[]klineRow](ctx, api, endpoint, params, klinesWeight(api.market, limit))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	klines := make([]Kline, len(rows))
+	for i, row := range rows {
+		klines[i] = Kline(row)
+		klines[i].Symbol = symbol
+		klines[i].Interval = interval
+		klines[i].IsClosed = klines[i].CloseTime < now
+	}
+	return klines, nil
+}
+
+// GetAggTradesOptions define optional parameters for GetAggTrades. If neither FromId nor
+// StartTime are set, the iterator returns the most recent trades only.
+type GetAggTradesOptions struct {
+	// FromId is the aggregate trade id to begin iterating from, inclusive.
+	FromId int64
+
+	// StartTime and EndTime define the timeframe over which trades will be returned.
+	// StartTime is ignored if FromId is set.
+	StartTime time.Time
+	EndTime   time.Time
+
+	// Limit defines the number of trades to return per pagination request. Defaults to
+	// 500. The maximum is 1000.
+	Limit int
+}
+
+type aggTradesIterator struct {
+	done   bool
+	api    *Api
+	symbol string
+	opts   GetAggTradesOptions
+	// nextId is the id of the first trade of the next request. Only valid if paging is
+	// true.
+	nextId int64
+	paging bool
+}
+
+func (it *aggTradesIterator) Done() bool {
+	return it.done
+}
+
+func (it *aggTradesIterator) params() map[string]string {
+	params := map[string]string{"symbol": it.symbol, "limit": strconv.Itoa(it.opts.Limit)}
+	if it.paging {
+		params["fromId"] = strconv.FormatInt(it.nextId, 10)
+	} else if !it.opts.StartTime.IsZero() {
+		// We only send the startTime, because Binance requires that the time between
+		// startTime and endTime is less than 1 hour. The trades are filtered by endTime
+		// locally instead.
+		params["startTime"] = strconv.FormatInt(it.opts.StartTime.UnixMilli(), 10)
+	}
+	return params
+}
+
+func (it *aggTradesIterator) Next(ctx context.Context) ([]AggTrade, error) {
+	endpoint, _ := it.api.endpoint("/api/v3/aggTrades", "/fapi/v1/aggTrades", "/dapi/v1/aggTrades")
+	trades, err := apiGet[[]AggTrade](ctx, it.api, endpoint, it.params(), it.api.weight(2, 20, 20))
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+
+	if len(trades) < it.opts.Limit || (!it.paging && it.opts.StartTime.IsZero()) {
+		it.done = true
+	}
+	if !it.opts.EndTime.IsZero() {
+		end := it.opts.EndTime.UnixMilli()
+		for i, t := range trades {
+			if t.Timestamp > end {
+				trades = trades[:i]
+				it.done = true
+				break
+			}
+		}
+	}
+	for i := range trades {
+		trades[i].Symbol = it.symbol
+	}
+	if len(trades) > 0 {
+		it.nextId = trades[len(trades)-1].AggTradeId + 1
+		it.paging = true
+	}
+
+	return trades, nil
+}
+
+// GetAggTrades returns an iterator over the aggregate trades of a symbol, in ascending
+// order of time. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#compressed-aggregate-trades-list
+//   - https://binance-docs.github.io/apidocs/futures/en/#compressed-aggregate-trades-list
+func (api *Api) GetAggTrades(symbol string, opts *GetAggTradesOptions) Iterator[[]AggTrade] {
+	var options GetAggTradesOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Limit == 0 {
+		options.Limit = 500
+	}
+	it := &aggTradesIterator{api: api, symbol: symbol, opts: options}
+	if options.FromId != 0 {
+		it.nextId = options.FromId
+		it.paging = true
+	}
+	return it
+}
+
+// PremiumIndex is the type returned by GetPremiumIndex. It holds the mark price and
+// funding rate of a futures symbol.
+type PremiumIndex struct {
+	Symbol               string
+	Pair                 string
+	MarkPrice            float64
+	IndexPrice           float64
+	EstimatedSettlePrice float64
+	LastFundingRate      float64
+	InterestRate         float64
+	NextFundingTime      int64
+	Time                 int64
+}
+
+func (p *PremiumIndex) UnmarshalJSON(data []byte) error {
+	// Delivery contracts have empty strings in place of funding fields, so we can't
+	// use the ",string" option.
+	var aux struct {
+		Symbol               string `json:"symbol"`
+		Pair                 string `json:"pair"`
+		MarkPrice            string `json:"markPrice"`
+		IndexPrice           string `json:"indexPrice"`
+		EstimatedSettlePrice string `json:"estimatedSettlePrice"`
+		LastFundingRate      string `json:"lastFundingRate"`
+		InterestRate         string `json:"interestRate"`
+		NextFundingTime      int64  `json:"nextFundingTime"`
+		Time                 int64  `json:"time"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*p = PremiumIndex{
+		Symbol:               aux.Symbol,
+		Pair:                 aux.Pair,
+		MarkPrice:            conv.BytesToFloat([]byte(aux.MarkPrice)),
+		IndexPrice:           conv.BytesToFloat([]byte(aux.IndexPrice)),
+		EstimatedSettlePrice: conv.BytesToFloat([]byte(aux.EstimatedSettlePrice)),
+		LastFundingRate:      conv.BytesToFloat([]byte(aux.LastFundingRate)),
+		InterestRate:         conv.BytesToFloat([]byte(aux.InterestRate)),
+		NextFundingTime:      aux.NextFundingTime,
+		Time:                 aux.Time,
+	}
+	return nil
+}
+
+// oneOrMany decodes a JSON response which may either be a single object or an array of
+// objects.
+type oneOrMany[T any] []T
+
+func (m *oneOrMany[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var items []T
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		*m = items
+		return nil
+	}
+	var item T
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*m = []T{item}
+	return nil
+}
+
+// GetPremiumIndex returns the mark price and funding rate of a futures symbol. If the
+// symbol is empty, it returns all symbols. Only available on the Perpetual and
+// InversePerpetual markets. For details see:
+//   - https://binance-docs.github.io/apidocs/futures/en/#mark-price
+//   - https://binance-docs.github.io/apidocs/delivery/en/#index-price-and-mark-price
+func (api *Api) GetPremiumIndex(ctx context.Context, symbol string) ([]PremiumIndex, error) {
+	endpoint, err := api.endpoint("", "/fapi/v1/premiumIndex", "/dapi/v1/premiumIndex")
+	if err != nil {
+		return nil, apiErr("premiumIndex", err)
+	}
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	return apiGet[oneOrMany[PremiumIndex]](ctx, api, endpoint, params, api.weight(0, 1, 10))
+}
+
+// FundingRate is the type returned by GetFundingRateHistory.
+type FundingRate struct {
+	Symbol      string  `json:"symbol"`
+	FundingTime int64   `json:"fundingTime"`
+	FundingRate float64 `json:"fundingRate,string"`
+}
+
+// GetFundingRateHistoryOptions define optional parameters for GetFundingRateHistory.
+type GetFundingRateHistoryOptions struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// Limit is the maximum number of funding rates to return. Defaults to 100. The
+	// maximum is 1000.
+	Limit int
+}
+
+// GetFundingRateHistory returns the historical funding rates of a perpetual futures
+// symbol, in ascending order of time. Only available on the Perpetual and
+// InversePerpetual markets. For details see:
+//   - https://binance-docs.github.io/apidocs/futures/en/#get-funding-rate-history
+//   - https://binance-docs.github.io/apidocs/delivery/en/#get-funding-rate-history-of-perpetual-futures
+func (api *Api) GetFundingRateHistory(ctx context.Context, symbol string, opts *GetFundingRateHistoryOptions) ([]FundingRate, error) {
+	endpoint, err := api.endpoint("", "/fapi/v1/fundingRate", "/dapi/v1/fundingRate")
+	if err != nil {
+		return nil, apiErr("fundingRate", err)
+	}
+	params := map[string]string{"symbol": symbol}
+	if opts != nil {
+		if !opts.StartTime.IsZero() {
+			params["startTime"] = strconv.FormatInt(opts.StartTime.UnixMilli(), 10)
+		}
+		if !opts.EndTime.IsZero() {
+			params["endTime"] = strconv.FormatInt(opts.EndTime.UnixMilli(), 10)
+		}
+		if opts.Limit != 0 {
+			params["limit"] = strconv.Itoa(opts.Limit)
+		}
+	}
+	return apiGet[[]FundingRate](ctx, api, endpoint, params, 1)
+}
+
+// OpenInterest is the type returned by GetOpenInterest.
+type OpenInterest struct {
+	Symbol       string  `json:"symbol"`
+	Pair         string  `json:"pair"`
+	ContractType string  `json:"contractType"`
+	OpenInterest float64 `json:"openInterest,string"`
+	Time         int64   `json:"time"`
+}
+
+// GetOpenInterest returns the present open interest of a futures symbol. Only available
+// on the Perpetual and InversePerpetual markets. For details see:
+//   - https://binance-docs.github.io/apidocs/futures/en/#open-interest
+//   - https://binance-docs.github.io/apidocs/delivery/en/#open-interest
+func (api *Api) GetOpenInterest(ctx context.Context, symbol string) (OpenInterest, error) {
+	endpoint, err := api.endpoint("", "/fapi/v1/openInterest", "/dapi/v1/openInterest")
+	if err != nil {
+		return OpenInterest{}, apiErr("openInterest", err)
 	}
+	return apiGet[OpenInterest](ctx, api, endpoint, map[string]string{"symbol": symbol}, 1)
 }
@@ -0,0 +1,90 @@
+package binance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// BookDepth is the type produced by a Binance partial depth stream. It is a snapshot of
+// the top levels of an orderbook.
+type BookDepth struct {
+	Symbol       string
+	LastUpdateId int64
+	// EventTime and TransactionTime are only set on futures markets.
+	EventTime       int64
+	TransactionTime int64
+	Bids            []tradekit.Level
+	Asks            []tradekit.Level
+}
+
+// BookDepthSub represents a subscription to a partial depth stream. Valid values of Depth
+// are 5, 10 or 20. See [NewBookDepthStream].
+type BookDepthSub struct {
+	Symbol string
+	Depth  int
+}
+
+func (s BookDepthSub) channel() string {
+	return fmt.Sprintf("%s@depth%d@100ms", strings.ToLower(s.Symbol), s.Depth)
+}
+
+// NewBookDepthStream creates a new [Stream] which produces snapshots of the top levels of
+// a symbol's orderbook. Updates are sent on a 100ms interval. For a stream of incremental
+// orderbook updates, see [NewOrderbookStream]. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#partial-book-depth-streams
+//   - https://binance-docs.github.io/apidocs/futures/en/#partial-book-depth-streams
+func NewBookDepthStream(wsUrl string, subs []BookDepthSub, paramFuncs ...tk.Param) Stream[BookDepth, BookDepthSub] {
+	p := streamParams[BookDepth, BookDepthSub]{
+		name:         "BookDepthStream",
+		wsUrl:        wsUrl,
+		parseMessage: parseBookDepth,
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+// parseBookDepth parses a partial depth message. On spot markets the message doesn't
+// include the symbol, so we take it from the name of the stream instead.
+func parseBookDepth(stream string, v *fastjson.Value) (BookDepth, error) {
+	if bytes.Equal(v.GetStringBytes("e"), []byte("depthUpdate")) {
+		// Futures markets
+		bids, err := parsePriceLevels(v.GetArray("b"))
+		if err != nil {
+			return BookDepth{}, err
+		}
+		asks, err := parsePriceLevels(v.GetArray("a"))
+		if err != nil {
+			return BookDepth{}, err
+		}
+		return BookDepth{
+			Symbol:          string(v.GetStringBytes("s")),
+			LastUpdateId:    v.GetInt64("u"),
+			EventTime:       v.GetInt64("E"),
+			TransactionTime: v.GetInt64("T"),
+			Bids:            bids,
+			Asks:            asks,
+		}, nil
+	}
+
+	bids, err := parsePriceLevels(v.GetArray("bids"))
+	if err != nil {
+		return BookDepth{}, err
+	}
+	asks, err := parsePriceLevels(v.GetArray("asks"))
+	if err != nil {
+		return BookDepth{}, err
+	}
+	symbol, _, _ := strings.Cut(stream, "@")
+	return BookDepth{
+		Symbol:       strings.ToUpper(symbol),
+		LastUpdateId: v.GetInt64("lastUpdateId"),
+		Bids:         bids,
+		Asks:         asks,
+	}, nil
+}
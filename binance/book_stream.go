@@ -7,23 +7,37 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/bogdanovich/tradekit"
-	"github.com/bogdanovich/tradekit/internal/websocket"
+	"github.com/bogdanovich/tradekit/lib/tk"
 	"github.com/valyala/fastjson"
 )
 
+// snapshotDepth is the depth of the orderbook snapshot retrieved from the Api when
+// synchronising an orderbook.
+const snapshotDepth = 100
+
 type bookUpdate struct {
 	EventTime     int64
 	Symbol        string
 	FirstUpdateId int64
 	FinalUpdateId int64
-	Bids          []tradekit.Level
-	Asks          []tradekit.Level
+	// PrevFinalUpdateId is only set on futures markets.
+	PrevFinalUpdateId int64
+	Bids              []tradekit.Level
+	Asks              []tradekit.Level
 }
 
-// BookUpdate is the message type produced by OrderbookStream.
+// follows reports whether the update directly follows an update with the given final
+// update id.
+func (m bookUpdate) follows(finalUpdateId int64) bool {
+	if m.PrevFinalUpdateId != 0 {
+		return m.PrevFinalUpdateId == finalUpdateId
+	}
+	return m.FirstUpdateId == finalUpdateId+1
+}
+
+// BookUpdate is the message type produced by the orderbook stream.
 type BookUpdate struct {
 	// Type is either "snapshot" or "change". If you receive a snapshot, you should
 	// overwrite your orderbook with its contents.
@@ -33,7 +47,7 @@ type BookUpdate struct {
 	// zero for "snapshot" updates on spot markets.
 	EventTime int64
 
-	// Symbol is the trading symbol corresponding to the stream.
+	// Symbol is the trading symbol corresponding to the update.
 	Symbol string
 
 	// Bids and Asks levels. For "change" messages, a level with a zero Amount indicates
@@ -42,165 +56,96 @@ type BookUpdate struct {
 	Asks []tradekit.Level
 }
 
-// OrderbookStream provides a streaming view of updates to the orderbook of of a Binance
-// trading symbol. It may be used to maintain a local tradekit Orderbook. The first
-// message produced is always a snapshot, and subsequent messages are orderbook updates.
-// A new snapshot is sent in the event that the stream's internal websocket reconnects.
-type OrderbookStream struct {
-	url    string
-	symbol string
-	msgs   chan BookUpdate
-	errc   chan error
-	p      fastjson.Parser
-	subIds map[int64]struct{}
-	api    *Api
+// OrderbookSub represents a subscription to the orderbook of a symbol. See
+// [NewOrderbookStream].
+type OrderbookSub struct {
+	Symbol string
 }
 
-// NewOrderbookStream creates a new Binance OrderbookStream for a given symbol. An Api
-// is required so that the stream can retrieve an initial orderbook snapshot. The provided
-// wsUrl should match the market of the Api (for example, if the wsUrl is for Spot, then
-// the Api should also be for Spot.). Updates are sent on a 100ms interval.
-func NewOrderbookStream(wsUrl string, symbol string, api *Api) *OrderbookStream {
-	errc := make(chan error, 1)
-	msgs := make(chan BookUpdate, 10)
-	subIds := make(map[int64]struct{})
-	return &OrderbookStream{url: wsUrl, symbol: symbol, msgs: msgs, errc: errc, subIds: subIds, api: api}
+func (s OrderbookSub) channel() string {
+	return fmt.Sprintf("%s@depth@100ms", strings.ToLower(s.Symbol))
 }
 
-func (s *OrderbookStream) parseBookUpdateMsg(msg websocket.Message) (bookUpdate, error) {
-	defer msg.Release()
-	v, err := s.p.ParseBytes(msg.Data())
-	if err != nil {
-		return bookUpdate{}, streamParseErr("depthUpdate", msg.Data(), err)
-	}
+const (
+	bookNeedsSnapshot = iota
+	bookAwaitingUpdate
+	bookSynced
+)
 
-	if id := v.GetInt64("id"); id != 0 {
-		// This should be a subscription response message
-		if _, ok := s.subIds[id]; ok {
-			delete(s.subIds, id)
-			return bookUpdate{}, nil
-		}
-		return bookUpdate{}, streamParseErr("depthUpdate", msg.Data(), errors.New("expected a book update message"))
-	}
+// bookSync holds the synchronisation state of a single symbol's orderbook.
+type bookSync struct {
+	state        int
+	lastUpdateId int64
+}
 
-	eventType := v.GetStringBytes("e")
-	if !bytes.Equal(eventType, []byte("depthUpdate")) {
-		return bookUpdate{}, streamParseErr("depthUpdate", msg.Data(), errors.New("expected depthUpdate type"))
-	}
+// orderbookStream wraps a stream of diff-depth updates and keeps each symbol in sync
+// with an orderbook snapshot retrieved from the Api.
+type orderbookStream struct {
+	*stream[bookUpdate, OrderbookSub]
+	api   *Api
+	msgs  chan BookUpdate
+	errc  chan error
+	books map[string]*bookSync
+}
 
-	bidLevels, err := parsePriceLevels(v.GetArray("b"))
-	if err != nil {
-		return bookUpdate{}, streamParseErr("depthUpdate", msg.Data(), err)
+// NewOrderbookStream creates a new [Stream] which produces updates to the orderbooks of
+// one or more symbols. It may be used to maintain a local tradekit Orderbook for each
+// symbol. The first message produced for a symbol is always a snapshot, and subsequent
+// messages are orderbook updates. A new snapshot is sent for a symbol whenever a gap
+// is detected in its updates, for example after the stream reconnects. Updates are sent
+// on a 100ms interval.
+//
+// An Api is required so that the stream can retrieve orderbook snapshots. The provided
+// wsUrl should match the market of the Api (for example, if the wsUrl is for Spot, then
+// the Api should also be for Spot). For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#how-to-manage-a-local-order-book-correctly
+//   - https://binance-docs.github.io/apidocs/futures/en/#how-to-manage-a-local-order-book-correctly
+func NewOrderbookStream(wsUrl string, api *Api, subs []OrderbookSub, paramFuncs ...tk.Param) Stream[BookUpdate, OrderbookSub] {
+	p := streamParams[bookUpdate, OrderbookSub]{
+		name:         "OrderbookStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseBookUpdate),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
 	}
-
-	askLevels, err := parsePriceLevels(v.GetArray("a"))
-	if err != nil {
-		return bookUpdate{}, streamParseErr("depthUpdate", msg.Data(), err)
+	s := newStream(p)
+	return &orderbookStream{
+		stream: s,
+		api:    api,
+		msgs:   make(chan BookUpdate, s.ChannelBufferSize),
+		errc:   make(chan error, 1),
+		books:  make(map[string]*bookSync),
 	}
-
-	return bookUpdate{
-		EventTime:     v.GetInt64("E"),
-		Symbol:        string(v.GetStringBytes("s")),
-		FirstUpdateId: v.GetInt64("U"),
-		FinalUpdateId: v.GetInt64("u"),
-		Bids:          bidLevels,
-		Asks:          askLevels,
-	}, nil
-}
-
-func (s *OrderbookStream) getSnapshot() (OrderbookResponse, error) {
-	symbol := strings.ToUpper(s.symbol)
-	return s.api.GetOrderbook(symbol, 100)
 }
 
-// Start initiates the websocket connection to the orderbook stream.
-func (s *OrderbookStream) Start(ctx context.Context) error {
-	// Websocket setup
-	connecting := make(chan struct{}, 1)
-	ws := websocket.New(s.url, nil)
-	ws.OnConnect = func() error {
-		connecting <- struct{}{}
-		channels := []string{fmt.Sprintf("%s@depth@100ms", s.symbol)}
-		id := genId()
-		subMsg, err := newSubscribeMsg(id, channels)
-		if err != nil {
-			return err
-		}
-		s.subIds[id] = struct{}{}
-		ws.Send(subMsg)
-		return nil
-	}
-	if err := ws.Start(ctx); err != nil {
-		return streamError("OrderbookStream", fmt.Errorf("websocket connect: %w", err))
+func (s *orderbookStream) Start(ctx context.Context) error {
+	if err := s.stream.Start(ctx); err != nil {
+		return err
 	}
 
 	go func() {
-		// TODO: we should keep track of the FinalUpdateId of the previous message, and
-		// make sure that it's equal to the FirstUpdateId + 1 of the current message, and
-		// if not, discard the message and reconnect.
-		defer close(s.msgs)
-		defer ws.Close()
-		getSnapshot := false
-		waitingForNextUpdate := false
-		var snapshot OrderbookResponse
-		timer := time.NewTimer(15 * time.Second)
+		defer func() {
+			close(s.msgs)
+			close(s.errc)
+		}()
+		updates := s.stream.Messages()
+		errc := s.stream.Err()
 		for {
 			select {
-			case <-connecting:
-				getSnapshot = true
-				timer.Reset(15 * time.Second)
-			case data := <-ws.Messages():
-				msg, err := s.parseBookUpdateMsg(data)
-				if err != nil {
-					s.errc <- streamError("OrderbookStream", err)
+			case m, ok := <-updates:
+				if !ok {
 					return
 				}
-				if msg.Symbol == "" {
-					// It's just the subscribe response. We can discard it
-					continue
+				if err := s.handleUpdate(ctx, m); err != nil {
+					s.errc <- s.nameErr(err)
+					return
 				}
-				if getSnapshot {
-					// The websocket has just connected and we've received the first
-					// message. Now we need to get a snapshot of the orderbook and then
-					// discard any update messages containing only updates prior to the
-					// final update of the snapshot.
-					// For details see: https://binance-docs.github.io/apidocs/spot/en/#how-to-manage-a-local-order-book-correctly
-					var err error
-					snapshot, err = s.getSnapshot()
-					if err != nil {
-						s.errc <- streamError("OrderbookStream", err)
-						break
-					}
-					getSnapshot = false
-					waitingForNextUpdate = true
-
-					s.msgs <- snapshotUpdate(snapshot)
-
-					if msg.FinalUpdateId > snapshot.LastUpdateId {
-						waitingForNextUpdate = false
-						if !timer.Stop() {
-							<-timer.C
-						}
-						s.msgs <- changeUpdate(msg)
-					}
-				} else if waitingForNextUpdate {
-					if msg.FinalUpdateId > snapshot.LastUpdateId {
-						waitingForNextUpdate = false
-						if !timer.Stop() {
-							<-timer.C
-						}
-						s.msgs <- changeUpdate(msg)
-					}
-				} else {
-					s.msgs <- changeUpdate(msg)
+			case err, ok := <-errc:
+				if !ok {
+					return
 				}
-			case err := <-ws.Err():
-				s.errc <- streamError("OrderbookStream", err)
+				s.errc <- err
 				return
-			case <-timer.C:
-				// We have taken a snapshot, but haven't received the first valid update
-				// message within 15 seconds.
-				s.errc <- streamError("OrderbookStream", errors.New("snapshot update not received within timeout"))
 			}
 		}
 	}()
@@ -208,21 +153,96 @@ func (s *OrderbookStream) Start(ctx context.Context) error {
 	return nil
 }
 
+func (s *orderbookStream) handleUpdate(ctx context.Context, m bookUpdate) error {
+	b, ok := s.books[m.Symbol]
+	if !ok {
+		b = &bookSync{state: bookNeedsSnapshot}
+		s.books[m.Symbol] = b
+	}
+
+	if b.state == bookSynced {
+		if m.follows(b.lastUpdateId) {
+			b.lastUpdateId = m.FinalUpdateId
+			s.msgs <- changeUpdate(m)
+			return nil
+		}
+		// We've missed an update, most likely due to a reconnect. Resynchronise.
+		s.Logger.Info(s.namePrefix(fmt.Sprintf("gap in %s updates, fetching snapshot", m.Symbol)))
+		b.state = bookNeedsSnapshot
+	}
+
+	if b.state == bookNeedsSnapshot {
+		snapshot, err := s.api.GetOrderbook(ctx, strings.ToUpper(m.Symbol), snapshotDepth)
+		if err != nil {
+			return err
+		}
+		b.lastUpdateId = snapshot.LastUpdateId
+		b.state = bookAwaitingUpdate
+		s.msgs <- snapshotUpdate(m.Symbol, snapshot)
+	}
+
+	// Discard any updates containing only updates prior to the snapshot.
+	if m.FinalUpdateId <= b.lastUpdateId {
+		return nil
+	}
+	if m.FirstUpdateId > b.lastUpdateId+1 {
+		// The snapshot is older than the update. Take a new one on the next update.
+		b.state = bookNeedsSnapshot
+		return nil
+	}
+	b.lastUpdateId = m.FinalUpdateId
+	b.state = bookSynced
+	s.msgs <- changeUpdate(m)
+	return nil
+}
+
 // Messages returns a channel for reading BookUpdate message produced by the stream.
-func (s *OrderbookStream) Messages() <-chan BookUpdate {
+func (s *orderbookStream) Messages() <-chan BookUpdate {
 	return s.msgs
 }
 
 // Err returns a channel which produces an error if there is a problem with the stream.
 // If an error is produced, then the Messages channel will be closed.
-func (s *OrderbookStream) Err() <-chan error {
+func (s *orderbookStream) Err() <-chan error {
 	return s.errc
 }
 
-func snapshotUpdate(m OrderbookResponse) BookUpdate {
+func (s *orderbookStream) PendingMessagesCount() int {
+	return len(s.msgs)
+}
+
+func parseBookUpdate(v *fastjson.Value) (bookUpdate, error) {
+	eventType := v.GetStringBytes("e")
+	if !bytes.Equal(eventType, []byte("depthUpdate")) {
+		return bookUpdate{}, errors.New("expected depthUpdate type")
+	}
+
+	bidLevels, err := parsePriceLevels(v.GetArray("b"))
+	if err != nil {
+		return bookUpdate{}, err
+	}
+
+	askLevels, err := parsePriceLevels(v.GetArray("a"))
+	if err != nil {
+		return bookUpdate{}, err
+	}
+
+	return bookUpdate{
+		EventTime:         v.GetInt64("E"),
+		Symbol:            string(v.GetStringBytes("s")),
+		FirstUpdateId:     v.GetInt64("U"),
+		FinalUpdateId:     v.GetInt64("u"),
+		PrevFinalUpdateId: v.GetInt64("pu"),
+		Bids:              bidLevels,
+		Asks:              askLevels,
+	}, nil
+}
+
+func snapshotUpdate(symbol string, m OrderbookResponse) BookUpdate {
 	return BookUpdate{
 		Type:      "snapshot",
 		EventTime: m.EventTime,
+		Symbol:    symbol,
 		Bids:      m.Bids,
 		Asks:      m.Asks,
 	}
@@ -232,6 +252,7 @@ func changeUpdate(m bookUpdate) BookUpdate {
 	return BookUpdate{
 		Type:      "change",
 		EventTime: m.EventTime,
+		Symbol:    m.Symbol,
 		Bids:      m.Bids,
 		Asks:      m.Asks,
 	}
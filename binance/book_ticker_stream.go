@@ -0,0 +1,62 @@
+package binance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// BookTicker is the type produced by a Binance book ticker stream. It holds the best bid
+// and ask of a symbol.
+type BookTicker struct {
+	UpdateId int64  `json:"u"`
+	Symbol   string `json:"s"`
+	// EventTime and TransactionTime are only set on futures markets.
+	EventTime       int64   `json:"E"`
+	TransactionTime int64   `json:"T"`
+	BidPrice        float64 `json:"b,string"`
+	BidQuantity     float64 `json:"B,string"`
+	AskPrice        float64 `json:"a,string"`
+	AskQuantity     float64 `json:"A,string"`
+}
+
+// BookTickerSub represents a subscription to the book ticker of a symbol. See
+// [NewBookTickerStream].
+type BookTickerSub struct {
+	Symbol string
+}
+
+func (s BookTickerSub) channel() string {
+	return fmt.Sprintf("%s@bookTicker", strings.ToLower(s.Symbol))
+}
+
+// NewBookTickerStream creates a new [Stream] which produces realtime updates to the best
+// bid and ask of a symbol. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#individual-symbol-book-ticker-streams
+//   - https://binance-docs.github.io/apidocs/futures/en/#individual-symbol-book-ticker-streams
+func NewBookTickerStream(wsUrl string, subs []BookTickerSub, paramFuncs ...tk.Param) Stream[BookTicker, BookTickerSub] {
+	p := streamParams[BookTicker, BookTickerSub]{
+		name:         "BookTickerStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseBookTicker),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func parseBookTicker(v *fastjson.Value) (BookTicker, error) {
+	return BookTicker{
+		UpdateId:        v.GetInt64("u"),
+		Symbol:          string(v.GetStringBytes("s")),
+		EventTime:       v.GetInt64("E"),
+		TransactionTime: v.GetInt64("T"),
+		BidPrice:        conv.BytesToFloat(v.GetStringBytes("b")),
+		BidQuantity:     conv.BytesToFloat(v.GetStringBytes("B")),
+		AskPrice:        conv.BytesToFloat(v.GetStringBytes("a")),
+		AskQuantity:     conv.BytesToFloat(v.GetStringBytes("A")),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package binance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// KlineInterval is the time interval of a kline (candlestick).
+type KlineInterval string
+
+const (
+	Interval1s  KlineInterval = "1s"
+	Interval1m  KlineInterval = "1m"
+	Interval3m  KlineInterval = "3m"
+	Interval5m  KlineInterval = "5m"
+	Interval15m KlineInterval = "15m"
+	Interval30m KlineInterval = "30m"
+	Interval1h  KlineInterval = "1h"
+	Interval2h  KlineInterval = "2h"
+	Interval4h  KlineInterval = "4h"
+	Interval6h  KlineInterval = "6h"
+	Interval8h  KlineInterval = "8h"
+	Interval12h KlineInterval = "12h"
+	Interval1d  KlineInterval = "1d"
+	Interval3d  KlineInterval = "3d"
+	Interval1w  KlineInterval = "1w"
+	Interval1M  KlineInterval = "1M"
+)
+
+// Kline is a candlestick for a symbol over a time interval.
+type Kline struct {
+	// EventTime is the timestamp at which Binance produced the message. It is only set
+	// on klines produced by a kline stream.
+	EventTime           int64         `json:"E"`
+	Symbol              string        `json:"s"`
+	Interval            KlineInterval `json:"i"`
+	StartTime           int64         `json:"t"`
+	CloseTime           int64         `json:"T"`
+	FirstTradeId        int64         `json:"f"`
+	LastTradeId         int64         `json:"L"`
+	Open                float64       `json:"o,string"`
+	High                float64       `json:"h,string"`
+	Low                 float64       `json:"l,string"`
+	Close               float64       `json:"c,string"`
+	Volume              float64       `json:"v,string"`
+	QuoteVolume         float64       `json:"q,string"`
+	NumTrades           int64         `json:"n"`
+	TakerBuyVolume      float64       `json:"V,string"`
+	TakerBuyQuoteVolume float64       `json:"Q,string"`
+	// IsClosed is true when the kline's interval has ended.
+	IsClosed bool `json:"x"`
+}
+
+// KlineSub represents a subscription to the klines of a symbol at a given interval. See
+// [NewKlineStream].
+type KlineSub struct {
+	Symbol   string
+	Interval KlineInterval
+}
+
+func (s KlineSub) channel() string {
+	return fmt.Sprintf("%s@kline_%s", strings.ToLower(s.Symbol), s.Interval)
+}
+
+// NewKlineStream creates a new [Stream] which produces updates to the current kline of a
+// symbol. Updates are sent every 1-2 seconds. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#kline-candlestick-streams
+//   - https://binance-docs.github.io/apidocs/futures/en/#kline-candlestick-streams
+func NewKlineStream(wsUrl string, subs []KlineSub, paramFuncs ...tk.Param) Stream[Kline, KlineSub] {
+	p := streamParams[Kline, KlineSub]{
+		name:         "KlineStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseKlineMsg),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func parseKlineMsg(v *fastjson.Value) (Kline, error) {
+	eventType := v.GetStringBytes("e")
+	if !bytes.Equal(eventType, []byte("kline")) {
+		return Kline{}, errors.New("expected kline type")
+	}
+	k := v.Get("k")
+	if k == nil {
+		return Kline{}, errors.New(`field "k" is missing`)
+	}
+	return Kline{
+		EventTime:           v.GetInt64("E"),
+		Symbol:              string(v.GetStringBytes("s")),
+		Interval:            KlineInterval(k.GetStringBytes("i")),
+		StartTime:           k.GetInt64("t"),
+		CloseTime:           k.GetInt64("T"),
+		FirstTradeId:        k.GetInt64("f"),
+		LastTradeId:         k.GetInt64("L"),
+		Open:                conv.BytesToFloat(k.GetStringBytes("o")),
+		High:                conv.BytesToFloat(k.GetStringBytes("h")),
+		Low:                 conv.BytesToFloat(k.GetStringBytes("l")),
+		Close:               conv.BytesToFloat(k.GetStringBytes("c")),
+		Volume:              conv.BytesToFloat(k.GetStringBytes("v")),
+		QuoteVolume:         conv.BytesToFloat(k.GetStringBytes("q")),
+		NumTrades:           k.GetInt64("n"),
+		TakerBuyVolume:      conv.BytesToFloat(k.GetStringBytes("V")),
+		TakerBuyQuoteVolume: conv.BytesToFloat(k.GetStringBytes("Q")),
+		IsClosed:            k.GetBool("x"),
+	}, nil
+}
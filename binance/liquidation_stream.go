@@ -0,0 +1,80 @@
+package binance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// Liquidation is the type produced by a Binance liquidation stream. It describes a
+// liquidation order on a futures market.
+type Liquidation struct {
+	EventTime      int64   `json:"E"`
+	Symbol         string  `json:"s"`
+	Side           string  `json:"S"`
+	OrderType      string  `json:"o"`
+	TimeInForce    string  `json:"f"`
+	Quantity       float64 `json:"q,string"`
+	Price          float64 `json:"p,string"`
+	AveragePrice   float64 `json:"ap,string"`
+	Status         string  `json:"X"`
+	LastFilledQty  float64 `json:"l,string"`
+	FilledQuantity float64 `json:"z,string"`
+	TradeTime      int64   `json:"T"`
+}
+
+// LiquidationSub represents a subscription to the liquidations of a futures symbol. See
+// [NewLiquidationStream].
+type LiquidationSub struct {
+	Symbol string
+}
+
+func (s LiquidationSub) channel() string {
+	return fmt.Sprintf("%s@forceOrder", strings.ToLower(s.Symbol))
+}
+
+// NewLiquidationStream creates a new [Stream] which produces liquidation orders. Binance
+// sends at most one liquidation per symbol every second. This stream is only available
+// on futures markets. For details see:
+//   - https://binance-docs.github.io/apidocs/futures/en/#liquidation-order-streams
+//   - https://binance-docs.github.io/apidocs/delivery/en/#liquidation-order-streams
+func NewLiquidationStream(wsUrl string, subs []LiquidationSub, paramFuncs ...tk.Param) Stream[Liquidation, LiquidationSub] {
+	p := streamParams[Liquidation, LiquidationSub]{
+		name:         "LiquidationStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseLiquidation),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func parseLiquidation(v *fastjson.Value) (Liquidation, error) {
+	eventType := v.GetStringBytes("e")
+	if !bytes.Equal(eventType, []byte("forceOrder")) {
+		return Liquidation{}, errors.New("expected forceOrder type")
+	}
+	o := v.Get("o")
+	if o == nil {
+		return Liquidation{}, errors.New(`field "o" is missing`)
+	}
+	return Liquidation{
+		EventTime:      v.GetInt64("E"),
+		Symbol:         string(o.GetStringBytes("s")),
+		Side:           string(o.GetStringBytes("S")),
+		OrderType:      string(o.GetStringBytes("o")),
+		TimeInForce:    string(o.GetStringBytes("f")),
+		Quantity:       conv.BytesToFloat(o.GetStringBytes("q")),
+		Price:          conv.BytesToFloat(o.GetStringBytes("p")),
+		AveragePrice:   conv.BytesToFloat(o.GetStringBytes("ap")),
+		Status:         string(o.GetStringBytes("X")),
+		LastFilledQty:  conv.BytesToFloat(o.GetStringBytes("l")),
+		FilledQuantity: conv.BytesToFloat(o.GetStringBytes("z")),
+		TradeTime:      o.GetInt64("T"),
+	}, nil
+}
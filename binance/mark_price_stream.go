@@ -0,0 +1,66 @@
+package binance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// MarkPrice is the type produced by a Binance mark price stream. It includes the funding
+// rate of a perpetual futures contract.
+type MarkPrice struct {
+	EventTime       int64   `json:"E"`
+	Symbol          string  `json:"s"`
+	MarkPrice       float64 `json:"p,string"`
+	IndexPrice      float64 `json:"i,string"`
+	EstSettlePrice  float64 `json:"P,string"`
+	FundingRate     float64 `json:"r,string"`
+	NextFundingTime int64   `json:"T"`
+}
+
+// MarkPriceSub represents a subscription to the mark price of a futures symbol. See
+// [NewMarkPriceStream].
+type MarkPriceSub struct {
+	Symbol string
+}
+
+func (s MarkPriceSub) channel() string {
+	return fmt.Sprintf("%s@markPrice@1s", strings.ToLower(s.Symbol))
+}
+
+// NewMarkPriceStream creates a new [Stream] which produces the mark price and funding
+// rate of a futures symbol every second. This stream is only available on futures
+// markets. For details see:
+//   - https://binance-docs.github.io/apidocs/futures/en/#mark-price-stream
+//   - https://binance-docs.github.io/apidocs/delivery/en/#mark-price-stream
+func NewMarkPriceStream(wsUrl string, subs []MarkPriceSub, paramFuncs ...tk.Param) Stream[MarkPrice, MarkPriceSub] {
+	p := streamParams[MarkPrice, MarkPriceSub]{
+		name:         "MarkPriceStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseMarkPrice),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func parseMarkPrice(v *fastjson.Value) (MarkPrice, error) {
+	eventType := v.GetStringBytes("e")
+	if !bytes.Equal(eventType, []byte("markPriceUpdate")) {
+		return MarkPrice{}, errors.New("expected markPriceUpdate type")
+	}
+	return MarkPrice{
+		EventTime:       v.GetInt64("E"),
+		Symbol:          string(v.GetStringBytes("s")),
+		MarkPrice:       conv.BytesToFloat(v.GetStringBytes("p")),
+		IndexPrice:      conv.BytesToFloat(v.GetStringBytes("i")),
+		EstSettlePrice:  conv.BytesToFloat(v.GetStringBytes("P")),
+		FundingRate:     conv.BytesToFloat(v.GetStringBytes("r")),
+		NextFundingTime: v.GetInt64("T"),
+	}, nil
+}
@@ -0,0 +1,296 @@
+package binance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+// signer signs the parameters of requests to private endpoints.
+type signer struct {
+	apiKey string
+	// Exactly one of secret or privateKey is set.
+	secret     []byte
+	privateKey ed25519.PrivateKey
+}
+
+// newSigner creates a signer from a Binance API key. If the secret is a PEM encoded
+// Ed25519 private key then requests are signed with Ed25519, otherwise it's treated as
+// an HMAC-SHA256 secret key.
+func newSigner(c tk.Credentials) (*signer, error) {
+	if c.ClientId == "" || c.ClientSecret == "" {
+		return nil, errors.New("ClientId and ClientSecret must be set")
+	}
+	if !strings.Contains(c.ClientSecret, "-----BEGIN") {
+		return &signer{apiKey: c.ClientId, secret: []byte(c.ClientSecret)}, nil
+	}
+
+	block, _ := pem.Decode([]byte(c.ClientSecret))
+	if block == nil {
+		return nil, errors.New("invalid PEM private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key must be an Ed25519 key")
+	}
+	return &signer{apiKey: c.ClientId, privateKey: privateKey}, nil
+}
+
+func (s *signer) sign(payload string) string {
+	if s.privateKey != nil {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, []byte(payload)))
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedQuery adds a timestamp to the params and returns the encoded query string with
+// its signature appended.
+func (s *signer) signedQuery(params map[string]string, now time.Time) string {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	values.Set("timestamp", strconv.FormatInt(now.UnixMilli(), 10))
+	query := values.Encode()
+	return query + "&signature=" + url.QueryEscape(s.sign(query))
+}
+
+// SetCredentials sets the credentials used to access private endpoints. The ClientId is
+// the Binance API key and the ClientSecret is either its HMAC secret key, or a PEM
+// encoded Ed25519 private key. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#signed-trade-user_data-and-margin-endpoint-security
+func (api *Api) SetCredentials(c tk.Credentials) error {
+	s, err := newSigner(c)
+	if err != nil {
+		return fmt.Errorf("Binance credentials: %w", err)
+	}
+	api.signer = s
+	return nil
+}
+
+type listenKeyResponse struct {
+	ListenKey string `json:"listenKey"`
+}
+
+// CreateListenKey creates a listen key for a user data stream. The key expires after 60
+// minutes unless it's extended with KeepAliveListenKey. Credentials are required. Most
+// users should use [NewUserDataStream] which manages the lifecycle of the key.
+func (api *Api) CreateListenKey(ctx context.Context) (string, error) {
+	endpoint, err := api.listenKeyEndpoint()
+	if err != nil {
+		return "", err
+	}
+	resp, err := apiRequest[listenKeyResponse](ctx, api, http.MethodPost, endpoint, nil, securityApiKey, api.weight(2, 1, 1))
+	if err != nil {
+		return "", err
+	}
+	return resp.ListenKey, nil
+}
+
+// KeepAliveListenKey extends the validity of a listen key by 60 minutes. Credentials are
+// required.
+func (api *Api) KeepAliveListenKey(ctx context.Context, listenKey string) error {
+	endpoint, err := api.listenKeyEndpoint()
+	if err != nil {
+		return err
+	}
+	_, err = apiRequest[struct{}](ctx, api, http.MethodPut, endpoint, api.listenKeyParams(listenKey), securityApiKey, api.weight(2, 1, 1))
+	return err
+}
+
+// CloseListenKey closes a listen key, ending its user data stream. Credentials are
+// required.
+func (api *Api) CloseListenKey(ctx context.Context, listenKey string) error {
+	endpoint, err := api.listenKeyEndpoint()
+	if err != nil {
+		return err
+	}
+	_, err = apiRequest[struct{}](ctx, api, http.MethodDelete, endpoint, api.listenKeyParams(listenKey), securityApiKey, api.weight(2, 1, 1))
+	return err
+}
+
+func (api *Api) listenKeyEndpoint() (string, error) {
+	endpoint, err := api.endpoint("/api/v3/userDataStream", "/fapi/v1/listenKey", "/dapi/v1/listenKey")
+	if err != nil {
+		return "", apiErr("listenKey", err)
+	}
+	return endpoint, nil
+}
+
+// listenKeyParams returns the parameters for a listen key request. Futures markets have
+// a single listen key per account so the key isn't sent.
+func (api *Api) listenKeyParams(listenKey string) map[string]string {
+	if api.market == Spot {
+		return map[string]string{"listenKey": listenKey}
+	}
+	return nil
+}
+
+// Order sides
+const (
+	Buy  = "BUY"
+	Sell = "SELL"
+)
+
+// Order types
+const (
+	OrderTypeLimit           = "LIMIT"
+	OrderTypeMarket          = "MARKET"
+	OrderTypeStopLoss        = "STOP_LOSS"
+	OrderTypeStopLossLimit   = "STOP_LOSS_LIMIT"
+	OrderTypeTakeProfit      = "TAKE_PROFIT"
+	OrderTypeTakeProfitLimit = "TAKE_PROFIT_LIMIT"
+	// OrderTypeLimitMaker is a post-only limit order on spot markets. On futures markets
+	// use a LIMIT order with the GTX time in force instead.
+	OrderTypeLimitMaker = "LIMIT_MAKER"
+	// OrderTypeStop and OrderTypeStopMarket are only available on futures markets.
+	OrderTypeStop       = "STOP"
+	OrderTypeStopMarket = "STOP_MARKET"
+)
+
+// Time in force values
+const (
+	GoodTillCancel    = "GTC"
+	ImmediateOrCancel = "IOC"
+	FillOrKill        = "FOK"
+	// GoodTillCrossing is a post-only time in force on futures markets.
+	GoodTillCrossing = "GTX"
+)
+
+// PlaceOrderParams are the parameters of a new order. See [Api.PlaceOrder].
+type PlaceOrderParams struct {
+	Symbol string
+	// Side is Buy or Sell.
+	Side string
+	// Type is the order type e.g. OrderTypeLimit.
+	Type string
+	// TimeInForce is required for limit orders e.g. GoodTillCancel.
+	TimeInForce string
+	Quantity    float64
+	// Price is required for limit orders.
+	Price     float64
+	StopPrice float64
+	// NewClientOrderId is an optional unique id for the order.
+	NewClientOrderId string
+	// ReduceOnly and PositionSide are only available on futures markets.
+	ReduceOnly   bool
+	PositionSide string
+}
+
+func (p PlaceOrderParams) params(market Market) map[string]string {
+	params := map[string]string{
+		"symbol":   p.Symbol,
+		"side":     p.Side,
+		"type":     p.Type,
+		"quantity": strconv.FormatFloat(p.Quantity, 'f', -1, 64),
+	}
+	if p.TimeInForce != "" {
+		params["timeInForce"] = p.TimeInForce
+	}
+	if p.Price != 0 {
+		params["price"] = strconv.FormatFloat(p.Price, 'f', -1, 64)
+	}
+	if p.StopPrice != 0 {
+		params["stopPrice"] = strconv.FormatFloat(p.StopPrice, 'f', -1, 64)
+	}
+	if p.NewClientOrderId != "" {
+		params["newClientOrderId"] = p.NewClientOrderId
+	}
+	// Return the order's state rather than only its id.
+	params["newOrderRespType"] = "RESULT"
+	if market != Spot {
+		if p.ReduceOnly {
+			params["reduceOnly"] = "true"
+		}
+		if p.PositionSide != "" {
+			params["positionSide"] = p.PositionSide
+		}
+	}
+	return params
+}
+
+// OrderResponse is the type returned by PlaceOrder and CancelOrder. Fields which aren't
+// returned by a market are left as their zero value.
+type OrderResponse struct {
+	Symbol        string  `json:"symbol"`
+	OrderId       int64   `json:"orderId"`
+	ClientOrderId string  `json:"clientOrderId"`
+	Price         float64 `json:"price,string"`
+	OrigQty       float64 `json:"origQty,string"`
+	ExecutedQty   float64 `json:"executedQty,string"`
+	Status        string  `json:"status"`
+	TimeInForce   string  `json:"timeInForce"`
+	Type          string  `json:"type"`
+	Side          string  `json:"side"`
+	// TransactTime is only set on spot markets.
+	TransactTime int64 `json:"transactTime"`
+	// UpdateTime, AvgPrice, StopPrice, ReduceOnly and PositionSide are only set on
+	// futures markets.
+	UpdateTime   int64   `json:"updateTime"`
+	AvgPrice     float64 `json:"avgPrice,string"`
+	StopPrice    float64 `json:"stopPrice,string"`
+	ReduceOnly   bool    `json:"reduceOnly"`
+	PositionSide string  `json:"positionSide"`
+}
+
+// PlaceOrder places a new order. Credentials are required. This endpoint is only
+// available on Spot and Perpetual (USD-M futures) markets. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#new-order-trade
+//   - https://binance-docs.github.io/apidocs/futures/en/#new-order-trade
+func (api *Api) PlaceOrder(ctx context.Context, p PlaceOrderParams) (OrderResponse, error) {
+	endpoint, err := api.endpoint("/api/v3/order", "/fapi/v1/order", "")
+	if err != nil {
+		return OrderResponse{}, apiErr("order", err)
+	}
+	return apiRequest[OrderResponse](ctx, api, http.MethodPost, endpoint, p.params(api.market), securitySigned, 1)
+}
+
+// CancelOrderParams are the parameters of CancelOrder. Either OrderId or
+// OrigClientOrderId must be set.
+type CancelOrderParams struct {
+	Symbol            string
+	OrderId           int64
+	OrigClientOrderId string
+}
+
+// CancelOrder cancels an active order. Credentials are required. This endpoint is only
+// available on Spot and Perpetual (USD-M futures) markets. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#cancel-order-trade
+//   - https://binance-docs.github.io/apidocs/futures/en/#cancel-order-trade
+func (api *Api) CancelOrder(ctx context.Context, p CancelOrderParams) (OrderResponse, error) {
+	endpoint, err := api.endpoint("/api/v3/order", "/fapi/v1/order", "")
+	if err != nil {
+		return OrderResponse{}, apiErr("order", err)
+	}
+	if p.OrderId == 0 && p.OrigClientOrderId == "" {
+		return OrderResponse{}, apiErr(endpoint, errors.New("OrderId or OrigClientOrderId is required"))
+	}
+	params := map[string]string{"symbol": p.Symbol}
+	if p.OrderId != 0 {
+		params["orderId"] = strconv.FormatInt(p.OrderId, 10)
+	}
+	if p.OrigClientOrderId != "" {
+		params["origClientOrderId"] = p.OrigClientOrderId
+	}
+	return apiRequest[OrderResponse](ctx, api, http.MethodDelete, endpoint, params, securitySigned, 1)
+}
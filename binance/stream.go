@@ -1,24 +1,474 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/internal/set"
+	"github.com/bogdanovich/tradekit/internal/websocket"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
 )
 
+// A Stream represents a connection to a collection of Binance market data streams over
+// a single websocket connection to the combined stream endpoint. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#websocket-market-streams
+//
+// The following functions create Streams:
+//   - [NewTradeStream]
+//   - [NewAggTradeStream]
+//   - [NewOrderbookStream]
+//   - [NewBookDepthStream]
+//   - [NewBookTickerStream]
+//   - [NewKlineStream]
+//   - [NewMarkPriceStream]
+//   - [NewLiquidationStream]
+type Stream[T any, U subscription] interface {
+	// SetStreamOptions sets optional parameters for the stream. If used, it should be
+	// called before Start.
+	SetStreamOptions(*tradekit.StreamOptions)
+
+	// Start the stream. The stream must be started before any messages will be received
+	// or any new subscriptions may be made.
+	Start(context.Context) error
+
+	// Messages returns a channel of messages received from the stream's subscriptions.
+	Messages() <-chan T
+
+	// Err returns a channel which produces an error when there is an irrevocable failure
+	// with the stream's connection. It should be read concurrently with the Messages
+	// channel. If the channel produces and error, the stream stops and the Messages
+	// channel is closed, and no further subscriptions may be made.
+	Err() <-chan error
+
+	// Subscribe adds a new subscription to the stream. This is a no-op if the
+	// subscription already exists.
+	Subscribe(subs ...U)
+
+	// Unsubscribe removes a subscription from the stream. This is a no-op if the
+	// subscription does not already exist.
+	Unsubscribe(subs ...U)
+
+	// PendingMessagesCount returns the number of messages that have been received but not
+	// read from the Messages channel.
+	PendingMessagesCount() int
+}
+
+type subscription interface {
+	channel() string
+}
+
+type streamParams[T any, U subscription] struct {
+	name         string
+	wsUrl        string
+	parseMessage func(stream string, data *fastjson.Value) (T, error)
+	subs         []U
+	*tk.Params
+}
+
+// Binance closes every websocket connection after 24 hours. We reset the connection
+// slightly before then so that the reconnect happens on our own terms.
+const maxConnectionLifetime = 23*time.Hour + 50*time.Minute
+
+// maxChannelsPerRequest is the maximum number of streams sent in a single SUBSCRIBE or
+// UNSUBSCRIBE request.
+const maxChannelsPerRequest = 100
+
+var reconnectableErrors = []string{
+	"read tcp",
+	"write tcp",
+	"websocket: close sent",
+	"websocket: bad handshake",
+}
+
+// stream makes subscriptions to Binance market data streams and provides a channel to
+// receive the messages they produce.
+type stream[T any, U subscription] struct {
+	name          string
+	url           string
+	msgs          chan T
+	errc          chan error
+	parseMessage  func(stream string, data *fastjson.Value) (T, error)
+	subscriptions set.Set[string]
+	opts          *tradekit.StreamOptions
+
+	// urlChannels are the streams included in the URL of the current websocket
+	// connection. Binance subscribes to these automatically on every (re)connect.
+	urlChannels set.Set[string]
+
+	// pendingIds are the ids of SUBSCRIBE/UNSUBSCRIBE requests awaiting a response.
+	pendingIds map[int64]struct{}
+
+	subRequests          chan []U
+	unsubRequests        chan []U
+	subscribeAllRequests chan struct{}
+
+	closed atomic.Bool
+	p      fastjson.Parser
+	*tk.Params
+}
+
+func newStream[T any, U subscription](p streamParams[T, U]) *stream[T, U] {
+	channels := make([]string, len(p.subs))
+	for i, sub := range p.subs {
+		channels[i] = sub.channel()
+	}
+	if p.Params == nil {
+		p.Params = tk.DefaultParams()
+	}
+
+	return &stream[T, U]{
+		name:                 p.name,
+		url:                  p.wsUrl,
+		msgs:                 make(chan T, p.ChannelBufferSize),
+		errc:                 make(chan error, 1),
+		parseMessage:         p.parseMessage,
+		subscriptions:        set.New[string](channels...),
+		urlChannels:          set.New[string](),
+		pendingIds:           make(map[int64]struct{}),
+		subRequests:          make(chan []U, 10),
+		unsubRequests:        make(chan []U, 10),
+		subscribeAllRequests: make(chan struct{}, 10),
+		Params:               p.Params,
+	}
+}
+
+func (s *stream[T, U]) SetStreamOptions(opts *tradekit.StreamOptions) {
+	s.opts = opts
+}
+
+func (s *stream[T, U]) Start(ctx context.Context) error {
+	restartChan := make(chan struct{}, 1)
+
+	go func() {
+		defer func() {
+			s.closed.Store(true)
+			close(s.msgs)
+			close(s.errc)
+			close(s.subRequests)
+			close(s.unsubRequests)
+			close(s.subscribeAllRequests)
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-restartChan:
+				s.Logger.Info(s.namePrefix("reconnecting in 5 seconds..."))
+				time.Sleep(5 * time.Second)
+
+				if err := s.startWebsocketStream(ctx, restartChan); err != nil {
+					select {
+					case s.errc <- s.nameErr(err):
+					default:
+					}
+					return
+				}
+				s.Logger.Info(s.namePrefix("reconnected"))
+			}
+		}
+	}()
+
+	return s.startWebsocketStream(ctx, restartChan)
+}
+
+// streamOptions returns the options for the websocket connection with the reset
+// interval capped to the maximum lifetime of a Binance connection.
+func (s *stream[T, U]) streamOptions() *tradekit.StreamOptions {
+	var opts tradekit.StreamOptions
+	if s.opts != nil {
+		opts = *s.opts
+	}
+	if opts.ResetInterval == 0 || opts.ResetInterval > maxConnectionLifetime {
+		opts.ResetInterval = maxConnectionLifetime
+	}
+	return &opts
+}
+
+func (s *stream[T, U]) startWebsocketStream(ctx context.Context, restartChan chan struct{}) error {
+	s.closed.Store(false)
+
+	channels := s.subscriptions.Slice()
+	u, err := combinedStreamUrl(s.url, channels)
+	if err != nil {
+		return s.nameErr(err)
+	}
+	s.urlChannels = set.New[string](channels...)
+
+	ws := websocket.New(u, s.streamOptions())
+
+	ws.OnConnect = func() error {
+		// Subscriptions in the URL are made automatically by Binance. Any changes made
+		// since the URL was created are applied by subscribeAll.
+		s.subscribeAllRequests <- struct{}{}
+		return nil
+	}
+
+	go func() {
+		defer ws.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-ws.Messages():
+				if err := s.handleMessage(msg); err != nil {
+					s.errc <- s.nameErr(err)
+					return
+				}
+			case subs := <-s.subRequests:
+				if err := s.subscribe(&ws, subs...); err != nil {
+					s.errc <- s.nameErr(err)
+					return
+				}
+			case subs := <-s.unsubRequests:
+				if err := s.unsubscribe(&ws, subs...); err != nil {
+					s.errc <- s.nameErr(err)
+					return
+				}
+			case <-s.subscribeAllRequests:
+				if err := s.subscribeAll(&ws); err != nil {
+					s.errc <- s.nameErr(err)
+					return
+				}
+			case err := <-ws.Err():
+				if s.shouldReconnect(err) {
+					s.Logger.Error(s.nameErr(err).Error())
+					select {
+					case restartChan <- struct{}{}:
+					default:
+					}
+					return
+				}
+				s.errc <- s.nameErr(err)
+				return
+			}
+		}
+	}()
+
+	if err := ws.Start(ctx); err != nil {
+		return s.nameErr(fmt.Errorf("connecting to websocket: %w", err))
+	}
+
+	return nil
+}
+
+func (s *stream[T, U]) shouldReconnect(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, reconnectable := range reconnectableErrors {
+		if strings.Contains(err.Error(), reconnectable) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stream[T, U]) handleMessage(msg websocket.Message) error {
+	defer msg.Release()
+
+	v, err := s.p.ParseBytes(msg.Data())
+	if err != nil {
+		return fmt.Errorf("invalid message: %s", string(msg.Data()))
+	}
+
+	if v.Exists("id") {
+		// This is a response to a SUBSCRIBE or UNSUBSCRIBE request.
+		if v.Exists("error") || v.Exists("code") {
+			return fmt.Errorf("received error response: %s", string(msg.Data()))
+		}
+		id := v.GetInt64("id")
+		if _, ok := s.pendingIds[id]; !ok {
+			return fmt.Errorf("response from unknown request: %s", string(msg.Data()))
+		}
+		delete(s.pendingIds, id)
+		return nil
+	}
+
+	channel := string(v.GetStringBytes("stream"))
+	if !s.subscriptions.Exists(channel) {
+		// We've received a message from a stream which we're no longer subscribed to.
+		// It's okay to ignore it.
+		return nil
+	}
+
+	data := v.Get("data")
+	if data == nil {
+		return fmt.Errorf(`field "data" is missing: %s`, string(msg.Data()))
+	}
+	m, err := s.parseMessage(channel, data)
+	if err != nil {
+		return streamParseErr(channel, msg.Data(), err)
+	}
+	s.msgs <- m
+	return nil
+}
+
+// subscribeAll reconciles the streams in the connection URL with the stream's current
+// subscriptions.
+func (s *stream[T, U]) subscribeAll(ws *websocket.Websocket) error {
+	if s.closed.Load() {
+		return errors.New("stream is closed")
+	}
+	add := make([]string, 0)
+	for _, c := range s.subscriptions.Slice() {
+		if !s.urlChannels.Exists(c) {
+			add = append(add, c)
+		}
+	}
+	remove := make([]string, 0)
+	for _, c := range s.urlChannels.Slice() {
+		if !s.subscriptions.Exists(c) {
+			remove = append(remove, c)
+		}
+	}
+	if err := s.sendRequests(ws, "SUBSCRIBE", add); err != nil {
+		return err
+	}
+	return s.sendRequests(ws, "UNSUBSCRIBE", remove)
+}
+
+func (s *stream[T, U]) Subscribe(subs ...U) {
+	if s.closed.Load() {
+		return
+	}
+	s.subRequests <- subs
+}
+
+func (s *stream[T, U]) Unsubscribe(subs ...U) {
+	if s.closed.Load() {
+		return
+	}
+	s.unsubRequests <- subs
+}
+
+// subscribe to the provided subscriptions. If a channel already exists in the stream's
+// subscriptions then it will be ignored. Returns an error if the stream is closed.
+func (s *stream[T, U]) subscribe(ws *websocket.Websocket, subs ...U) error {
+	if s.closed.Load() {
+		return errors.New("stream is closed")
+	}
+	newChannels := make([]string, 0)
+	for _, sub := range subs {
+		c := sub.channel()
+		if !s.subscriptions.Exists(c) {
+			newChannels = append(newChannels, c)
+			s.subscriptions.Add(c)
+		}
+	}
+	return s.sendRequests(ws, "SUBSCRIBE", newChannels)
+}
+
+// unsubscribe from the provided subscriptions. Returns an error if the stream is closed.
+func (s *stream[T, U]) unsubscribe(ws *websocket.Websocket, subs ...U) error {
+	if s.closed.Load() {
+		return errors.New("stream is closed")
+	}
+	removeChannels := make([]string, 0)
+	for _, sub := range subs {
+		c := sub.channel()
+		if s.subscriptions.Pop(c) {
+			removeChannels = append(removeChannels, c)
+		}
+	}
+	return s.sendRequests(ws, "UNSUBSCRIBE", removeChannels)
+}
+
+// sendRequests sends SUBSCRIBE or UNSUBSCRIBE requests for the given channels in chunks
+// of maxChannelsPerRequest.
+func (s *stream[T, U]) sendRequests(ws *websocket.Websocket, method string, channels []string) error {
+	for i := 0; i < len(channels); i += maxChannelsPerRequest {
+		end := i + maxChannelsPerRequest
+		if end > len(channels) {
+			end = len(channels)
+		}
+		id := genId()
+		msg, err := newRequestMsg(id, method, channels[i:end])
+		if err != nil {
+			return err
+		}
+		s.pendingIds[id] = struct{}{}
+		ws.Send(msg)
+	}
+	return nil
+}
+
+func (s *stream[T, U]) Err() <-chan error {
+	return s.errc
+}
+
+func (s *stream[T, U]) Messages() <-chan T {
+	return s.msgs
+}
+
+func (s *stream[T, U]) PendingMessagesCount() int {
+	return len(s.msgs)
+}
+
+func (s *stream[T, U]) nameErr(err error) error {
+	return streamError(s.name, err)
+}
+
+func (s *stream[T, U]) namePrefix(msg string) string {
+	return fmt.Sprintf("Binance %s: %s", s.name, msg)
+}
+
+// combinedStreamUrl returns the URL of the combined stream endpoint for the given
+// channels. Any path on the provided base URL is replaced.
+func combinedStreamUrl(baseUrl string, channels []string) (string, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid wsUrl: %s", baseUrl)
+	}
+	u.Path = "/stream"
+	u.RawQuery = ""
+	if len(channels) > 0 {
+		// Stream names must not be escaped.
+		u.RawQuery = "streams=" + strings.Join(channels, "/")
+	}
+	return u.String(), nil
+}
+
+var lastId atomic.Int64
+
+// genId returns a unique, increasing request id.
 func genId() int64 {
-	return time.Now().UnixNano()
+	for {
+		last := lastId.Load()
+		id := time.Now().UnixNano()
+		if id <= last {
+			id = last + 1
+		}
+		if lastId.CompareAndSwap(last, id) {
+			return id
+		}
+	}
 }
 
-func newSubscribeMsg(id int64, channels []string) ([]byte, error) {
+func newRequestMsg(id int64, method string, channels []string) ([]byte, error) {
 	m := map[string]interface{}{
 		"id":     id,
-		"method": "SUBSCRIBE",
+		"method": method,
 		"params": channels,
 	}
 	return json.Marshal(m)
 }
 
+// dataParser adapts a message parser which doesn't need the name of the stream the
+// message was received from.
+func dataParser[T any](parse func(*fastjson.Value) (T, error)) func(string, *fastjson.Value) (T, error) {
+	return func(_ string, v *fastjson.Value) (T, error) {
+		return parse(v)
+	}
+}
+
 func streamParseErr(stream string, msg []byte, err error) error {
 	return fmt.Errorf("parsing Binance %s stream message: %w (%s)", stream, err, string(msg))
 }
@@ -0,0 +1,475 @@
+package binance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestCombinedStreamUrl(t *testing.T) {
+	u, err := combinedStreamUrl("wss://stream.binance.com:9443/ws", []string{"btcusdt@trade", "ethusdt@depth@100ms"})
+	assert.Nil(t, err)
+	assert.Equal(t, "wss://stream.binance.com:9443/stream?streams=btcusdt@trade/ethusdt@depth@100ms", u)
+
+	u, err = combinedStreamUrl("wss://fstream.binance.com", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "wss://fstream.binance.com/stream", u)
+}
+
+func TestSubscriptionChannels(t *testing.T) {
+	assert.Equal(t, "btcusdt@trade", TradeSub{Symbol: "BTCUSDT"}.channel())
+	assert.Equal(t, "btcusdt@aggTrade", AggTradeSub{Symbol: "BTCUSDT"}.channel())
+	assert.Equal(t, "btcusdt@depth@100ms", OrderbookSub{Symbol: "BTCUSDT"}.channel())
+}
+
+func TestParseTrade(t *testing.T) {
+	input := `
+	{
+	  "e": "trade",
+	  "E": 1672515782136,
+	  "s": "BNBBTC",
+	  "t": 12345,
+	  "p": "0.001",
+	  "q": "100",
+	  "b": 88,
+	  "a": 50,
+	  "T": 1672515782136,
+	  "m": true,
+	  "M": true
+	}
+	`
+	expected := Trade{
+		EventTime:     1672515782136,
+		Symbol:        "BNBBTC",
+		TradeId:       12345,
+		Price:         0.001,
+		Quantity:      100,
+		BuyerOrderId:  88,
+		SellerOrderId: 50,
+		TradeTime:     1672515782136,
+		IsBuyerMaker:  true,
+		M:             true,
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(input)
+	assert.Nil(t, err)
+	trade, err := parseTrade(v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, trade)
+
+	v, err = p.Parse(`{"e": "aggTrade"}`)
+	assert.Nil(t, err)
+	_, err = parseTrade(v)
+	assert.NotNil(t, err)
+}
+
+func TestParseBookUpdate(t *testing.T) {
+	input := `
+	{
+	  "e": "depthUpdate",
+	  "E": 123456789,
+	  "T": 123456788,
+	  "s": "BTCUSDT",
+	  "U": 157,
+	  "u": 160,
+	  "pu": 149,
+	  "b": [["0.0024", "10"]],
+	  "a": [["0.0026", "100"]]
+	}
+	`
+	expected := bookUpdate{
+		EventTime:         123456789,
+		Symbol:            "BTCUSDT",
+		FirstUpdateId:     157,
+		FinalUpdateId:     160,
+		PrevFinalUpdateId: 149,
+		Bids:              []tradekit.Level{{Price: 0.0024, Amount: 10}},
+		Asks:              []tradekit.Level{{Price: 0.0026, Amount: 100}},
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(input)
+	assert.Nil(t, err)
+	update, err := parseBookUpdate(v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, update)
+}
+
+func TestBookUpdateFollows(t *testing.T) {
+	spot := bookUpdate{FirstUpdateId: 11, FinalUpdateId: 15}
+	assert.True(t, spot.follows(10))
+	assert.False(t, spot.follows(9))
+
+	futures := bookUpdate{FirstUpdateId: 11, FinalUpdateId: 15, PrevFinalUpdateId: 8}
+	assert.True(t, futures.follows(8))
+	assert.False(t, futures.follows(10))
+}
+
+func TestOrderbookStreamSynced(t *testing.T) {
+	s := NewOrderbookStream("wss://stream.binance.com:9443", nil, nil).(*orderbookStream)
+	s.books["BTCUSDT"] = &bookSync{state: bookSynced, lastUpdateId: 10}
+
+	err := s.handleUpdate(context.Background(), bookUpdate{Symbol: "BTCUSDT", FirstUpdateId: 11, FinalUpdateId: 12})
+	assert.Nil(t, err)
+	assert.Equal(t, BookUpdate{Type: "change", Symbol: "BTCUSDT"}, <-s.msgs)
+	assert.Equal(t, int64(12), s.books["BTCUSDT"].lastUpdateId)
+}
+
+func TestParseKline(t *testing.T) {
+	input := `
+	{
+	  "e": "kline",
+	  "E": 1672515782136,
+	  "s": "BNBBTC",
+	  "k": {
+	    "t": 1672515780000,
+	    "T": 1672515839999,
+	    "s": "BNBBTC",
+	    "i": "1m",
+	    "f": 100,
+	    "L": 200,
+	    "o": "0.0010",
+	    "c": "0.0020",
+	    "h": "0.0025",
+	    "l": "0.0015",
+	    "v": "1000",
+	    "n": 100,
+	    "x": false,
+	    "q": "1.0000",
+	    "V": "500",
+	    "Q": "0.500",
+	    "B": "123456"
+	  }
+	}
+	`
+	expected := Kline{
+		EventTime:           1672515782136,
+		Symbol:              "BNBBTC",
+		Interval:            Interval1m,
+		StartTime:           1672515780000,
+		CloseTime:           1672515839999,
+		FirstTradeId:        100,
+		LastTradeId:         200,
+		Open:                0.001,
+		High:                0.0025,
+		Low:                 0.0015,
+		Close:               0.002,
+		Volume:              1000,
+		QuoteVolume:         1,
+		NumTrades:           100,
+		TakerBuyVolume:      500,
+		TakerBuyQuoteVolume: 0.5,
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(input)
+	assert.Nil(t, err)
+	kline, err := parseKlineMsg(v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, kline)
+	assert.Equal(t, "bnbbtc@kline_1m", KlineSub{Symbol: "BNBBTC", Interval: Interval1m}.channel())
+}
+
+func TestParseMarkPrice(t *testing.T) {
+	input := `
+	{
+	  "e": "markPriceUpdate",
+	  "E": 1562305380000,
+	  "s": "BTCUSDT",
+	  "p": "11794.15000000",
+	  "i": "11784.62659091",
+	  "P": "11784.25641265",
+	  "r": "0.00038167",
+	  "T": 1562306400000
+	}
+	`
+	expected := MarkPrice{
+		EventTime:       1562305380000,
+		Symbol:          "BTCUSDT",
+		MarkPrice:       11794.15,
+		IndexPrice:      11784.62659091,
+		EstSettlePrice:  11784.25641265,
+		FundingRate:     0.00038167,
+		NextFundingTime: 1562306400000,
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(input)
+	assert.Nil(t, err)
+	markPrice, err := parseMarkPrice(v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, markPrice)
+}
+
+func TestParseLiquidation(t *testing.T) {
+	input := `
+	{
+	  "e": "forceOrder",
+	  "E": 1568014460893,
+	  "o": {
+	    "s": "BTCUSDT",
+	    "S": "SELL",
+	    "o": "LIMIT",
+	    "f": "IOC",
+	    "q": "0.014",
+	    "p": "9910",
+	    "ap": "9910",
+	    "X": "FILLED",
+	    "l": "0.014",
+	    "z": "0.014",
+	    "T": 1568014460893
+	  }
+	}
+	`
+	expected := Liquidation{
+		EventTime:      1568014460893,
+		Symbol:         "BTCUSDT",
+		Side:           "SELL",
+		OrderType:      "LIMIT",
+		TimeInForce:    "IOC",
+		Quantity:       0.014,
+		Price:          9910,
+		AveragePrice:   9910,
+		Status:         "FILLED",
+		LastFilledQty:  0.014,
+		FilledQuantity: 0.014,
+		TradeTime:      1568014460893,
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(input)
+	assert.Nil(t, err)
+	liquidation, err := parseLiquidation(v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, liquidation)
+}
+
+func TestParseBookDepth(t *testing.T) {
+	spot := `
+	{
+	  "lastUpdateId": 160,
+	  "bids": [["0.0024", "10"]],
+	  "asks": [["0.0026", "100"]]
+	}
+	`
+	expected := BookDepth{
+		Symbol:       "BNBBTC",
+		LastUpdateId: 160,
+		Bids:         []tradekit.Level{{Price: 0.0024, Amount: 10}},
+		Asks:         []tradekit.Level{{Price: 0.0026, Amount: 100}},
+	}
+
+	var p fastjson.Parser
+	v, err := p.Parse(spot)
+	assert.Nil(t, err)
+	depth, err := parseBookDepth("bnbbtc@depth5@100ms", v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, depth)
+
+	futures := `
+	{
+	  "e": "depthUpdate",
+	  "E": 1571889248277,
+	  "T": 1571889248276,
+	  "s": "BTCUSDT",
+	  "U": 390497796,
+	  "u": 390497878,
+	  "pu": 390497794,
+	  "b": [["7403.89", "0.002"]],
+	  "a": [["7405.96", "3.340"]]
+	}
+	`
+	expected = BookDepth{
+		Symbol:          "BTCUSDT",
+		LastUpdateId:    390497878,
+		EventTime:       1571889248277,
+		TransactionTime: 1571889248276,
+		Bids:            []tradekit.Level{{Price: 7403.89, Amount: 0.002}},
+		Asks:            []tradekit.Level{{Price: 7405.96, Amount: 3.34}},
+	}
+	v, err = p.Parse(futures)
+	assert.Nil(t, err)
+	depth, err = parseBookDepth("btcusdt@depth5@100ms", v)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, depth)
+}
+
+func TestParseUserDataEvent(t *testing.T) {
+	var p fastjson.Parser
+
+	executionReport := `
+	{
+	  "e": "executionReport",
+	  "E": 1499405658658,
+	  "s": "ETHBTC",
+	  "c": "mUvoqJxFIILMdfAW5iGSOW",
+	  "S": "BUY",
+	  "o": "LIMIT",
+	  "f": "GTC",
+	  "q": "1.00000000",
+	  "p": "0.10264410",
+	  "P": "0.00000000",
+	  "C": "",
+	  "x": "TRADE",
+	  "X": "PARTIALLY_FILLED",
+	  "r": "NONE",
+	  "i": 4293153,
+	  "l": "0.50000000",
+	  "z": "0.50000000",
+	  "L": "0.10264410",
+	  "n": "0.00050000",
+	  "N": "ETH",
+	  "T": 1499405658657,
+	  "t": 12,
+	  "m": true,
+	  "O": 1499405658657,
+	  "Z": "0.05132205"
+	}
+	`
+	v, err := p.Parse(executionReport)
+	assert.Nil(t, err)
+	event, err := parseUserDataEvent(v)
+	assert.Nil(t, err)
+	assert.Equal(t, UserDataEvent{
+		Type:      EventExecutionReport,
+		EventTime: 1499405658658,
+		ExecutionReport: &ExecutionReport{
+			Symbol:                   "ETHBTC",
+			ClientOrderId:            "mUvoqJxFIILMdfAW5iGSOW",
+			Side:                     "BUY",
+			OrderType:                "LIMIT",
+			TimeInForce:              "GTC",
+			Quantity:                 1,
+			Price:                    0.1026441,
+			ExecutionType:            "TRADE",
+			Status:                   "PARTIALLY_FILLED",
+			RejectReason:             "NONE",
+			OrderId:                  4293153,
+			LastExecutedQuantity:     0.5,
+			CumulativeFilledQuantity: 0.5,
+			LastExecutedPrice:        0.1026441,
+			Commission:               0.0005,
+			CommissionAsset:          "ETH",
+			TransactionTime:          1499405658657,
+			TradeId:                  12,
+			IsMaker:                  true,
+			CreationTime:             1499405658657,
+			CumulativeQuoteQuantity:  0.05132205,
+		},
+	}, event)
+
+	orderUpdate := `
+	{
+	  "e": "ORDER_TRADE_UPDATE",
+	  "E": 1568879465651,
+	  "T": 1568879465650,
+	  "o": {
+	    "s": "BTCUSDT",
+	    "c": "TEST",
+	    "S": "SELL",
+	    "o": "LIMIT",
+	    "f": "GTC",
+	    "q": "0.001",
+	    "p": "7103.04",
+	    "ap": "7103.04",
+	    "sp": "0",
+	    "x": "TRADE",
+	    "X": "FILLED",
+	    "i": 8886774,
+	    "l": "0.001",
+	    "z": "0.001",
+	    "L": "7103.04",
+	    "N": "USDT",
+	    "n": "0.0028",
+	    "T": 1568879465650,
+	    "t": 123,
+	    "m": false,
+	    "R": true,
+	    "ps": "BOTH",
+	    "rp": "1.5"
+	  }
+	}
+	`
+	v, err = p.Parse(orderUpdate)
+	assert.Nil(t, err)
+	event, err = parseUserDataEvent(v)
+	assert.Nil(t, err)
+	assert.Equal(t, UserDataEvent{
+		Type:      EventOrderTradeUpdate,
+		EventTime: 1568879465651,
+		OrderUpdate: &OrderUpdate{
+			TransactionTime:          1568879465650,
+			Symbol:                   "BTCUSDT",
+			ClientOrderId:            "TEST",
+			Side:                     "SELL",
+			OrderType:                "LIMIT",
+			TimeInForce:              "GTC",
+			Quantity:                 0.001,
+			Price:                    7103.04,
+			AveragePrice:             7103.04,
+			ExecutionType:            "TRADE",
+			Status:                   "FILLED",
+			OrderId:                  8886774,
+			LastExecutedQuantity:     0.001,
+			CumulativeFilledQuantity: 0.001,
+			LastExecutedPrice:        7103.04,
+			CommissionAsset:          "USDT",
+			Commission:               0.0028,
+			TradeTime:                1568879465650,
+			TradeId:                  123,
+			ReduceOnly:               true,
+			PositionSide:             "BOTH",
+			RealizedProfit:           1.5,
+		},
+	}, event)
+
+	accountUpdate := `
+	{
+	  "e": "ACCOUNT_UPDATE",
+	  "E": 1564745798939,
+	  "T": 1564745798938,
+	  "a": {
+	    "m": "ORDER",
+	    "B": [{"a": "USDT", "wb": "122624.12345678", "cw": "100.12345678", "bc": "50.12345678"}],
+	    "P": [{"s": "BTCUSDT", "pa": "20", "ep": "6563.66500", "bep": "6563.6", "cr": "0", "up": "2850.21200", "mt": "isolated", "iw": "13200.70726908", "ps": "LONG"}]
+	  }
+	}
+	`
+	v, err = p.Parse(accountUpdate)
+	assert.Nil(t, err)
+	event, err = parseUserDataEvent(v)
+	assert.Nil(t, err)
+	assert.Equal(t, UserDataEvent{
+		Type:      EventAccountUpdate,
+		EventTime: 1564745798939,
+		AccountUpdate: &AccountUpdate{
+			TransactionTime: 1564745798938,
+			Reason:          "ORDER",
+			Balances: []BalanceUpdate{
+				{Asset: "USDT", WalletBalance: 122624.12345678, CrossWalletBalance: 100.12345678, BalanceChange: 50.12345678},
+			},
+			Positions: []PositionUpdate{
+				{
+					Symbol:         "BTCUSDT",
+					PositionAmount: 20,
+					EntryPrice:     6563.665,
+					BreakEvenPrice: 6563.6,
+					UnrealizedPnl:  2850.212,
+					MarginType:     "isolated",
+					IsolatedWallet: 13200.70726908,
+					PositionSide:   "LONG",
+				},
+			},
+		},
+	}, event)
+
+	v, err = p.Parse(`{"e": "listenKeyExpired", "E": 1576653824250}`)
+	assert.Nil(t, err)
+	event, err = parseUserDataEvent(v)
+	assert.Nil(t, err)
+	assert.Equal(t, UserDataEvent{Type: EventListenKeyExpired, EventTime: 1576653824250}, event)
+}
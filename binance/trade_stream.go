@@ -2,16 +2,16 @@ package binance
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
-	"github.com/bogdanovich/tradekit/internal/websocket"
+	"github.com/bogdanovich/tradekit/lib/tk"
 	"github.com/valyala/fastjson"
 )
 
-// Trade is the type produced by a Binance TradeStream.
+// Trade is the type produced by a Binance trade stream.
 type Trade struct {
 	// EventTime is the timestamp at which Binance produced the message.
 	EventTime     int64   `json:"E"`
@@ -27,52 +27,42 @@ type Trade struct {
 	M            bool  `json:"M"`
 }
 
-// TradeStream provides a realtime stream of trades from Binance. This stream is only
-// available on spot markets.
-type TradeStream struct {
-	url     string
-	symbols []string
-	msgs    chan Trade
-	errc    chan error
-	p       fastjson.Parser
-	subIds  map[int64]struct{}
+// TradeSub represents a subscription to the trades of a symbol. See [NewTradeStream].
+type TradeSub struct {
+	Symbol string
 }
 
-func NewTradeSteam(url string, symbols ...string) *TradeStream {
-	errc := make(chan error, 1)
-	msgs := make(chan Trade, 10)
-	subIds := make(map[int64]struct{})
-	return &TradeStream{url: url, symbols: symbols, msgs: msgs, errc: errc, subIds: subIds}
+func (s TradeSub) channel() string {
+	return fmt.Sprintf("%s@trade", strings.ToLower(s.Symbol))
 }
 
-func (s *TradeStream) parseTradeMsg(msg websocket.Message) (Trade, error) {
-	defer msg.Release()
-	v, err := s.p.ParseBytes(msg.Data())
-	if err != nil {
-		return Trade{}, streamParseErr("trade", msg.Data(), err)
-	}
-
-	if id := v.GetInt64("id"); id != 0 {
-		// This should be a subscription response message
-		if _, ok := s.subIds[id]; ok {
-			delete(s.subIds, id)
-			return Trade{}, nil
-		}
-		return Trade{}, streamParseErr("trade", msg.Data(), errors.New("expected a trade message"))
+// NewTradeStream creates a new [Stream] which produces a realtime stream of trades. This
+// stream is only available on spot markets. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#trade-streams
+func NewTradeStream(wsUrl string, subs []TradeSub, paramFuncs ...tk.Param) Stream[Trade, TradeSub] {
+	p := streamParams[Trade, TradeSub]{
+		name:         "TradeStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseTrade),
+		subs:         subs,
+		Params:       tk.ApplyParams(paramFuncs),
 	}
+	return newStream(p)
+}
 
+func parseTrade(v *fastjson.Value) (Trade, error) {
 	eventType := v.GetStringBytes("e")
 	if !bytes.Equal(eventType, []byte("trade")) {
-		return Trade{}, streamParseErr("trade", msg.Data(), errors.New("expected trade type"))
+		return Trade{}, errors.New("expected trade type")
 	}
 
 	price, err := strconv.ParseFloat(string(v.GetStringBytes("p")), 64)
 	if err != nil {
-		return Trade{}, streamParseErr("trade", msg.Data(), errors.New("invalid price"))
+		return Trade{}, errors.New("invalid price")
 	}
 	quantity, err := strconv.ParseFloat(string(v.GetStringBytes("q")), 64)
 	if err != nil {
-		return Trade{}, streamParseErr("trade", msg.Data(), errors.New("invalid quanity"))
+		return Trade{}, errors.New("invalid quantity")
 	}
 
 	return Trade{
@@ -88,55 +78,3 @@ func (s *TradeStream) parseTradeMsg(msg websocket.Message) (Trade, error) {
 		M:             v.GetBool("M"),
 	}, nil
 }
-
-func (s *TradeStream) Start(ctx context.Context) error {
-	// Websocket setup
-	ws := websocket.New(s.url, nil)
-	ws.OnConnect = func() error {
-		channels := make([]string, len(s.symbols))
-		for i, symbol := range s.symbols {
-			channels[i] = fmt.Sprintf("%s@trade", symbol)
-		}
-		id := genId()
-		subMsg, err := newSubscribeMsg(id, channels)
-		if err != nil {
-			return streamError("TradeStream", err)
-		}
-		s.subIds[id] = struct{}{}
-		ws.Send(subMsg)
-		return nil
-	}
-	if err := ws.Start(ctx); err != nil {
-		return streamError("TradeStream", fmt.Errorf("websocket connect: %w", err))
-	}
-
-	go func() {
-		defer close(s.msgs)
-		for {
-			select {
-			case data := <-ws.Messages():
-				msg, err := s.parseTradeMsg(data)
-				if err != nil {
-					s.errc <- streamError("TradeStream", err)
-					return
-				}
-				if msg.Symbol != "" {
-					s.msgs <- msg
-				}
-			case err := <-ws.Err():
-				s.errc <- streamError("TradeStream", err)
-				return
-			}
-		}
-	}()
-
-	return nil
-}
-
-func (s *TradeStream) Messages() <-chan Trade {
-	return s.msgs
-}
-
-func (s *TradeStream) Err() <-chan error {
-	return s.errc
-}
@@ -0,0 +1,395 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/lib/conv"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// User data event types
+const (
+	EventExecutionReport  = "executionReport"
+	EventOrderTradeUpdate = "ORDER_TRADE_UPDATE"
+	EventAccountUpdate    = "ACCOUNT_UPDATE"
+	EventListenKeyExpired = "listenKeyExpired"
+)
+
+// UserDataEvent is the type produced by a Binance user data stream. The field matching
+// the Type of the event is set. Events of other types, for example
+// "outboundAccountPosition", are produced with only their Type and EventTime set.
+type UserDataEvent struct {
+	Type      string
+	EventTime int64
+	// ExecutionReport is set for executionReport events on spot markets.
+	ExecutionReport *ExecutionReport
+	// OrderUpdate is set for ORDER_TRADE_UPDATE events on futures markets.
+	OrderUpdate *OrderUpdate
+	// AccountUpdate is set for ACCOUNT_UPDATE events on futures markets.
+	AccountUpdate *AccountUpdate
+}
+
+// ExecutionReport is an update to an order on a spot market.
+type ExecutionReport struct {
+	Symbol                   string
+	ClientOrderId            string
+	Side                     string
+	OrderType                string
+	TimeInForce              string
+	Quantity                 float64
+	Price                    float64
+	StopPrice                float64
+	OrigClientOrderId        string
+	ExecutionType            string
+	Status                   string
+	RejectReason             string
+	OrderId                  int64
+	LastExecutedQuantity     float64
+	CumulativeFilledQuantity float64
+	LastExecutedPrice        float64
+	Commission               float64
+	CommissionAsset          string
+	TransactionTime          int64
+	TradeId                  int64
+	IsMaker                  bool
+	CreationTime             int64
+	CumulativeQuoteQuantity  float64
+}
+
+// OrderUpdate is an update to an order on a futures market.
+type OrderUpdate struct {
+	TransactionTime          int64
+	Symbol                   string
+	ClientOrderId            string
+	Side                     string
+	OrderType                string
+	TimeInForce              string
+	Quantity                 float64
+	Price                    float64
+	AveragePrice             float64
+	StopPrice                float64
+	ExecutionType            string
+	Status                   string
+	OrderId                  int64
+	LastExecutedQuantity     float64
+	CumulativeFilledQuantity float64
+	LastExecutedPrice        float64
+	CommissionAsset          string
+	Commission               float64
+	TradeTime                int64
+	TradeId                  int64
+	IsMaker                  bool
+	ReduceOnly               bool
+	PositionSide             string
+	RealizedProfit           float64
+}
+
+// AccountUpdate is a change to the balances or positions of a futures account.
+type AccountUpdate struct {
+	TransactionTime int64
+	// Reason is the event which caused the update e.g. "ORDER" or "FUNDING_FEE".
+	Reason    string
+	Balances  []BalanceUpdate
+	Positions []PositionUpdate
+}
+
+// BalanceUpdate is the balance of an asset in an AccountUpdate.
+type BalanceUpdate struct {
+	Asset              string
+	WalletBalance      float64
+	CrossWalletBalance float64
+	BalanceChange      float64
+}
+
+// PositionUpdate is a position in an AccountUpdate.
+type PositionUpdate struct {
+	Symbol              string
+	PositionAmount      float64
+	EntryPrice          float64
+	BreakEvenPrice      float64
+	AccumulatedRealized float64
+	UnrealizedPnl       float64
+	MarginType          string
+	IsolatedWallet      float64
+	PositionSide        string
+}
+
+// UserDataStream is a stream of events for a Binance account. See [NewUserDataStream].
+type UserDataStream interface {
+	// SetStreamOptions sets optional parameters for the stream. If used, it should be
+	// called before Start.
+	SetStreamOptions(*tradekit.StreamOptions)
+	// Start the stream. The stream must be started before any messages will be sent to
+	// the Messages channel.
+	Start(context.Context) error
+	// Messages returns a channel of messages produced by the stream.
+	Messages() <-chan UserDataEvent
+	// Err returns a channel which produces an error when there is an irrecoverable
+	// problem with the stream. If an error is produced, the Messages channel will be
+	// closed.
+	Err() <-chan error
+	// PendingMessagesCount returns the number of messages in the stream that are yet to be
+	// read from the Messages channel.
+	PendingMessagesCount() int
+}
+
+// Binance expires a listen key after 60 minutes unless it's kept alive.
+const listenKeyKeepAliveInterval = 30 * time.Minute
+
+// listenKeySub is a subscription to the user data stream of a listen key.
+type listenKeySub struct {
+	listenKey string
+}
+
+func (s listenKeySub) channel() string {
+	return s.listenKey
+}
+
+// userDataStream manages the listen key of a stream of user data events.
+type userDataStream struct {
+	*stream[UserDataEvent, listenKeySub]
+	api       *Api
+	msgs      chan UserDataEvent
+	errc      chan error
+	listenKey string
+}
+
+// NewUserDataStream creates a new [UserDataStream] which produces order and account
+// updates for the account of the Api's credentials. The stream creates a listen key when
+// it's started, keeps it alive every 30 minutes, replaces it if it expires, and closes it
+// when the stream's context is cancelled.
+//
+// The provided wsUrl should match the market of the Api, and the Api must have
+// credentials set. For details see:
+//   - https://binance-docs.github.io/apidocs/spot/en/#user-data-streams
+//   - https://binance-docs.github.io/apidocs/futures/en/#user-data-streams
+func NewUserDataStream(wsUrl string, api *Api, paramFuncs ...tk.Param) UserDataStream {
+	p := streamParams[UserDataEvent, listenKeySub]{
+		name:         "UserDataStream",
+		wsUrl:        wsUrl,
+		parseMessage: dataParser(parseUserDataEvent),
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	s := newStream(p)
+	return &userDataStream{
+		stream: s,
+		api:    api,
+		msgs:   make(chan UserDataEvent, s.ChannelBufferSize),
+		errc:   make(chan error, 1),
+	}
+}
+
+func (s *userDataStream) Start(ctx context.Context) error {
+	listenKey, err := s.api.CreateListenKey(ctx)
+	if err != nil {
+		return s.nameErr(err)
+	}
+	s.listenKey = listenKey
+	s.stream.subscriptions.Add(listenKeySub{listenKey}.channel())
+
+	if err := s.stream.Start(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() {
+			// The stream's context is done, so the key is closed with a new context.
+			if err := s.api.CloseListenKey(context.Background(), s.listenKey); err != nil {
+				s.Logger.Error(s.namePrefix(fmt.Sprintf("closing listen key: %s", err)))
+			}
+			close(s.msgs)
+			close(s.errc)
+		}()
+		ticker := time.NewTicker(listenKeyKeepAliveInterval)
+		defer ticker.Stop()
+		events := s.stream.Messages()
+		errc := s.stream.Err()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.api.KeepAliveListenKey(ctx, s.listenKey); err != nil {
+					s.Logger.Error(s.namePrefix(fmt.Sprintf("keeping listen key alive: %s", err)))
+					if err := s.renewListenKey(ctx); err != nil {
+						s.errc <- s.nameErr(err)
+						return
+					}
+				}
+			case m, ok := <-events:
+				if !ok {
+					return
+				}
+				if m.Type == EventListenKeyExpired {
+					s.Logger.Info(s.namePrefix("listen key expired, creating a new one"))
+					if err := s.renewListenKey(ctx); err != nil {
+						s.errc <- s.nameErr(err)
+						return
+					}
+				}
+				s.msgs <- m
+			case err, ok := <-errc:
+				if !ok {
+					return
+				}
+				s.errc <- err
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// renewListenKey creates a new listen key and moves the stream's subscription to it.
+func (s *userDataStream) renewListenKey(ctx context.Context) error {
+	listenKey, err := s.api.CreateListenKey(ctx)
+	if err != nil {
+		return err
+	}
+	// Futures markets return the existing key if it's still valid.
+	if listenKey == s.listenKey {
+		return nil
+	}
+	s.stream.Subscribe(listenKeySub{listenKey})
+	s.stream.Unsubscribe(listenKeySub{s.listenKey})
+	s.listenKey = listenKey
+	return nil
+}
+
+// Messages returns a channel for reading UserDataEvent messages produced by the stream.
+func (s *userDataStream) Messages() <-chan UserDataEvent {
+	return s.msgs
+}
+
+// Err returns a channel which produces an error if there is a problem with the stream.
+// If an error is produced, then the Messages channel will be closed.
+func (s *userDataStream) Err() <-chan error {
+	return s.errc
+}
+
+func (s *userDataStream) PendingMessagesCount() int {
+	return len(s.msgs)
+}
+
+func parseUserDataEvent(v *fastjson.Value) (UserDataEvent, error) {
+	event := UserDataEvent{
+		Type:      string(v.GetStringBytes("e")),
+		EventTime: v.GetInt64("E"),
+	}
+	switch event.Type {
+	case "":
+		return UserDataEvent{}, errors.New(`field "e" is missing`)
+	case EventExecutionReport:
+		r := parseExecutionReport(v)
+		event.ExecutionReport = &r
+	case EventOrderTradeUpdate:
+		o := v.Get("o")
+		if o == nil {
+			return UserDataEvent{}, errors.New(`field "o" is missing`)
+		}
+		u := parseOrderUpdate(o)
+		u.TransactionTime = v.GetInt64("T")
+		event.OrderUpdate = &u
+	case EventAccountUpdate:
+		a := v.Get("a")
+		if a == nil {
+			return UserDataEvent{}, errors.New(`field "a" is missing`)
+		}
+		u := parseAccountUpdate(a)
+		u.TransactionTime = v.GetInt64("T")
+		event.AccountUpdate = &u
+	}
+	return event, nil
+}
+
+func parseExecutionReport(v *fastjson.Value) ExecutionReport {
+	return ExecutionReport{
+		Symbol:                   string(v.GetStringBytes("s")),
+		ClientOrderId:            string(v.GetStringBytes("c")),
+		Side:                     string(v.GetStringBytes("S")),
+		OrderType:                string(v.GetStringBytes("o")),
+		TimeInForce:              string(v.GetStringBytes("f")),
+		Quantity:                 conv.BytesToFloat(v.GetStringBytes("q")),
+		Price:                    conv.BytesToFloat(v.GetStringBytes("p")),
+		StopPrice:                conv.BytesToFloat(v.GetStringBytes("P")),
+		OrigClientOrderId:        string(v.GetStringBytes("C")),
+		ExecutionType:            string(v.GetStringBytes("x")),
+		Status:                   string(v.GetStringBytes("X")),
+		RejectReason:             string(v.GetStringBytes("r")),
+		OrderId:                  v.GetInt64("i"),
+		LastExecutedQuantity:     conv.BytesToFloat(v.GetStringBytes("l")),
+		CumulativeFilledQuantity: conv.BytesToFloat(v.GetStringBytes("z")),
+		LastExecutedPrice:        conv.BytesToFloat(v.GetStringBytes("L")),
+		Commission:               conv.BytesToFloat(v.GetStringBytes("n")),
+		CommissionAsset:          string(v.GetStringBytes("N")),
+		TransactionTime:          v.GetInt64("T"),
+		TradeId:                  v.GetInt64("t"),
+		IsMaker:                  v.GetBool("m"),
+		CreationTime:             v.GetInt64("O"),
+		CumulativeQuoteQuantity:  conv.BytesToFloat(v.GetStringBytes("Z")),
+	}
+}
+
+func parseOrderUpdate(o *fastjson.Value) OrderUpdate {
+	return OrderUpdate{
+		Symbol:                   string(o.GetStringBytes("s")),
+		ClientOrderId:            string(o.GetStringBytes("c")),
+		Side:                     string(o.GetStringBytes("S")),
+		OrderType:                string(o.GetStringBytes("o")),
+		TimeInForce:              string(o.GetStringBytes("f")),
+		Quantity:                 conv.BytesToFloat(o.GetStringBytes("q")),
+		Price:                    conv.BytesToFloat(o.GetStringBytes("p")),
+		AveragePrice:             conv.BytesToFloat(o.GetStringBytes("ap")),
+		StopPrice:                conv.BytesToFloat(o.GetStringBytes("sp")),
+		ExecutionType:            string(o.GetStringBytes("x")),
+		Status:                   string(o.GetStringBytes("X")),
+		OrderId:                  o.GetInt64("i"),
+		LastExecutedQuantity:     conv.BytesToFloat(o.GetStringBytes("l")),
+		CumulativeFilledQuantity: conv.BytesToFloat(o.GetStringBytes("z")),
+		LastExecutedPrice:        conv.BytesToFloat(o.GetStringBytes("L")),
+		CommissionAsset:          string(o.GetStringBytes("N")),
+		Commission:               conv.BytesToFloat(o.GetStringBytes("n")),
+		TradeTime:                o.GetInt64("T"),
+		TradeId:                  o.GetInt64("t"),
+		IsMaker:                  o.GetBool("m"),
+		ReduceOnly:               o.GetBool("R"),
+		PositionSide:             string(o.GetStringBytes("ps")),
+		RealizedProfit:           conv.BytesToFloat(o.GetStringBytes("rp")),
+	}
+}
+
+func parseAccountUpdate(a *fastjson.Value) AccountUpdate {
+	balances := a.GetArray("B")
+	positions := a.GetArray("P")
+	u := AccountUpdate{
+		Reason:    string(a.GetStringBytes("m")),
+		Balances:  make([]BalanceUpdate, len(balances)),
+		Positions: make([]PositionUpdate, len(positions)),
+	}
+	for i, b := range balances {
+		u.Balances[i] = BalanceUpdate{
+			Asset:              string(b.GetStringBytes("a")),
+			WalletBalance:      conv.BytesToFloat(b.GetStringBytes("wb")),
+			CrossWalletBalance: conv.BytesToFloat(b.GetStringBytes("cw")),
+			BalanceChange:      conv.BytesToFloat(b.GetStringBytes("bc")),
+		}
+	}
+	for i, p := range positions {
+		u.Positions[i] = PositionUpdate{
+			Symbol:              string(p.GetStringBytes("s")),
+			PositionAmount:      conv.BytesToFloat(p.GetStringBytes("pa")),
+			EntryPrice:          conv.BytesToFloat(p.GetStringBytes("ep")),
+			BreakEvenPrice:      conv.BytesToFloat(p.GetStringBytes("bep")),
+			AccumulatedRealized: conv.BytesToFloat(p.GetStringBytes("cr")),
+			UnrealizedPnl:       conv.BytesToFloat(p.GetStringBytes("up")),
+			MarginType:          string(p.GetStringBytes("mt")),
+			IsolatedWallet:      conv.BytesToFloat(p.GetStringBytes("iw")),
+			PositionSide:        string(p.GetStringBytes("ps")),
+		}
+	}
+	return u
+}
@@ -104,6 +104,43 @@ func (s SpotTickerData) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// FuturesTicker is the type produced by a futures ticker stream. Bybit sends a snapshot
+// of the ticker followed by deltas of its changed fields, which are merged into the
+// previous ticker, so each FuturesTicker has the full state of the ticker.
+type FuturesTicker struct {
+	Topic         string            `json:"topic" parquet:"name=topic, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp     int64             `json:"ts" parquet:"name=timestamp, type=INT64"`
+	Type          string            `json:"type" parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CrossSequence int64             `json:"cs" parquet:"name=cross_sequence, type=INT64"`
+	Data          FuturesTickerData `json:"data" parquet:"name=data"`
+}
+
+type FuturesTickerData struct {
+	Symbol                 string  `json:"symbol" parquet:"name=symbol, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TickDirection          string  `json:"tickDirection" parquet:"name=tick_direction, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LastPrice              float64 `json:"lastPrice" parquet:"name=last_price, type=DOUBLE"`
+	MarkPrice              float64 `json:"markPrice" parquet:"name=mark_price, type=DOUBLE"`
+	IndexPrice             float64 `json:"indexPrice" parquet:"name=index_price, type=DOUBLE"`
+	HighPrice24h           float64 `json:"highPrice24h" parquet:"name=high_price_24h, type=DOUBLE"`
+	LowPrice24h            float64 `json:"lowPrice24h" parquet:"name=low_price_24h, type=DOUBLE"`
+	PrevPrice24h           float64 `json:"prevPrice24h" parquet:"name=prev_price_24h, type=DOUBLE"`
+	PrevPrice1h            float64 `json:"prevPrice1h" parquet:"name=prev_price_1h, type=DOUBLE"`
+	Price24hPcnt           float64 `json:"price24hPcnt" parquet:"name=price_24h_pcnt, type=DOUBLE"`
+	Volume24h              float64 `json:"volume24h" parquet:"name=volume_24h, type=DOUBLE"`
+	Turnover24h            float64 `json:"turnover24h" parquet:"name=turnover_24h, type=DOUBLE"`
+	OpenInterest           float64 `json:"openInterest" parquet:"name=open_interest, type=DOUBLE"`
+	OpenInterestValue      float64 `json:"openInterestValue" parquet:"name=open_interest_value, type=DOUBLE"`
+	FundingRate            float64 `json:"fundingRate" parquet:"name=funding_rate, type=DOUBLE"`
+	NextFundingTime        int64   `json:"nextFundingTime" parquet:"name=next_funding_time, type=INT64"`
+	Bid1Price              float64 `json:"bid1Price" parquet:"name=bid1_price, type=DOUBLE"`
+	Bid1Size               float64 `json:"bid1Size" parquet:"name=bid1_size, type=DOUBLE"`
+	Ask1Price              float64 `json:"ask1Price" parquet:"name=ask1_price, type=DOUBLE"`
+	Ask1Size               float64 `json:"ask1Size" parquet:"name=ask1_size, type=DOUBLE"`
+	DeliveryTime           string  `json:"deliveryTime" parquet:"name=delivery_time, type=BYTE_ARRAY, convertedtype=UTF8"` // Delivery time of dated futures e.g. 2025-01-24T08:00:00Z
+	BasisRate              float64 `json:"basisRate" parquet:"name=basis_rate, type=DOUBLE"`
+	PredictedDeliveryPrice float64 `json:"predictedDeliveryPrice" parquet:"name=predicted_delivery_price, type=DOUBLE"`
+}
+
 type Liquidation struct {
 	Topic     string          `json:"topic"`
 	Type      string          `json:"type"`
@@ -277,3 +314,78 @@ func ParseLiquidation(v *fastjson.Value) Liquidation {
 		Data:      data,
 	}
 }
+
+// futuresTickerMerger merges the deltas of futures tickers into their previous state.
+type futuresTickerMerger struct {
+	tickers map[string]FuturesTickerData
+}
+
+func newFuturesTickerMerger() *futuresTickerMerger {
+	return &futuresTickerMerger{tickers: make(map[string]FuturesTickerData)}
+}
+
+func (m *futuresTickerMerger) merge(v *fastjson.Value) FuturesTicker {
+	data := v.Get("data")
+	if data == nil {
+		return FuturesTicker{}
+	}
+	typ := string(v.GetStringBytes("type"))
+	symbol := string(data.GetStringBytes("symbol"))
+	var d FuturesTickerData
+	if typ != "snapshot" {
+		d = m.tickers[symbol]
+	}
+	MergeFuturesTickerData(&d, data)
+	m.tickers[symbol] = d
+	return FuturesTicker{
+		Topic:         string(v.GetStringBytes("topic")),
+		Timestamp:     v.GetInt64("ts"),
+		Type:          typ,
+		CrossSequence: v.GetInt64("cs"),
+		Data:          d,
+	}
+}
+
+// MergeFuturesTickerData sets the fields of d which are present in v, which is the data
+// of a futures ticker snapshot or delta.
+func MergeFuturesTickerData(d *FuturesTickerData, v *fastjson.Value) {
+	mergeString(&d.Symbol, v, "symbol")
+	mergeString(&d.TickDirection, v, "tickDirection")
+	mergeFloat(&d.LastPrice, v, "lastPrice")
+	mergeFloat(&d.MarkPrice, v, "markPrice")
+	mergeFloat(&d.IndexPrice, v, "indexPrice")
+	mergeFloat(&d.HighPrice24h, v, "highPrice24h")
+	mergeFloat(&d.LowPrice24h, v, "lowPrice24h")
+	mergeFloat(&d.PrevPrice24h, v, "prevPrice24h")
+	mergeFloat(&d.PrevPrice1h, v, "prevPrice1h")
+	mergeFloat(&d.Price24hPcnt, v, "price24hPcnt")
+	mergeFloat(&d.Volume24h, v, "volume24h")
+	mergeFloat(&d.Turnover24h, v, "turnover24h")
+	mergeFloat(&d.OpenInterest, v, "openInterest")
+	mergeFloat(&d.OpenInterestValue, v, "openInterestValue")
+	mergeFloat(&d.FundingRate, v, "fundingRate")
+	if x := v.GetStringBytes("nextFundingTime"); x != nil {
+		d.NextFundingTime, _ = strconv.ParseInt(string(x), 10, 64)
+	}
+	mergeFloat(&d.Bid1Price, v, "bid1Price")
+	mergeFloat(&d.Bid1Size, v, "bid1Size")
+	mergeFloat(&d.Ask1Price, v, "ask1Price")
+	mergeFloat(&d.Ask1Size, v, "ask1Size")
+	mergeString(&d.DeliveryTime, v, "deliveryTime")
+	mergeFloat(&d.BasisRate, v, "basisRate")
+	mergeFloat(&d.PredictedDeliveryPrice, v, "predictedDeliveryPrice")
+}
+
+// mergeFloat sets dst to the number in the string field key of v, if it's present and
+// not empty.
+func mergeFloat(dst *float64, v *fastjson.Value, key string) {
+	if x := v.GetStringBytes(key); len(x) > 0 {
+		*dst = conv.BytesToFloat(x)
+	}
+}
+
+func mergeString(dst *string, v *fastjson.Value, key string) {
+	if x := v.GetStringBytes(key); x != nil {
+		*dst = string(x)
+	}
+}
@@ -200,3 +200,61 @@ func TestMarshalUnmarshalTrade(t *testing.T) {
 	trade := ParseTrade(v)
 	assert.Equal(t, expected, trade)
 }
+
+func TestFuturesTickerMerge(t *testing.T) {
+	m := newFuturesTickerMerger()
+	snapshot := fastjson.MustParse(`{
+		"topic": "tickers.BTCUSDT",
+		"type": "snapshot",
+		"data": {
+			"symbol": "BTCUSDT",
+			"tickDirection": "PlusTick",
+			"lastPrice": "97000.5",
+			"markPrice": "97001.2",
+			"indexPrice": "96990.1",
+			"fundingRate": "0.0001",
+			"nextFundingTime": "1736236800000",
+			"bid1Price": "97000.4",
+			"ask1Price": "97000.5"
+		},
+		"cs": 100,
+		"ts": 1736220000000
+	}`)
+	first := m.merge(snapshot)
+	assert.Equal(t, FuturesTicker{
+		Topic:         "tickers.BTCUSDT",
+		Type:          "snapshot",
+		Timestamp:     1736220000000,
+		CrossSequence: 100,
+		Data: FuturesTickerData{
+			Symbol:          "BTCUSDT",
+			TickDirection:   "PlusTick",
+			LastPrice:       97000.5,
+			MarkPrice:       97001.2,
+			IndexPrice:      96990.1,
+			FundingRate:     0.0001,
+			NextFundingTime: 1736236800000,
+			Bid1Price:       97000.4,
+			Ask1Price:       97000.5,
+		},
+	}, first)
+
+	delta := fastjson.MustParse(`{
+		"topic": "tickers.BTCUSDT",
+		"type": "delta",
+		"data": {"symbol": "BTCUSDT", "markPrice": "97010", "fundingRate": "0.00012"},
+		"cs": 101,
+		"ts": 1736220000100
+	}`)
+	second := m.merge(delta)
+	expected := first.Data
+	expected.MarkPrice = 97010
+	expected.FundingRate = 0.00012
+	assert.Equal(t, expected, second.Data)
+	assert.Equal(t, "delta", second.Type)
+	assert.Equal(t, int64(1736220000100), second.Timestamp)
+
+	// A delta of another symbol starts from an empty ticker.
+	other := m.merge(fastjson.MustParse(`{"type":"delta","data":{"symbol":"ETHUSDT","lastPrice":"3000"}}`))
+	assert.Equal(t, FuturesTickerData{Symbol: "ETHUSDT", LastPrice: 3000}, other.Data)
+}
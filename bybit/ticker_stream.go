@@ -34,3 +34,22 @@ func NewSpotTickerStream(wsUrl string, subs []TickerSub, paramFuncs ...tk.Param)
 	}
 	return newStream[SpotTicker](params)
 }
+
+// NewFuturesTickerStream returns a stream of the tickers of linear or inverse futures,
+// including perpetuals, which have their funding rate. The wsUrl is the public linear or
+// inverse endpoint e.g. wss://stream.bybit.com/v5/public/linear. For details see:
+//   - https://bybit-exchange.github.io/docs/v5/websocket/public/ticker
+func NewFuturesTickerStream(wsUrl string, subs []TickerSub, paramFuncs ...tk.Param) Stream[FuturesTicker] {
+	subscriptions := make([]subscription, len(subs))
+	for i, sub := range subs {
+		subscriptions[i] = sub
+	}
+	params := streamParams[FuturesTicker]{
+		name:         "FuturesTickerStream",
+		wsUrl:        wsUrl,
+		parseMessage: newFuturesTickerMerger().merge,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream[FuturesTicker](params)
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func main() {
-	_, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
 	api, err := deribit.NewApi("https://www.deribit.com/api/v2/")
@@ -18,7 +18,7 @@ func main() {
 		panic(err)
 	}
 
-	positions, err := api.GetPositions(deribit.GetPositionsParams{
+	positions, err := api.GetPositions(ctx, deribit.GetPositionsParams{
 		Credentials: deribit.Credentials{
 			ClientId:     os.Getenv("DERIBIT_CLIENT_ID"),
 			ClientSecret: os.Getenv("DERIBIT_CLIENT_SECRET"),
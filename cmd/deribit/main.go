@@ -18,7 +18,7 @@ func main() {
 		panic(err)
 	}
 
-	options, err := api.GetOptionInstruments("BTC", false)
+	options, err := api.GetOptionInstruments(ctx, "BTC", false)
 	if err != nil {
 		panic(err)
 	}
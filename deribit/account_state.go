@@ -0,0 +1,200 @@
+package deribit
+
+import (
+	"sync"
+)
+
+// AccountState folds a user's portfolio, positions and open orders into a single view of
+// their account. Portfolios from [NewUserPortfolioStream] or
+// [TradingExecutor.GetAccountSummary] are applied with ApplyPortfolio, and changes from
+// [NewUserChangesStream] with ApplyChanges.
+//
+// Deribit's portfolio updates lag fills, so between updates the margin, equity and greeks
+// of a portfolio are adjusted by the difference between each updated position and its
+// previous state, and by the fees of new trades. The next portfolio update replaces the
+// adjusted values. An AccountState is safe for concurrent use.
+type AccountState struct {
+	mu         sync.RWMutex
+	portfolios map[string]DeribitUserPortfolioCurrency
+	positions  map[string]DeribitPosition
+	openOrders map[string]Order
+}
+
+// NewAccountState creates an empty AccountState.
+func NewAccountState() *AccountState {
+	return &AccountState{
+		portfolios: make(map[string]DeribitUserPortfolioCurrency),
+		positions:  make(map[string]DeribitPosition),
+		openOrders: make(map[string]Order),
+	}
+}
+
+// ApplyPortfolio replaces the portfolio of a currency.
+func (a *AccountState) ApplyPortfolio(p DeribitUserPortfolioCurrency) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.portfolios[p.Currency] = p
+}
+
+// ApplyPositions replaces the state of the given positions without adjusting the
+// portfolio, such as when the positions are initialised from
+// [TradingExecutor.GetPositions].
+func (a *AccountState) ApplyPositions(positions []DeribitPosition) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range positions {
+		a.setPosition(p)
+	}
+}
+
+// ApplyChanges applies the orders, trades and positions of a user changes event.
+func (a *AccountState) ApplyChanges(c UserChanges) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, o := range c.Orders {
+		if isOpenOrder(o) {
+			a.openOrders[o.OrderId] = o
+		} else {
+			delete(a.openOrders, o.OrderId)
+		}
+	}
+
+	for _, t := range c.Trades {
+		currency := settlementCurrency(t.InstrumentName)
+		if p, ok := a.portfolios[currency]; ok {
+			p.Balance -= t.Fee
+			p.Equity -= t.Fee
+			p.MarginBalance -= t.Fee
+			p.AvailableFunds = p.MarginBalance - p.InitialMargin
+			a.portfolios[currency] = p
+		}
+	}
+
+	for _, pos := range c.Positions {
+		prev := a.positions[pos.InstrumentName]
+		currency := settlementCurrency(pos.InstrumentName)
+		if p, ok := a.portfolios[currency]; ok {
+			a.portfolios[currency] = adjustPortfolio(p, prev, pos)
+		}
+		a.setPosition(pos)
+	}
+}
+
+// Portfolio returns the portfolio of a currency, and false if no portfolio has been
+// applied for the currency.
+func (a *AccountState) Portfolio(currency string) (DeribitUserPortfolioCurrency, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	p, ok := a.portfolios[currency]
+	return p, ok
+}
+
+// Position returns the position in an instrument, and false if there is no open position.
+func (a *AccountState) Position(instrument string) (DeribitPosition, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	p, ok := a.positions[instrument]
+	return p, ok
+}
+
+// Positions returns all open positions.
+func (a *AccountState) Positions() []DeribitPosition {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	positions := make([]DeribitPosition, 0, len(a.positions))
+	for _, p := range a.positions {
+		positions = append(positions, p)
+	}
+	return positions
+}
+
+// OpenOrders returns all open orders.
+func (a *AccountState) OpenOrders() []Order {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	orders := make([]Order, 0, len(a.openOrders))
+	for _, o := range a.openOrders {
+		orders = append(orders, o)
+	}
+	return orders
+}
+
+func (a *AccountState) setPosition(p DeribitPosition) {
+	if p.Size == 0 {
+		delete(a.positions, p.InstrumentName)
+	} else {
+		a.positions[p.InstrumentName] = p
+	}
+}
+
+// adjustPortfolio adjusts the margin, equity and greeks of a portfolio by the change from
+// the previous to the current state of a position. When a position is reduced, its
+// floating P&L is realised, which moves it into the balance without changing equity.
+func adjustPortfolio(p DeribitUserPortfolioCurrency, prev, cur DeribitPosition) DeribitUserPortfolioCurrency {
+	dUPL := cur.FloatingProfitLoss - prev.FloatingProfitLoss
+	dRPL := cur.RealizedProfitLoss - prev.RealizedProfitLoss
+	p.SessionUPL += dUPL
+	p.SessionRPL += dRPL
+	p.Balance += dRPL
+	p.Equity += dUPL + dRPL
+	p.MarginBalance += dUPL + dRPL
+	p.InitialMargin += cur.InitialMargin - prev.InitialMargin
+	p.MaintenanceMargin += cur.MaintenanceMargin - prev.MaintenanceMargin
+	p.AvailableFunds = p.MarginBalance - p.InitialMargin
+
+	index := indexName(cur.InstrumentName)
+	dDelta := cur.Delta - prev.Delta
+	p.DeltaTotal += dDelta
+	p.DeltaTotalMap = adjustFloatMap(p.DeltaTotalMap, index, dDelta)
+
+	if cur.Kind == OptionInstrument {
+		dGamma := cur.Gamma - prev.Gamma
+		dVega := cur.Vega - prev.Vega
+		dTheta := cur.Theta - prev.Theta
+		p.OptionsDelta += dDelta
+		p.OptionsGamma += dGamma
+		p.OptionsVega += dVega
+		p.OptionsTheta += dTheta
+		p.OptionsGammaMap = adjustFloatMap(p.OptionsGammaMap, index, dGamma)
+		p.OptionsVegaMap = adjustFloatMap(p.OptionsVegaMap, index, dVega)
+		p.OptionsThetaMap = adjustFloatMap(p.OptionsThetaMap, index, dTheta)
+	}
+	return p
+}
+
+// adjustFloatMap returns a copy of m with d added to the value of key. The map is copied
+// because portfolios returned by the AccountState share it.
+func adjustFloatMap(m map[string]float64, key string, d float64) map[string]float64 {
+	if d == 0 {
+		return m
+	}
+	adjusted := make(map[string]float64, len(m)+1)
+	for k, v := range m {
+		adjusted[k] = v
+	}
+	adjusted[key] += d
+	return adjusted
+}
+
+func isOpenOrder(o Order) bool {
+	return o.OrderState == "open" || o.OrderState == "untriggered"
+}
+
+// settlementCurrency returns the currency an instrument is settled in.
+func settlementCurrency(instrument string) string {
+	n, err := ParseInstrumentName(instrument)
+	if err != nil {
+		return ""
+	}
+	return n.SettlementCurrency()
+}
+
+// indexName returns the name of the index an instrument is priced on e.g. "btc_usd".
+func indexName(instrument string) string {
+	n, err := ParseInstrumentName(instrument)
+	if err != nil {
+		return ""
+	}
+	return n.IndexName()
+}
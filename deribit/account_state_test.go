@@ -0,0 +1,122 @@
+package deribit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestParseUserChanges(t *testing.T) {
+	v := fastjson.MustParse(`{
+		"instrument_name": "BTC-PERPETUAL",
+		"orders": [{"order_id": "1", "order_state": "filled", "instrument_name": "BTC-PERPETUAL", "amount": 10}],
+		"trades": [{"trade_id": "t1", "order_id": "1", "instrument_name": "BTC-PERPETUAL", "price": 50000, "amount": 10, "fee": 0.0001}],
+		"positions": [{"instrument_name": "BTC-PERPETUAL", "kind": "future", "size": 10, "delta": 0.0002}]
+	}`)
+	c := parseUserChanges(v)
+	assert.Equal(t, "BTC-PERPETUAL", c.Instrument)
+	assert.Len(t, c.Orders, 1)
+	assert.Equal(t, "filled", c.Orders[0].OrderState)
+	assert.Len(t, c.Trades, 1)
+	assert.Equal(t, 0.0001, c.Trades[0].Fee)
+	assert.Len(t, c.Positions, 1)
+	assert.Equal(t, 0.0002, c.Positions[0].Delta)
+
+	assert.Equal(t, "user.changes.BTC-PERPETUAL.raw", UserChangesSub{Instrument: "BTC-PERPETUAL"}.channel())
+	assert.Equal(t, "user.changes.option.BTC.raw", UserChangesSub{Kind: OptionInstrument, Currency: "BTC"}.channel())
+}
+
+func TestAccountState(t *testing.T) {
+	a := NewAccountState()
+	a.ApplyPortfolio(DeribitUserPortfolioCurrency{
+		Currency:        "BTC",
+		Balance:         1,
+		Equity:          1,
+		MarginBalance:   1,
+		InitialMargin:   0.1,
+		AvailableFunds:  0.9,
+		DeltaTotal:      0.5,
+		DeltaTotalMap:   map[string]float64{"btc_usd": 0.5},
+		OptionsGammaMap: map[string]float64{"btc_usd": 0.001},
+	})
+	before, _ := a.Portfolio("BTC")
+
+	// An order is opened.
+	a.ApplyChanges(UserChanges{
+		Instrument: "BTC-27DEC24-60000-C",
+		Orders:     []Order{{OrderId: "1", OrderState: "open", InstrumentName: "BTC-27DEC24-60000-C"}},
+	})
+	assert.Len(t, a.OpenOrders(), 1)
+
+	// The order is filled, opening a position.
+	a.ApplyChanges(UserChanges{
+		Instrument: "BTC-27DEC24-60000-C",
+		Orders:     []Order{{OrderId: "1", OrderState: "filled", InstrumentName: "BTC-27DEC24-60000-C"}},
+		Trades:     []TradeExecution{{TradeId: "t1", OrderId: "1", InstrumentName: "BTC-27DEC24-60000-C", Fee: 0.0003}},
+		Positions: []DeribitPosition{{
+			InstrumentName:     "BTC-27DEC24-60000-C",
+			Kind:               OptionInstrument,
+			Size:               1,
+			Delta:              0.4,
+			Gamma:              0.0002,
+			Vega:               30,
+			Theta:              -10,
+			InitialMargin:      0.02,
+			MaintenanceMargin:  0.01,
+			FloatingProfitLoss: -0.001,
+		}},
+	})
+	assert.Empty(t, a.OpenOrders())
+	pos, ok := a.Position("BTC-27DEC24-60000-C")
+	assert.True(t, ok)
+	assert.Equal(t, 0.4, pos.Delta)
+
+	p, ok := a.Portfolio("BTC")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.9997, p.Balance, 1e-12)
+	assert.InDelta(t, 0.9987, p.Equity, 1e-12)
+	assert.InDelta(t, 0.12, p.InitialMargin, 1e-12)
+	assert.InDelta(t, 0.01, p.MaintenanceMargin, 1e-12)
+	assert.InDelta(t, 0.9987-0.12, p.AvailableFunds, 1e-12)
+	assert.InDelta(t, 0.9, p.DeltaTotal, 1e-12)
+	assert.InDelta(t, 0.9, p.DeltaTotalMap["btc_usd"], 1e-12)
+	assert.InDelta(t, 0.0012, p.OptionsGammaMap["btc_usd"], 1e-12)
+	assert.InDelta(t, 30, p.OptionsVegaMap["btc_usd"], 1e-12)
+	assert.InDelta(t, -10, p.OptionsTheta, 1e-12)
+
+	// Portfolios returned earlier are unchanged.
+	assert.Equal(t, 0.5, before.DeltaTotalMap["btc_usd"])
+
+	// The position is closed, and its floating P&L is realised.
+	a.ApplyChanges(UserChanges{
+		Instrument: "BTC-27DEC24-60000-C",
+		Positions: []DeribitPosition{{
+			InstrumentName:     "BTC-27DEC24-60000-C",
+			Kind:               OptionInstrument,
+			RealizedProfitLoss: -0.001,
+		}},
+	})
+	_, ok = a.Position("BTC-27DEC24-60000-C")
+	assert.False(t, ok)
+	p, _ = a.Portfolio("BTC")
+	assert.InDelta(t, 0.5, p.DeltaTotal, 1e-12)
+	assert.InDelta(t, 0.1, p.InitialMargin, 1e-12)
+	assert.InDelta(t, 0.9987, p.Equity, 1e-12)
+	assert.InDelta(t, 0.9987, p.MarginBalance, 1e-12)
+	assert.InDelta(t, 0.9987, p.Balance, 1e-12)
+	assert.InDelta(t, 0, p.SessionUPL, 1e-12)
+	assert.InDelta(t, -0.001, p.SessionRPL, 1e-12)
+
+	// A portfolio update replaces the adjusted values.
+	a.ApplyPortfolio(DeribitUserPortfolioCurrency{Currency: "BTC", Equity: 2})
+	p, _ = a.Portfolio("BTC")
+	assert.Equal(t, float64(2), p.Equity)
+}
+
+func TestSettlementCurrency(t *testing.T) {
+	assert.Equal(t, "BTC", settlementCurrency("BTC-PERPETUAL"))
+	assert.Equal(t, "USDC", settlementCurrency("BTC_USDC-PERPETUAL"))
+	assert.Equal(t, "btc_usd", indexName("BTC-27DEC24-60000-C"))
+	assert.Equal(t, "sol_usdc", indexName("SOL_USDC-PERPETUAL"))
+}
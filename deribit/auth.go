@@ -0,0 +1,218 @@
+package deribit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/valyala/fastjson"
+)
+
+// AuthOptions configure how a websocket connection authenticates with Deribit. For
+// details see: https://docs.deribit.com/#public-auth
+type AuthOptions struct {
+	// Scope requests an access token with restricted access, for example "trade:read".
+	// Including "session:name" in the scope requests a session token, which remains
+	// valid after the connection closes. By default, Deribit issues a connection scoped
+	// token with the full access of the API key.
+	Scope string
+	// Signature authenticates with a client_signature grant instead of client
+	// credentials, so that the client secret is never sent to Deribit.
+	Signature bool
+}
+
+// authToken is the result of a public/auth request.
+type authToken struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int64
+	Scope     string
+}
+
+// The access token is refreshed once this fraction of its lifetime has passed.
+const tokenRefreshFraction = 0.9
+
+// authSession authenticates a websocket connection and keeps its access token fresh by
+// scheduling refresh_token grants before the token expires. Streams, the TradingExecutor
+// and WsApi each hold a session for their connection. It's safe for concurrent use.
+type authSession struct {
+	clientId     string
+	clientSecret string
+	opts         AuthOptions
+
+	m sync.Mutex
+	// pendingIds are the ids of auth requests awaiting a response.
+	pendingIds map[int64]struct{}
+	token      authToken
+	timer      *time.Timer
+	refreshc   chan struct{}
+	now        func() time.Time
+}
+
+func newAuthSession(clientId, clientSecret string, opts AuthOptions) *authSession {
+	return &authSession{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		opts:         opts,
+		pendingIds:   make(map[int64]struct{}),
+		refreshc:     make(chan struct{}, 1),
+		now:          time.Now,
+	}
+}
+
+// authRequest returns a new public/auth request for a connection. Any previous token is
+// discarded, since it's tied to the previous connection.
+func (a *authSession) authRequest() (id int64, msg []byte, err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.stopTimer()
+	a.token = authToken{}
+
+	params, err := a.credentialParams()
+	if err != nil {
+		return 0, nil, err
+	}
+	return a.request(params)
+}
+
+// refreshRequest returns a new public/auth request which refreshes the current token.
+func (a *authSession) refreshRequest() (id int64, msg []byte, err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.token.RefreshToken == "" {
+		params, err := a.credentialParams()
+		if err != nil {
+			return 0, nil, err
+		}
+		return a.request(params)
+	}
+	params := map[string]interface{}{
+		"grant_type":    "refresh_token",
+		"refresh_token": a.token.RefreshToken,
+	}
+	return a.request(params)
+}
+
+// request creates an auth request message with the given params. The lock must be held.
+func (a *authSession) request(params map[string]interface{}) (int64, []byte, error) {
+	id := genId()
+	msg, err := rpcRequestMsg(methodPublicAuth, id, params)
+	if err != nil {
+		return 0, nil, err
+	}
+	a.pendingIds[id] = struct{}{}
+	return id, msg, nil
+}
+
+// credentialParams returns the params for a client_credentials or client_signature
+// grant. The lock must be held.
+func (a *authSession) credentialParams() (map[string]interface{}, error) {
+	params := map[string]interface{}{"client_id": a.clientId}
+	if a.opts.Scope != "" {
+		params["scope"] = a.opts.Scope
+	}
+	if !a.opts.Signature {
+		params["grant_type"] = "client_credentials"
+		params["client_secret"] = a.clientSecret
+		return params, nil
+	}
+
+	nonce, err := genNonce()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := a.now().UnixMilli()
+	params["grant_type"] = "client_signature"
+	params["timestamp"] = timestamp
+	params["nonce"] = nonce
+	params["data"] = ""
+	params["signature"] = clientSignature(a.clientSecret, timestamp, nonce, "")
+	return params, nil
+}
+
+// clientSignature returns the signature of a client_signature grant. For details see:
+// https://docs.deribit.com/#authentication
+func clientSignature(secret string, timestamp int64, nonce string, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + nonce + "\n" + data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func genNonce() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isResponse returns true if a message with the given id is the response to an auth
+// request.
+func (a *authSession) isResponse(id int64) bool {
+	a.m.Lock()
+	defer a.m.Unlock()
+	_, ok := a.pendingIds[id]
+	return ok
+}
+
+// handleResponse handles the response to an auth request. On success, a refresh of the
+// token is scheduled. An error is returned if the authentication failed.
+func (a *authSession) handleResponse(v *fastjson.Value) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+	delete(a.pendingIds, v.GetInt64("id"))
+
+	if rpcErr := isRpcError(v); rpcErr != nil {
+		return fmt.Errorf("auth failure: %w", rpcErr)
+	}
+	result := v.Get("result")
+	if result == nil {
+		return errors.New(`auth failure: field "result" is missing`)
+	}
+	a.token = authToken{
+		AccessToken:  string(result.GetStringBytes("access_token")),
+		RefreshToken: string(result.GetStringBytes("refresh_token")),
+		ExpiresIn:    result.GetInt64("expires_in"),
+		Scope:        string(result.GetStringBytes("scope")),
+	}
+
+	a.stopTimer()
+	if a.token.ExpiresIn > 0 {
+		refreshIn := time.Duration(float64(a.token.ExpiresIn) * tokenRefreshFraction * float64(time.Second))
+		a.timer = time.AfterFunc(refreshIn, func() {
+			select {
+			case a.refreshc <- struct{}{}:
+			default:
+			}
+		})
+	}
+	return nil
+}
+
+// refreshRequests returns a channel which is signalled when the access token should be
+// refreshed with a request from refreshRequest.
+func (a *authSession) refreshRequests() <-chan struct{} {
+	return a.refreshc
+}
+
+// stop cancels any scheduled refresh.
+func (a *authSession) stop() {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.stopTimer()
+}
+
+// stopTimer stops the refresh timer. The lock must be held.
+func (a *authSession) stopTimer() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+}
@@ -0,0 +1,115 @@
+package deribit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func decodeAuthParams(t *testing.T, msg []byte) map[string]interface{} {
+	var req struct {
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+	}
+	assert.Nil(t, json.Unmarshal(msg, &req))
+	assert.Equal(t, "public/auth", req.Method)
+	return req.Params
+}
+
+func TestAuthSessionRequests(t *testing.T) {
+	a := newAuthSession("id", "secret", AuthOptions{Scope: "session:test"})
+	id, msg, err := a.authRequest()
+	assert.Nil(t, err)
+	assert.True(t, a.isResponse(id))
+	assert.Equal(t, map[string]interface{}{
+		"grant_type":    "client_credentials",
+		"client_id":     "id",
+		"client_secret": "secret",
+		"scope":         "session:test",
+	}, decodeAuthParams(t, msg))
+
+	// The secret isn't sent with a client signature.
+	a = newAuthSession("id", "secret", AuthOptions{Signature: true})
+	a.now = func() time.Time { return time.UnixMilli(1576074319000) }
+	_, msg, err = a.authRequest()
+	assert.Nil(t, err)
+	params := decodeAuthParams(t, msg)
+	assert.NotContains(t, params, "client_secret")
+	assert.Equal(t, "client_signature", params["grant_type"])
+	assert.Equal(t, float64(1576074319000), params["timestamp"])
+	nonce := params["nonce"].(string)
+	assert.Len(t, nonce, 16)
+	assert.Equal(t, clientSignature("secret", 1576074319000, nonce, ""), params["signature"])
+}
+
+func TestClientSignature(t *testing.T) {
+	// Example from https://docs.deribit.com/#authentication
+	sig := clientSignature("AMANDASECRECT", 1576074319000, "1iqt2wls", "")
+	assert.Equal(t, "56590594f97921b09b18f166befe0d1319b198bbcdad7ca73382de2f88fe9aa1", sig)
+}
+
+func TestAuthSessionResponse(t *testing.T) {
+	a := newAuthSession("id", "secret", AuthOptions{})
+	id, _, err := a.authRequest()
+	assert.Nil(t, err)
+
+	resp := `{"jsonrpc":"2.0","id":` + jsonInt(id) + `,"result":{"access_token":"abc","expires_in":1,"refresh_token":"xyz","scope":"connection mainaccount","token_type":"bearer"}}`
+	assert.Nil(t, a.handleResponse(fastjson.MustParse(resp)))
+	assert.False(t, a.isResponse(id))
+	assert.Equal(t, authToken{AccessToken: "abc", RefreshToken: "xyz", ExpiresIn: 1, Scope: "connection mainaccount"}, a.token)
+
+	// A refresh is requested before the token expires.
+	select {
+	case <-a.refreshRequests():
+	case <-time.After(time.Second):
+		t.Fatal("expected a token refresh")
+	}
+	id, msg, err := a.refreshRequest()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"grant_type":    "refresh_token",
+		"refresh_token": "xyz",
+	}, decodeAuthParams(t, msg))
+
+	resp = `{"jsonrpc":"2.0","id":` + jsonInt(id) + `,"error":{"code":13004,"message":"invalid_credentials"}}`
+	err = a.handleResponse(fastjson.MustParse(resp))
+	assert.Equal(t, "auth failure: Deribit RPC error [13004]: invalid_credentials", err.Error())
+	a.stop()
+}
+
+func jsonInt(v int64) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestWsApiAuthRefresh(t *testing.T) {
+	var grants, refreshes atomic.Int32
+	server := newTestWsServer(t, func(method string, params map[string]interface{}) interface{} {
+		if method != "public/auth" {
+			return nil
+		}
+		if params["grant_type"] == "refresh_token" {
+			assert.Equal(t, "refresh", params["refresh_token"])
+			refreshes.Add(1)
+		} else {
+			grants.Add(1)
+		}
+		return map[string]interface{}{"access_token": "access", "refresh_token": "refresh", "expires_in": 1}
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := NewWsApi("ws"+strings.TrimPrefix(server.URL, "http"), tk.WithCredentials(tk.Credentials{ClientId: "id", ClientSecret: "secret"}))
+	assert.Nil(t, api.Start(ctx))
+
+	assert.Eventually(t, func() bool { return refreshes.Load() >= 1 }, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), grants.Load())
+}
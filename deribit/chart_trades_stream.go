@@ -0,0 +1,60 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitChartTradesSub represents a subscription to a Deribit candle stream created using [NewChartTradesStream].
+// The resolution is a number of minutes ("1", "3", "5", "10", "15", "30", "60", "120",
+// "180", "360", "720") or "1D". For details see:
+// https://docs.deribit.com/#chart-trades-instrument_name-resolution
+type DeribitChartTradesSub struct {
+	Instrument string
+	Resolution string
+}
+
+// DeribitCandle is an OHLCV candle. Volume is in the base currency, and Cost is in the
+// quote currency.
+type DeribitCandle struct {
+	Tick   int64   `json:"tick" parquet:"name=tick, type=INT64"`
+	Open   float64 `json:"open" parquet:"name=open, type=DOUBLE"`
+	High   float64 `json:"high" parquet:"name=high, type=DOUBLE"`
+	Low    float64 `json:"low" parquet:"name=low, type=DOUBLE"`
+	Close  float64 `json:"close" parquet:"name=close, type=DOUBLE"`
+	Volume float64 `json:"volume" parquet:"name=volume, type=DOUBLE"`
+	Cost   float64 `json:"cost" parquet:"name=cost, type=DOUBLE"`
+}
+
+func (sub DeribitChartTradesSub) channel() string {
+	return fmt.Sprintf("chart.trades.%s.%s", sub.Instrument, sub.Resolution)
+}
+
+// NewChartTradesStream creates a new [Stream] which produces updates to the current
+// candle of an instrument. For details see:
+// https://docs.deribit.com/#chart-trades-instrument_name-resolution
+func NewChartTradesStream(wsUrl string, subscriptions []DeribitChartTradesSub, paramFuncs ...tk.Param) Stream[DeribitCandle, DeribitChartTradesSub] {
+	p := streamParams[DeribitCandle, DeribitChartTradesSub]{
+		name:         "ChartTradesStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: ParseDeribitCandle,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitCandle(v *fastjson.Value) DeribitCandle {
+	return DeribitCandle{
+		Tick:   v.GetInt64("tick"),
+		Open:   v.GetFloat64("open"),
+		High:   v.GetFloat64("high"),
+		Low:    v.GetFloat64("low"),
+		Close:  v.GetFloat64("close"),
+		Volume: v.GetFloat64("volume"),
+		Cost:   v.GetFloat64("cost"),
+	}
+}
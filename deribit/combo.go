@@ -0,0 +1,82 @@
+package deribit
+
+import (
+	"context"
+
+	"github.com/valyala/fastjson"
+)
+
+// ComboLeg is a leg of a combo instrument. A negative amount is a short leg.
+type ComboLeg struct {
+	InstrumentName string  `json:"instrument_name"`
+	Amount         float64 `json:"amount"`
+}
+
+// Combo is a combo instrument made of multiple legs, such as a futures spread or an
+// option strategy. Combo orders are placed with [TradingExecutor.Buy] and
+// [TradingExecutor.Sell] on the combo's instrument name, which is its Id.
+type Combo struct {
+	// Id is the combo's instrument name e.g. BTC-FS-29DEC23_PERP.
+	Id                string     `json:"id"`
+	InstrumentId      int64      `json:"instrument_id"`
+	State             string     `json:"state"`
+	StateTimestamp    int64      `json:"state_timestamp"`
+	CreationTimestamp int64      `json:"creation_timestamp"`
+	Legs              []ComboLeg `json:"legs"`
+}
+
+// ComboTrade is a leg of a combo created with [TradingExecutor.CreateCombo]. The
+// direction is either [Buy] or [Sell].
+type ComboTrade struct {
+	InstrumentName string
+	Amount         float64
+	Direction      string
+}
+
+func comboTradesParams(trades []ComboTrade) map[string]interface{} {
+	items := make([]map[string]interface{}, len(trades))
+	for i, t := range trades {
+		items[i] = map[string]interface{}{
+			"instrument_name": t.InstrumentName,
+			"amount":          t.Amount,
+			"direction":       t.Direction,
+		}
+	}
+	return map[string]interface{}{"trades": items}
+}
+
+func parseCombo(v *fastjson.Value) Combo {
+	legItems := v.GetArray("legs")
+	legs := make([]ComboLeg, len(legItems))
+	for i, item := range legItems {
+		legs[i] = ComboLeg{
+			InstrumentName: string(item.GetStringBytes("instrument_name")),
+			Amount:         item.GetFloat64("amount"),
+		}
+	}
+	return Combo{
+		Id:                string(v.GetStringBytes("id")),
+		InstrumentId:      v.GetInt64("instrument_id"),
+		State:             string(v.GetStringBytes("state")),
+		StateTimestamp:    v.GetInt64("state_timestamp"),
+		CreationTimestamp: v.GetInt64("creation_timestamp"),
+		Legs:              legs,
+	}
+}
+
+// GetCombos returns the active combos of a currency. Use "any" to get the combos of all
+// currencies. For details see: https://docs.deribit.com/#public-get_combos
+func (api *Api) GetCombos(ctx context.Context, currency string) ([]Combo, error) {
+	return getCombos(ctx, api, currency)
+}
+
+// GetCombos returns the active combos of a currency. Use "any" to get the combos of all
+// currencies. For details see: https://docs.deribit.com/#public-get_combos
+func (api *WsApi) GetCombos(ctx context.Context, currency string) ([]Combo, error) {
+	return getCombos(ctx, api, currency)
+}
+
+func getCombos(ctx context.Context, c rpcCaller, currency string) ([]Combo, error) {
+	params := map[string]interface{}{"currency": currency}
+	return call[[]Combo](ctx, c, methodPublicGetCombos, params)
+}
@@ -0,0 +1,53 @@
+package deribit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestComboTradesParams(t *testing.T) {
+	params := comboTradesParams([]ComboTrade{
+		{InstrumentName: "BTC-29DEC23", Amount: 1, Direction: Sell},
+		{InstrumentName: "BTC-PERPETUAL", Amount: 1, Direction: Buy},
+	})
+	expected := map[string]interface{}{
+		"trades": []map[string]interface{}{
+			{"instrument_name": "BTC-29DEC23", "amount": float64(1), "direction": "sell"},
+			{"instrument_name": "BTC-PERPETUAL", "amount": float64(1), "direction": "buy"},
+		},
+	}
+	assert.Equal(t, expected, params)
+}
+
+func TestParseCombo(t *testing.T) {
+	input := `{
+		"state_timestamp": 1650960943922,
+		"state": "active",
+		"legs": [
+			{"instrument_name": "BTC-29DEC23", "amount": -1},
+			{"instrument_name": "BTC-PERPETUAL", "amount": 1}
+		],
+		"instrument_id": 27,
+		"id": "BTC-FS-29DEC23_PERP",
+		"creation_timestamp": 1650960943000
+	}`
+	expected := Combo{
+		Id:                "BTC-FS-29DEC23_PERP",
+		InstrumentId:      27,
+		State:             "active",
+		StateTimestamp:    1650960943922,
+		CreationTimestamp: 1650960943000,
+		Legs: []ComboLeg{
+			{InstrumentName: "BTC-29DEC23", Amount: -1},
+			{InstrumentName: "BTC-PERPETUAL", Amount: 1},
+		},
+	}
+	assert.Equal(t, expected, parseCombo(fastjson.MustParse(input)))
+
+	// The Api decodes combos with encoding/json.
+	var combo Combo
+	assert.Nil(t, decodeRpcResponse([]byte(`{"result":`+input+`}`), &combo))
+	assert.Equal(t, expected, combo)
+}
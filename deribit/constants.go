@@ -11,6 +11,14 @@ const (
 	OptionComboInstrument InstrumentKind = "option_combo"
 )
 
+// OptionType specifies whether an option is a call or a put.
+type OptionType string
+
+const (
+	CallOption OptionType = "call"
+	PutOption  OptionType = "put"
+)
+
 // UpdateFrequency specifies the update frequency for a data stream.
 type UpdateFrequency string
 
@@ -48,3 +56,30 @@ const (
 	Buy  string = "buy"
 	Sell string = "sell"
 )
+
+// LinkedOrderType links a primary order with the secondary orders of its OtocoConfig.
+type LinkedOrderType string
+
+const (
+	// OneTriggersOther places the secondary orders when the primary order fills.
+	OneTriggersOther LinkedOrderType = "one_triggers_other"
+	// OneCancelsOther cancels the other order when either order fills.
+	OneCancelsOther LinkedOrderType = "one_cancels_other"
+	// OneTriggersOneCancelsOther places a pair of one-cancels-other orders when the
+	// primary order fills.
+	OneTriggersOneCancelsOther LinkedOrderType = "one_triggers_one_cancels_other"
+)
+
+// TriggerFillCondition specifies when the secondary orders of a linked order are placed.
+type TriggerFillCondition string
+
+const (
+	// FirstHit places the secondary orders in full on the primary order's first fill.
+	FirstHit TriggerFillCondition = "first_hit"
+	// CompleteFill places the secondary orders once the primary order is completely
+	// filled.
+	CompleteFill TriggerFillCondition = "complete_fill"
+	// IncrementalFill places the secondary orders in proportion to each fill of the
+	// primary order.
+	IncrementalFill TriggerFillCondition = "incremental"
+)
@@ -0,0 +1,59 @@
+package deribit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitEstimatedExpirationPriceSub represents a subscription to a Deribit estimated expiration price stream created using [NewEstimatedExpirationPriceStream].
+// For details see: https://docs.deribit.com/#estimated_expiration_price-index_name
+type DeribitEstimatedExpirationPriceSub struct {
+	IndexName string
+}
+
+// DeribitEstimatedExpirationPrice is the estimated expiration price of an index's
+// futures and options, updated until the next expiry.
+type DeribitEstimatedExpirationPrice struct {
+	// IndexName is the index of the subscription. It's not part of the message data.
+	IndexName string `json:"index_name" parquet:"name=index_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// Seconds is the number of seconds until the next expiry.
+	Seconds     int64   `json:"seconds" parquet:"name=seconds, type=INT64"`
+	Price       float64 `json:"price" parquet:"name=price, type=DOUBLE"`
+	IsEstimated bool    `json:"is_estimated" parquet:"name=is_estimated, type=BOOLEAN"`
+}
+
+const estimatedExpirationPricePrefix = "estimated_expiration_price."
+
+func (sub DeribitEstimatedExpirationPriceSub) channel() string {
+	return fmt.Sprintf("%s%s", estimatedExpirationPricePrefix, sub.IndexName)
+}
+
+// NewEstimatedExpirationPriceStream creates a new [Stream] which produces a stream of
+// estimated expiration prices. For details see:
+// https://docs.deribit.com/#estimated_expiration_price-index_name
+func NewEstimatedExpirationPriceStream(wsUrl string, subscriptions []DeribitEstimatedExpirationPriceSub, paramFuncs ...tk.Param) Stream[DeribitEstimatedExpirationPrice, DeribitEstimatedExpirationPriceSub] {
+	p := streamParams[DeribitEstimatedExpirationPrice, DeribitEstimatedExpirationPriceSub]{
+		name:      "EstimatedExpirationPriceStream",
+		wsUrl:     wsUrl,
+		isPrivate: false,
+		parseChannelMessage: func(channel string, v *fastjson.Value) DeribitEstimatedExpirationPrice {
+			p := ParseDeribitEstimatedExpirationPrice(v)
+			p.IndexName = strings.TrimPrefix(channel, estimatedExpirationPricePrefix)
+			return p
+		},
+		subs:   subscriptions,
+		Params: tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitEstimatedExpirationPrice(v *fastjson.Value) DeribitEstimatedExpirationPrice {
+	return DeribitEstimatedExpirationPrice{
+		Seconds:     v.GetInt64("seconds"),
+		Price:       v.GetFloat64("price"),
+		IsEstimated: v.GetBool("is_estimated"),
+	}
+}
@@ -0,0 +1,265 @@
+package deribit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/valyala/fastjson"
+)
+
+// GetOpenOrdersOptions filter the orders returned by [TradingExecutor.GetOpenOrders]. If
+// Instrument is set, only its orders are returned. Otherwise, if Label is set, the orders
+// with the label on the currency's instruments are returned, and Currency is required.
+// Otherwise, if Currency is set, orders on the currency's instruments of the given kind
+// are returned. If the options are nil, all open orders are returned. Type is one of the
+// open order types, such as "limit" or "trigger_all". For details see:
+//   - https://docs.deribit.com/#private-get_open_orders
+//   - https://docs.deribit.com/#private-get_open_orders_by_currency
+//   - https://docs.deribit.com/#private-get_open_orders_by_instrument
+//   - https://docs.deribit.com/#private-get_open_orders_by_label
+type GetOpenOrdersOptions struct {
+	Currency   string
+	Kind       InstrumentKind
+	Instrument string
+	Label      string
+	Type       string
+}
+
+// GetOrderHistoryOptions specify the orders returned by [TradingExecutor.GetOrderHistory].
+// Either Instrument or Currency is required. For details see:
+//   - https://docs.deribit.com/#private-get_order_history_by_currency
+//   - https://docs.deribit.com/#private-get_order_history_by_instrument
+type GetOrderHistoryOptions struct {
+	Currency   string
+	Kind       InstrumentKind
+	Instrument string
+	// Count is the number of orders to return. Deribit's default is 20.
+	Count           int
+	Offset          int
+	IncludeOld      bool
+	IncludeUnfilled bool
+}
+
+// GetUserTradesOptions specify the trades returned by [TradingExecutor.GetUserTrades].
+// Either OrderId, Instrument or Currency is required. The trades of an order are returned
+// by a single call to the iterator's Next. For details see:
+//   - https://docs.deribit.com/#private-get_user_trades_by_currency
+//   - https://docs.deribit.com/#private-get_user_trades_by_instrument
+//   - https://docs.deribit.com/#private-get_user_trades_by_order
+type GetUserTradesOptions struct {
+	Currency   string
+	Kind       InstrumentKind
+	Instrument string
+	OrderId    string
+	// Count is the number of trades returned by each call to the iterator's Next.
+	// Defaults to 10.
+	Count int
+	// Ascending iterates from the oldest trade. By default, trades are returned from the
+	// most recent.
+	Ascending  bool
+	IncludeOld bool
+
+	startTradeId  string
+	endTradeId    string
+	startSequence int64
+	endSequence   int64
+}
+
+// Margins are the margins required to buy or sell an amount of an instrument, returned
+// by [TradingExecutor.GetMargins].
+type Margins struct {
+	Buy      float64
+	Sell     float64
+	MaxPrice float64
+	MinPrice float64
+}
+
+func (o *GetOpenOrdersOptions) methodAndParams() (rpcMethod, map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if o == nil {
+		return methodPrivateGetOpenOrders, params, nil
+	}
+	var method rpcMethod
+	if o.Instrument != "" {
+		method = methodPrivateGetOpenOrdersByInstrument
+		params["instrument_name"] = o.Instrument
+	} else if o.Label != "" {
+		if o.Currency == "" {
+			return "", nil, errors.New("GetOpenOrdersOptions requires a currency with a label")
+		}
+		// Orders by label can't be filtered by type.
+		params["currency"] = o.Currency
+		params["label"] = o.Label
+		return methodPrivateGetOpenOrdersByLabel, params, nil
+	} else if o.Currency != "" {
+		method = methodPrivateGetOpenOrdersByCurrency
+		params["currency"] = o.Currency
+		if o.Kind != "" {
+			params["kind"] = string(o.Kind)
+		}
+	} else {
+		method = methodPrivateGetOpenOrders
+		if o.Kind != "" {
+			params["kind"] = string(o.Kind)
+		}
+	}
+	if o.Type != "" {
+		params["type"] = o.Type
+	}
+	return method, params, nil
+}
+
+func (o GetOrderHistoryOptions) methodAndParams() (rpcMethod, map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	var method rpcMethod
+	if o.Instrument != "" {
+		method = methodPrivateGetOrderHistoryByInstrument
+		params["instrument_name"] = o.Instrument
+	} else if o.Currency != "" {
+		method = methodPrivateGetOrderHistoryByCurrency
+		params["currency"] = o.Currency
+		if o.Kind != "" {
+			params["kind"] = string(o.Kind)
+		}
+	} else {
+		return "", nil, errors.New("GetOrderHistoryOptions requires an instrument or currency")
+	}
+	if o.Count != 0 {
+		params["count"] = o.Count
+	}
+	if o.Offset != 0 {
+		params["offset"] = o.Offset
+	}
+	if o.IncludeOld {
+		params["include_old"] = o.IncludeOld
+	}
+	if o.IncludeUnfilled {
+		params["include_unfilled"] = o.IncludeUnfilled
+	}
+	return method, params, nil
+}
+
+func (o GetUserTradesOptions) methodAndParams() (rpcMethod, map[string]interface{}, error) {
+	params := map[string]interface{}{"count": o.Count}
+	var method rpcMethod
+	if o.OrderId != "" {
+		method = methodPrivateGetUserTradesByOrder
+		params = map[string]interface{}{"order_id": o.OrderId}
+		if o.IncludeOld {
+			params["historical"] = o.IncludeOld
+		}
+	} else if o.Instrument != "" {
+		method = methodPrivateGetUserTradesByInstrument
+		params["instrument_name"] = o.Instrument
+		if o.startSequence != 0 {
+			params["start_seq"] = o.startSequence
+		}
+		if o.endSequence != 0 {
+			params["end_seq"] = o.endSequence
+		}
+	} else if o.Currency != "" {
+		method = methodPrivateGetUserTradesByCurrency
+		params["currency"] = o.Currency
+		if o.Kind != "" {
+			params["kind"] = string(o.Kind)
+		}
+		if o.startTradeId != "" {
+			params["start_id"] = o.startTradeId
+		}
+		if o.endTradeId != "" {
+			params["end_id"] = o.endTradeId
+		}
+	} else {
+		return "", nil, errors.New("GetUserTradesOptions requires an order id, instrument or currency")
+	}
+	if o.Ascending {
+		params["sorting"] = "asc"
+	} else {
+		params["sorting"] = "desc"
+	}
+	if o.IncludeOld && method != methodPrivateGetUserTradesByOrder {
+		params["include_old"] = o.IncludeOld
+	}
+	return method, params, nil
+}
+
+type getUserTradesResponse struct {
+	Trades  []TradeExecution `json:"trades"`
+	HasMore bool             `json:"has_more"`
+}
+
+type userTradesIterator struct {
+	done      bool
+	params    GetUserTradesOptions
+	c         rpcCaller
+	doneFirst bool
+}
+
+func (it *userTradesIterator) Next(ctx context.Context) ([]TradeExecution, error) {
+	method, params, err := it.params.methodAndParams()
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	if method == methodPrivateGetUserTradesByOrder {
+		// The trades of an order aren't paginated.
+		it.done = true
+		return call[[]TradeExecution](ctx, it.c, method, params)
+	}
+	resp, err := call[getUserTradesResponse](ctx, it.c, method, params)
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+
+	trades := resp.Trades
+	if it.doneFirst && len(trades) > 0 {
+		// Subsequent requests start from the last trade of the previous request, so it's
+		// skipped to avoid a duplicate.
+		trades = trades[1:]
+	}
+
+	if len(trades) == 0 {
+		it.done = true
+		return trades, nil
+	}
+
+	if resp.HasMore {
+		t := trades[len(trades)-1]
+		if it.params.Ascending {
+			it.params.startTradeId = t.TradeId
+			it.params.startSequence = t.TradeSeq
+		} else {
+			it.params.endTradeId = t.TradeId
+			it.params.endSequence = t.TradeSeq
+		}
+	} else {
+		it.done = true
+	}
+
+	it.doneFirst = true
+
+	return trades, nil
+}
+
+func (it *userTradesIterator) Done() bool {
+	return it.done
+}
+
+func parseOrders(v *fastjson.Value) []Order {
+	items := v.GetArray()
+	orders := make([]Order, len(items))
+	for i, item := range items {
+		orders[i] = parseOrder(item)
+	}
+	return orders
+}
+
+func parseMargins(v *fastjson.Value) Margins {
+	return Margins{
+		Buy:      v.GetFloat64("buy"),
+		Sell:     v.GetFloat64("sell"),
+		MaxPrice: v.GetFloat64("max_price"),
+		MinPrice: v.GetFloat64("min_price"),
+	}
+}
@@ -0,0 +1,164 @@
+package deribit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOpenOrdersOptions(t *testing.T) {
+	var opts *GetOpenOrdersOptions
+	method, params, err := opts.methodAndParams()
+	assert.Nil(t, err)
+	assert.Equal(t, methodPrivateGetOpenOrders, method)
+	assert.Equal(t, map[string]interface{}{}, params)
+
+	opts = &GetOpenOrdersOptions{Currency: "BTC", Kind: OptionInstrument, Type: "limit"}
+	method, params, err = opts.methodAndParams()
+	assert.Nil(t, err)
+	assert.Equal(t, methodPrivateGetOpenOrdersByCurrency, method)
+	assert.Equal(t, map[string]interface{}{"currency": "BTC", "kind": "option", "type": "limit"}, params)
+
+	opts = &GetOpenOrdersOptions{Currency: "BTC", Instrument: "BTC-PERPETUAL"}
+	method, params, err = opts.methodAndParams()
+	assert.Nil(t, err)
+	assert.Equal(t, methodPrivateGetOpenOrdersByInstrument, method)
+	assert.Equal(t, map[string]interface{}{"instrument_name": "BTC-PERPETUAL"}, params)
+
+	opts = &GetOpenOrdersOptions{Currency: "BTC", Label: "hedge", Type: "limit"}
+	method, params, err = opts.methodAndParams()
+	assert.Nil(t, err)
+	assert.Equal(t, methodPrivateGetOpenOrdersByLabel, method)
+	assert.Equal(t, map[string]interface{}{"currency": "BTC", "label": "hedge"}, params)
+
+	opts = &GetOpenOrdersOptions{Label: "hedge"}
+	_, _, err = opts.methodAndParams()
+	assert.NotNil(t, err)
+}
+
+func TestGetOrderHistoryOptions(t *testing.T) {
+	opts := GetOrderHistoryOptions{Currency: "ETH", Kind: FutureInstrument, Count: 50, Offset: 100, IncludeUnfilled: true}
+	method, params, err := opts.methodAndParams()
+	assert.Nil(t, err)
+	assert.Equal(t, methodPrivateGetOrderHistoryByCurrency, method)
+	expected := map[string]interface{}{
+		"currency":         "ETH",
+		"kind":             "future",
+		"count":            50,
+		"offset":           100,
+		"include_unfilled": true,
+	}
+	assert.Equal(t, expected, params)
+
+	_, _, err = GetOrderHistoryOptions{}.methodAndParams()
+	assert.NotNil(t, err)
+}
+
+// fakeRpcCaller returns the results in order, and records the params of each call.
+type fakeRpcCaller struct {
+	results []string
+	params  []map[string]interface{}
+}
+
+func (c *fakeRpcCaller) rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error {
+	c.params = append(c.params, params)
+	res := c.results[0]
+	c.results = c.results[1:]
+	return json.Unmarshal([]byte(res), result)
+}
+
+func TestUserTradesIterator(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`{"trades": [{"trade_id": "BTC-3", "trade_seq": 3}, {"trade_id": "BTC-2", "trade_seq": 2}], "has_more": true}`,
+		`{"trades": [{"trade_id": "BTC-2", "trade_seq": 2}, {"trade_id": "BTC-1", "trade_seq": 1}], "has_more": false}`,
+	}}
+	it := &userTradesIterator{c: c, params: GetUserTradesOptions{Currency: "BTC", Count: 2}}
+
+	trades, err := it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, trades, 2)
+	assert.False(t, it.Done())
+
+	// The last trade of the previous page is skipped.
+	trades, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, trades, 1)
+	assert.Equal(t, "BTC-1", trades[0].TradeId)
+	assert.True(t, it.Done())
+
+	assert.Equal(t, map[string]interface{}{"currency": "BTC", "count": 2, "sorting": "desc"}, c.params[0])
+	assert.Equal(t, map[string]interface{}{"currency": "BTC", "count": 2, "sorting": "desc", "end_id": "BTC-2"}, c.params[1])
+
+	_, _, err = GetUserTradesOptions{}.methodAndParams()
+	assert.NotNil(t, err)
+
+	// The trades of an order are returned in one page.
+	c = &fakeRpcCaller{results: []string{`[{"trade_id": "BTC-4", "order_id": "o1"}]`}}
+	it = &userTradesIterator{c: c, params: GetUserTradesOptions{OrderId: "o1", Currency: "BTC", IncludeOld: true, Count: 2}}
+	trades, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []TradeExecution{{TradeId: "BTC-4", OrderId: "o1"}}, trades)
+	assert.True(t, it.Done())
+	assert.Equal(t, map[string]interface{}{"order_id": "o1", "sorting": "desc", "historical": true}, c.params[0])
+}
+
+func TestTradingExecutorGetUserTrades(t *testing.T) {
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req struct {
+				Id     int64                  `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			var result interface{} = "ok"
+			switch req.Method {
+			case "public/auth":
+				result = map[string]interface{}{"access_token": "access", "expires_in": 3600}
+			case "private/get_user_trades_by_instrument":
+				assert.Equal(t, "BTC-PERPETUAL", req.Params["instrument_name"])
+				result = map[string]interface{}{"trades": []map[string]interface{}{{"trade_id": "1"}}, "has_more": false}
+			case "private/get_user_trades_by_order":
+				assert.Equal(t, "o1", req.Params["order_id"])
+				result = []map[string]interface{}{{"trade_id": "2", "order_id": "o1"}}
+			}
+			if err := conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result}); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	ex := NewTradingExecutor(wsUrl, Credentials{ClientId: "id", ClientSecret: "secret"})
+	assert.Nil(t, ex.Start(ctx))
+
+	it := ex.GetUserTrades(GetUserTradesOptions{Instrument: "BTC-PERPETUAL"})
+	trades, err := it.Next(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []TradeExecution{{TradeId: "1"}}, trades)
+	assert.True(t, it.Done())
+
+	it = ex.GetUserTrades(GetUserTradesOptions{OrderId: "o1"})
+	trades, err = it.Next(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []TradeExecution{{TradeId: "2", OrderId: "o1"}}, trades)
+	assert.True(t, it.Done())
+}
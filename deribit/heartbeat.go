@@ -0,0 +1,63 @@
+package deribit
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fastjson"
+)
+
+// heartbeatInterval is the interval at which Deribit is asked to send heartbeats on a
+// connection. Deribit's minimum interval is 10 seconds.
+const heartbeatInterval = 30 * time.Second
+
+// heartbeat monitors the heartbeats Deribit sends on a connection after a
+// public/set_heartbeat request. Deribit periodically sends a test_request, which must be
+// answered with public/test or the connection is closed. If nothing is received on the
+// connection for two intervals, the heartbeat is missed and the connection should be
+// reset. For details see: https://docs.deribit.com/#public-set_heartbeat
+type heartbeat struct {
+	interval time.Duration
+	// last is the time, in unix nanoseconds, when a message was last received.
+	last atomic.Int64
+	now  func() time.Time
+}
+
+func newHeartbeat() *heartbeat {
+	return &heartbeat{interval: heartbeatInterval, now: time.Now}
+}
+
+// setRequest returns a public/set_heartbeat request for a new connection.
+func (h *heartbeat) setRequest() (id int64, msg []byte, err error) {
+	h.received()
+	id = genId()
+	params := map[string]interface{}{"interval": int(h.interval / time.Second)}
+	msg, err = rpcRequestMsg(methodPublicSetHeartbeat, id, params)
+	return id, msg, err
+}
+
+// received records that a message was received on the connection.
+func (h *heartbeat) received() {
+	h.last.Store(h.now().UnixNano())
+}
+
+// missed returns true if nothing has been received on the connection for two intervals.
+func (h *heartbeat) missed() bool {
+	return h.now().Sub(time.Unix(0, h.last.Load())) > 2*h.interval
+}
+
+// isHeartbeat returns true if a message is a heartbeat notification.
+func isHeartbeat(v *fastjson.Value) bool {
+	return string(v.GetStringBytes("method")) == "heartbeat"
+}
+
+// reply returns the public/test request answering a heartbeat, or nil if the heartbeat
+// doesn't require a reply.
+func (h *heartbeat) reply(v *fastjson.Value) (id int64, msg []byte, err error) {
+	if string(v.GetStringBytes("params", "type")) != "test_request" {
+		return 0, nil, nil
+	}
+	id = genId()
+	msg, err = rpcRequestMsg(methodPublicTest, id, map[string]interface{}{})
+	return id, msg, err
+}
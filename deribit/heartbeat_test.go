@@ -0,0 +1,193 @@
+package deribit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := newHeartbeat()
+	h.now = func() time.Time { return now }
+
+	_, msg, err := h.setRequest()
+	assert.Nil(t, err)
+	assert.Equal(t, float64(30), decodeParams(t, msg)["interval"])
+
+	now = now.Add(time.Minute)
+	assert.False(t, h.missed())
+	now = now.Add(time.Second)
+	assert.True(t, h.missed())
+	h.received()
+	assert.False(t, h.missed())
+
+	v := fastjson.MustParse(`{"jsonrpc":"2.0","method":"heartbeat","params":{"type":"heartbeat"}}`)
+	assert.True(t, isHeartbeat(v))
+	_, reply, err := h.reply(v)
+	assert.Nil(t, err)
+	assert.Nil(t, reply)
+
+	v = fastjson.MustParse(`{"jsonrpc":"2.0","method":"heartbeat","params":{"type":"test_request"}}`)
+	_, reply, err = h.reply(v)
+	assert.Nil(t, err)
+	assert.Contains(t, string(reply), `"method":"public/test"`)
+
+	assert.False(t, isHeartbeat(fastjson.MustParse(`{"jsonrpc":"2.0","id":1,"result":{}}`)))
+}
+
+func decodeParams(t *testing.T, msg []byte) map[string]interface{} {
+	v := fastjson.MustParse(string(msg))
+	var params map[string]interface{}
+	assert.Nil(t, decodeRpcResponse([]byte(`{"result":`+v.Get("params").String()+`}`), &params))
+	return params
+}
+
+// executorTestServer is a websocket server for testing the TradingExecutor. It records
+// the methods requested on each connection, and sends a heartbeat test_request after a
+// heartbeat is set. Sell requests are never answered, and auth requests on later
+// connections are answered after a delay.
+type executorTestServer struct {
+	*httptest.Server
+	m           sync.Mutex
+	connections [][]string
+}
+
+func newExecutorTestServer(t *testing.T) *executorTestServer {
+	s := &executorTestServer{}
+	var upgrader websocket.Upgrader
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		s.m.Lock()
+		n := len(s.connections)
+		s.connections = append(s.connections, nil)
+		s.m.Unlock()
+		for {
+			var req struct {
+				Id     int64                  `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.m.Lock()
+			s.connections[n] = append(s.connections[n], req.Method)
+			s.m.Unlock()
+
+			var result interface{} = "ok"
+			switch req.Method {
+			case "public/auth":
+				if n > 0 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				result = map[string]interface{}{"access_token": "access", "expires_in": 3600}
+			case "private/sell":
+				continue
+			case "private/enable_cancel_on_disconnect":
+				assert.Equal(t, "connection", req.Params["scope"])
+			case "private/buy":
+				result = map[string]interface{}{"order": map[string]interface{}{"order_id": "1"}}
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+			if req.Method == "public/set_heartbeat" {
+				notification := map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "heartbeat",
+					"params":  map[string]interface{}{"type": "test_request"},
+				}
+				if err := conn.WriteJSON(notification); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	return s
+}
+
+func (s *executorTestServer) methods() [][]string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	res := make([][]string, len(s.connections))
+	for i, c := range s.connections {
+		res[i] = append([]string{}, c...)
+	}
+	return res
+}
+
+func TestTradingExecutorHeartbeat(t *testing.T) {
+	server := newExecutorTestServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	ex := NewTradingExecutor(wsUrl, Credentials{ClientId: "id", ClientSecret: "secret"}, WithCancelOnDisconnect())
+	ex.(*liveTradeExecutor).heartbeat.interval = 100 * time.Millisecond
+	assert.Nil(t, ex.Start(ctx))
+
+	done := make(chan OrderUpdate, 1)
+	assert.Nil(t, ex.Buy("BTC-PERPETUAL", 10, nil, func(res RpcResponse[OrderUpdate]) {
+		assert.Nil(t, res.Error)
+		done <- res.Result
+	}))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a response to the buy request")
+	}
+
+	// The server doesn't send heartbeats, so the executor reconnects after two intervals.
+	// The sell request, which is never answered, fails when the connection is reset.
+	reset := make(chan *Error, 1)
+	assert.Nil(t, ex.Sell("BTC-PERPETUAL", 10, nil, func(res RpcResponse[OrderUpdate]) {
+		reset <- res.Error
+	}))
+	select {
+	case err := <-reset:
+		assert.Equal(t, "connection reset", err.Message)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the sell request to fail")
+	}
+
+	// A request made while reconnecting is sent once the connection is authenticated.
+	assert.Nil(t, ex.Buy("BTC-PERPETUAL", 10, nil, func(res RpcResponse[OrderUpdate]) {
+		assert.Nil(t, res.Error)
+		done <- res.Result
+	}))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a response to the buy request")
+	}
+
+	expected := []string{"public/auth", "public/set_heartbeat", "private/enable_cancel_on_disconnect"}
+	conns := server.methods()
+	assert.Equal(t, expected, conns[0][:3])
+	assert.Contains(t, conns[0], "public/test")
+	assert.Contains(t, conns[0], "private/buy")
+	assert.Contains(t, conns[0], "private/sell")
+	assert.Equal(t, append(expected, "private/buy"), conns[1][:4])
+
+	select {
+	case err := <-ex.Err():
+		t.Fatalf("unexpected error: %v", err)
+	default:
+	}
+}
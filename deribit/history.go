@@ -0,0 +1,445 @@
+package deribit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SettlementType is the type of a settlement event returned by
+// [Api.GetSettlementHistoryByInstrument].
+type SettlementType string
+
+const (
+	SettlementTypeSettlement SettlementType = "settlement"
+	SettlementTypeDelivery   SettlementType = "delivery"
+	SettlementTypeBankruptcy SettlementType = "bankruptcy"
+)
+
+// GetChartDataOptions specify the candles returned by [Api.GetChartData]. For details
+// see: https://docs.deribit.com/#public-get_tradingview_chart_data
+type GetChartDataOptions struct {
+	// Resolution is the candle duration in minutes ("1", "3", "5", "10", "15", "30", "60",
+	// "120", "180", "360", "720"), or "1D". It's required.
+	Resolution string
+	// Start is the time of the first candle. It's required.
+	Start time.Time
+	// End is the time of the last candle. Defaults to the current time.
+	End time.Time
+	// Count is the maximum number of candles returned by each call to the iterator's
+	// Next. Defaults to 1000.
+	Count int
+}
+
+// GetFundingRateHistoryOptions specify the period of the funding rates returned by
+// [Api.GetFundingRateHistory]. For details see:
+// https://docs.deribit.com/#public-get_funding_rate_history
+type GetFundingRateHistoryOptions struct {
+	// Start is the time of the first funding rate. It's required.
+	Start time.Time
+	// End is the time of the last funding rate. Defaults to the current time.
+	End time.Time
+}
+
+// GetVolatilityIndexDataOptions specify the candles returned by
+// [Api.GetVolatilityIndexData]. For details see:
+// https://docs.deribit.com/#public-get_volatility_index_data
+type GetVolatilityIndexDataOptions struct {
+	// Resolution is the candle duration in seconds ("1", "60", "3600", "43200"), or "1D".
+	// It's required.
+	Resolution string
+	// Start is the time of the first candle. It's required.
+	Start time.Time
+	// End is the time of the last candle. Defaults to the current time.
+	End time.Time
+}
+
+// GetSettlementHistoryParams specify the settlements returned by
+// [Api.GetSettlementHistoryByInstrument]. For details see:
+// https://docs.deribit.com/#private-get_settlement_history_by_instrument
+type GetSettlementHistoryParams struct {
+	// Credentials are required by the Api. They're ignored by the WsApi, which must have
+	// been created with credentials.
+	Credentials Credentials
+	// Type filters the settlements by type. By default, all types are returned.
+	Type SettlementType
+	// Count is the number of settlements returned by each call to the iterator's Next.
+	// Deribit's default is 20.
+	Count int
+	// SearchStart is the time from which to search backwards for settlements. Defaults
+	// to the current time.
+	SearchStart time.Time
+}
+
+// FundingRate is the funding rate of a perpetual over an hour.
+type FundingRate struct {
+	Timestamp      int64   `json:"timestamp"`
+	IndexPrice     float64 `json:"index_price"`
+	PrevIndexPrice float64 `json:"prev_index_price"`
+	Interest8h     float64 `json:"interest_8h"`
+	Interest1h     float64 `json:"interest_1h"`
+}
+
+// FundingChartData is the funding of a perpetual over a period, returned by
+// [Api.GetFundingChartData].
+type FundingChartData struct {
+	CurrentInterest float64             `json:"current_interest"`
+	Interest8h      float64             `json:"interest_8h"`
+	Data            []FundingChartPoint `json:"data"`
+}
+
+type FundingChartPoint struct {
+	Timestamp  int64   `json:"timestamp"`
+	IndexPrice float64 `json:"index_price"`
+	Interest8h float64 `json:"interest_8h"`
+}
+
+// HistoricalVolatility is the realised volatility of a currency, as a percentage.
+type HistoricalVolatility struct {
+	Timestamp  int64
+	Volatility float64
+}
+
+// VolatilityCandle is an OHLC candle of a volatility index.
+type VolatilityCandle struct {
+	Timestamp int64
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+}
+
+// Settlement is a settlement, delivery or bankruptcy event of a position.
+type Settlement struct {
+	Type              SettlementType `json:"type"`
+	Timestamp         int64          `json:"timestamp"`
+	InstrumentName    string         `json:"instrument_name"`
+	Position          float64        `json:"position"`
+	MarkPrice         float64        `json:"mark_price"`
+	IndexPrice        float64        `json:"index_price"`
+	ProfitLoss        float64        `json:"profit_loss"`
+	SessionProfitLoss float64        `json:"session_profit_loss"`
+	Funding           float64        `json:"funding"`
+	Funded            float64        `json:"funded"`
+}
+
+// GetChartData returns an iterator over the OHLCV candles of an instrument. Candles are
+// returned in ascending order from the start time. For details see:
+// https://docs.deribit.com/#public-get_tradingview_chart_data
+func (api *Api) GetChartData(instrument string, opts GetChartDataOptions) Iterator[[]DeribitCandle] {
+	return getChartData(api, instrument, opts)
+}
+
+// GetFundingRateHistory returns an iterator over the hourly funding rates of a perpetual.
+// Funding rates are returned in ascending order from the start time. For details see:
+// https://docs.deribit.com/#public-get_funding_rate_history
+func (api *Api) GetFundingRateHistory(instrument string, opts GetFundingRateHistoryOptions) Iterator[[]FundingRate] {
+	return getFundingRateHistory(api, instrument, opts)
+}
+
+// GetFundingChartData returns the funding of a perpetual over a length of time, which is
+// one of "8h", "24h" or "1m". For details see:
+// https://docs.deribit.com/#public-get_funding_chart_data
+func (api *Api) GetFundingChartData(ctx context.Context, instrument string, length string) (FundingChartData, error) {
+	return getFundingChartData(ctx, api, instrument, length)
+}
+
+// GetHistoricalVolatility returns the hourly realised volatility of a currency over the
+// past 15 days. For details see: https://docs.deribit.com/#public-get_historical_volatility
+func (api *Api) GetHistoricalVolatility(ctx context.Context, currency string) ([]HistoricalVolatility, error) {
+	return getHistoricalVolatility(ctx, api, currency)
+}
+
+// GetVolatilityIndexData returns an iterator over the candles of a currency's volatility
+// index (DVOL). Candles are returned in pages from the end time backwards, with each page
+// in ascending order. For details see:
+// https://docs.deribit.com/#public-get_volatility_index_data
+func (api *Api) GetVolatilityIndexData(currency string, opts GetVolatilityIndexDataOptions) Iterator[[]VolatilityCandle] {
+	return getVolatilityIndexData(api, currency, opts)
+}
+
+// GetSettlementHistoryByInstrument returns an iterator over the settlement events of an
+// instrument, from the most recent. For details see:
+// https://docs.deribit.com/#private-get_settlement_history_by_instrument
+func (api *Api) GetSettlementHistoryByInstrument(instrument string, p GetSettlementHistoryParams) Iterator[[]Settlement] {
+	c := privateApiCaller{api: api, c: p.Credentials}
+	return getSettlementHistory(c, instrument, p)
+}
+
+// privateApiCaller sends authenticated requests to the HTTP API.
+type privateApiCaller struct {
+	api *Api
+	c   Credentials
+}
+
+func (p privateApiCaller) rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error {
+	return apiGet(ctx, p.api, method, stringParams(params), &p.c, result)
+}
+
+// timeWindowIterator iterates over a period in ascending windows of time, for methods
+// which limit the number of items returned for a period rather than paginating.
+type timeWindowIterator[T any] struct {
+	done   bool
+	start  time.Time
+	end    time.Time
+	window time.Duration
+	fetch  func(ctx context.Context, start, end time.Time) ([]T, error)
+}
+
+func newTimeWindowIterator[T any](start, end time.Time, window time.Duration, fetch func(ctx context.Context, start, end time.Time) ([]T, error)) *timeWindowIterator[T] {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return &timeWindowIterator[T]{start: start, end: end, window: window, fetch: fetch}
+}
+
+func (it *timeWindowIterator[T]) Next(ctx context.Context) ([]T, error) {
+	end := it.start.Add(it.window)
+	if end.After(it.end) {
+		end = it.end
+	}
+	items, err := it.fetch(ctx, it.start, end)
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	// The end of the window is inclusive, so the next window starts after it.
+	it.start = end.Add(time.Millisecond)
+	if it.start.After(it.end) {
+		it.done = true
+	}
+	return items, nil
+}
+
+func (it *timeWindowIterator[T]) Done() bool {
+	return it.done
+}
+
+// errIterator is an iterator which returns an error from its first call to Next.
+type errIterator[T any] struct {
+	err  error
+	done bool
+}
+
+func (it *errIterator[T]) Next(ctx context.Context) (T, error) {
+	it.done = true
+	var zero T
+	return zero, it.err
+}
+
+func (it *errIterator[T]) Done() bool {
+	return it.done
+}
+
+type chartData struct {
+	Status string    `json:"status"`
+	Ticks  []int64   `json:"ticks"`
+	Open   []float64 `json:"open"`
+	High   []float64 `json:"high"`
+	Low    []float64 `json:"low"`
+	Close  []float64 `json:"close"`
+	Volume []float64 `json:"volume"`
+	Cost   []float64 `json:"cost"`
+}
+
+func (d chartData) candles() []DeribitCandle {
+	candles := make([]DeribitCandle, len(d.Ticks))
+	for i, tick := range d.Ticks {
+		candles[i] = DeribitCandle{
+			Tick:   tick,
+			Open:   valueAt(d.Open, i),
+			High:   valueAt(d.High, i),
+			Low:    valueAt(d.Low, i),
+			Close:  valueAt(d.Close, i),
+			Volume: valueAt(d.Volume, i),
+			Cost:   valueAt(d.Cost, i),
+		}
+	}
+	return candles
+}
+
+func valueAt(values []float64, i int) float64 {
+	if i < len(values) {
+		return values[i]
+	}
+	return 0
+}
+
+// resolutionDuration converts a resolution to a duration, where a numeric resolution is
+// a number of the given unit.
+func resolutionDuration(resolution string, unit time.Duration) (time.Duration, error) {
+	if resolution == "1D" {
+		return 24 * time.Hour, nil
+	}
+	n, err := strconv.Atoi(resolution)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid resolution %q", resolution)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+func getChartData(c rpcCaller, instrument string, opts GetChartDataOptions) Iterator[[]DeribitCandle] {
+	resolution, err := resolutionDuration(opts.Resolution, time.Minute)
+	if err != nil {
+		return &errIterator[[]DeribitCandle]{err: err}
+	}
+	if opts.Start.IsZero() {
+		return &errIterator[[]DeribitCandle]{err: errors.New("GetChartDataOptions requires a start time")}
+	}
+	count := opts.Count
+	if count == 0 {
+		count = 1000
+	}
+	window := time.Duration(count-1) * resolution
+	fetch := func(ctx context.Context, start, end time.Time) ([]DeribitCandle, error) {
+		params := map[string]interface{}{
+			"instrument_name": instrument,
+			"resolution":      opts.Resolution,
+			"start_timestamp": start.UnixMilli(),
+			"end_timestamp":   end.UnixMilli(),
+		}
+		data, err := call[chartData](ctx, c, methodPublicGetTradingviewChartData, params)
+		if err != nil {
+			return nil, err
+		}
+		return data.candles(), nil
+	}
+	return newTimeWindowIterator(opts.Start, opts.End, window, fetch)
+}
+
+// fundingRateWindow is the period of funding rates requested at a time. Deribit returns
+// at most 744 hourly funding rates per request.
+const fundingRateWindow = 30 * 24 * time.Hour
+
+func getFundingRateHistory(c rpcCaller, instrument string, opts GetFundingRateHistoryOptions) Iterator[[]FundingRate] {
+	if opts.Start.IsZero() {
+		return &errIterator[[]FundingRate]{err: errors.New("GetFundingRateHistoryOptions requires a start time")}
+	}
+	fetch := func(ctx context.Context, start, end time.Time) ([]FundingRate, error) {
+		params := map[string]interface{}{
+			"instrument_name": instrument,
+			"start_timestamp": start.UnixMilli(),
+			"end_timestamp":   end.UnixMilli(),
+		}
+		return call[[]FundingRate](ctx, c, methodPublicGetFundingRateHistory, params)
+	}
+	return newTimeWindowIterator(opts.Start, opts.End, fundingRateWindow, fetch)
+}
+
+func getFundingChartData(ctx context.Context, c rpcCaller, instrument string, length string) (FundingChartData, error) {
+	params := map[string]interface{}{"instrument_name": instrument, "length": length}
+	return call[FundingChartData](ctx, c, methodPublicGetFundingChartData, params)
+}
+
+func getHistoricalVolatility(ctx context.Context, c rpcCaller, currency string) ([]HistoricalVolatility, error) {
+	params := map[string]interface{}{"currency": currency}
+	data, err := call[[][2]float64](ctx, c, methodPublicGetHistoricalVolatility, params)
+	if err != nil {
+		return nil, err
+	}
+	vols := make([]HistoricalVolatility, len(data))
+	for i, d := range data {
+		vols[i] = HistoricalVolatility{Timestamp: int64(d[0]), Volatility: d[1]}
+	}
+	return vols, nil
+}
+
+type volatilityIndexData struct {
+	Data         [][5]float64 `json:"data"`
+	Continuation *int64       `json:"continuation"`
+}
+
+type volatilityIndexIterator struct {
+	done     bool
+	c        rpcCaller
+	currency string
+	opts     GetVolatilityIndexDataOptions
+}
+
+func (it *volatilityIndexIterator) Next(ctx context.Context) ([]VolatilityCandle, error) {
+	params := map[string]interface{}{
+		"currency":        it.currency,
+		"resolution":      it.opts.Resolution,
+		"start_timestamp": it.opts.Start.UnixMilli(),
+		"end_timestamp":   it.opts.End.UnixMilli(),
+	}
+	resp, err := call[volatilityIndexData](ctx, it.c, methodPublicGetVolatilityIndexData, params)
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	candles := make([]VolatilityCandle, len(resp.Data))
+	for i, d := range resp.Data {
+		candles[i] = VolatilityCandle{Timestamp: int64(d[0]), Open: d[1], High: d[2], Low: d[3], Close: d[4]}
+	}
+	if resp.Continuation == nil || len(candles) == 0 || *resp.Continuation >= it.opts.End.UnixMilli() {
+		it.done = true
+	} else {
+		// The continuation is the end time of the next page.
+		it.opts.End = time.UnixMilli(*resp.Continuation)
+	}
+	return candles, nil
+}
+
+func (it *volatilityIndexIterator) Done() bool {
+	return it.done
+}
+
+func getVolatilityIndexData(c rpcCaller, currency string, opts GetVolatilityIndexDataOptions) Iterator[[]VolatilityCandle] {
+	if opts.Start.IsZero() {
+		return &errIterator[[]VolatilityCandle]{err: errors.New("GetVolatilityIndexDataOptions requires a start time")}
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	return &volatilityIndexIterator{c: c, currency: currency, opts: opts}
+}
+
+type settlementsResponse struct {
+	Settlements  []Settlement `json:"settlements"`
+	Continuation string       `json:"continuation"`
+}
+
+type settlementsIterator struct {
+	done         bool
+	c            rpcCaller
+	instrument   string
+	params       GetSettlementHistoryParams
+	continuation string
+}
+
+func (it *settlementsIterator) Next(ctx context.Context) ([]Settlement, error) {
+	params := map[string]interface{}{"instrument_name": it.instrument}
+	if it.params.Type != "" {
+		params["type"] = string(it.params.Type)
+	}
+	if it.params.Count != 0 {
+		params["count"] = it.params.Count
+	}
+	if !it.params.SearchStart.IsZero() {
+		params["search_start_timestamp"] = it.params.SearchStart.UnixMilli()
+	}
+	if it.continuation != "" {
+		params["continuation"] = it.continuation
+	}
+	resp, err := call[settlementsResponse](ctx, it.c, methodPrivateGetSettlementHistoryByInstrument, params)
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	if resp.Continuation == "" || resp.Continuation == "none" || len(resp.Settlements) == 0 {
+		it.done = true
+	}
+	it.continuation = resp.Continuation
+	return resp.Settlements, nil
+}
+
+func (it *settlementsIterator) Done() bool {
+	return it.done
+}
+
+func getSettlementHistory(c rpcCaller, instrument string, p GetSettlementHistoryParams) Iterator[[]Settlement] {
+	return &settlementsIterator{c: c, instrument: instrument, params: p}
+}
@@ -0,0 +1,120 @@
+package deribit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChartDataIterator(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`{"status":"ok","ticks":[0,60000],"open":[1,2],"high":[3,4],"low":[0.5,1],"close":[2,3],"volume":[10,20],"cost":[15,30]}`,
+		`{"status":"ok","ticks":[120000],"open":[3],"high":[5],"low":[2],"close":[4],"volume":[5],"cost":[8]}`,
+	}}
+	opts := GetChartDataOptions{Resolution: "1", Start: time.UnixMilli(0), End: time.UnixMilli(120000), Count: 2}
+	it := getChartData(c, "BTC-PERPETUAL", opts)
+
+	candles, err := it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []DeribitCandle{
+		{Tick: 0, Open: 1, High: 3, Low: 0.5, Close: 2, Volume: 10, Cost: 15},
+		{Tick: 60000, Open: 2, High: 4, Low: 1, Close: 3, Volume: 20, Cost: 30},
+	}, candles)
+	assert.False(t, it.Done())
+
+	candles, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, candles, 1)
+	assert.True(t, it.Done())
+
+	assert.Equal(t, int64(0), c.params[0]["start_timestamp"])
+	assert.Equal(t, int64(60000), c.params[0]["end_timestamp"])
+	assert.Equal(t, int64(60001), c.params[1]["start_timestamp"])
+	assert.Equal(t, int64(120000), c.params[1]["end_timestamp"])
+
+	it = getChartData(c, "BTC-PERPETUAL", GetChartDataOptions{Resolution: "2h", Start: time.UnixMilli(0)})
+	_, err = it.Next(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, it.Done())
+}
+
+func TestFundingRateHistoryIterator(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`[{"timestamp":3600000,"index_price":50000,"prev_index_price":49900,"interest_8h":0.0001,"interest_1h":0.00001}]`,
+		`[]`,
+	}}
+	start := time.UnixMilli(0)
+	opts := GetFundingRateHistoryOptions{Start: start, End: start.Add(fundingRateWindow + time.Hour)}
+	it := getFundingRateHistory(c, "BTC-PERPETUAL", opts)
+
+	rates, err := it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []FundingRate{{Timestamp: 3600000, IndexPrice: 50000, PrevIndexPrice: 49900, Interest8h: 0.0001, Interest1h: 0.00001}}, rates)
+	assert.False(t, it.Done())
+
+	_, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, it.Done())
+}
+
+func TestFundingChartDataAndHistoricalVolatility(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`{"current_interest":0.0002,"interest_8h":0.0001,"data":[{"timestamp":1,"index_price":50000,"interest_8h":0.0001}]}`,
+		`[[1000,55.5],[2000,56.5]]`,
+	}}
+	data, err := getFundingChartData(context.Background(), c, "BTC-PERPETUAL", "8h")
+	assert.Nil(t, err)
+	assert.Equal(t, FundingChartData{
+		CurrentInterest: 0.0002,
+		Interest8h:      0.0001,
+		Data:            []FundingChartPoint{{Timestamp: 1, IndexPrice: 50000, Interest8h: 0.0001}},
+	}, data)
+	assert.Equal(t, map[string]interface{}{"instrument_name": "BTC-PERPETUAL", "length": "8h"}, c.params[0])
+
+	vols, err := getHistoricalVolatility(context.Background(), c, "BTC")
+	assert.Nil(t, err)
+	assert.Equal(t, []HistoricalVolatility{{Timestamp: 1000, Volatility: 55.5}, {Timestamp: 2000, Volatility: 56.5}}, vols)
+}
+
+func TestVolatilityIndexIterator(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`{"data":[[3000,50,52,49,51],[4000,51,53,50,52]],"continuation":2999}`,
+		`{"data":[[2000,48,50,47,50]],"continuation":null}`,
+	}}
+	opts := GetVolatilityIndexDataOptions{Resolution: "1", Start: time.UnixMilli(0), End: time.UnixMilli(5000)}
+	it := getVolatilityIndexData(c, "BTC", opts)
+
+	candles, err := it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, VolatilityCandle{Timestamp: 3000, Open: 50, High: 52, Low: 49, Close: 51}, candles[0])
+	assert.False(t, it.Done())
+
+	candles, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, candles, 1)
+	assert.True(t, it.Done())
+	assert.Equal(t, int64(2999), c.params[1]["end_timestamp"])
+}
+
+func TestSettlementsIterator(t *testing.T) {
+	c := &fakeRpcCaller{results: []string{
+		`{"settlements":[{"type":"settlement","timestamp":2,"instrument_name":"BTC-PERPETUAL","position":10,"profit_loss":0.001}],"continuation":"abc"}`,
+		`{"settlements":[{"type":"delivery","timestamp":1,"instrument_name":"BTC-PERPETUAL"}],"continuation":"none"}`,
+	}}
+	it := getSettlementHistory(c, "BTC-PERPETUAL", GetSettlementHistoryParams{Count: 1})
+
+	settlements, err := it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []Settlement{{Type: SettlementTypeSettlement, Timestamp: 2, InstrumentName: "BTC-PERPETUAL", Position: 10, ProfitLoss: 0.001}}, settlements)
+	assert.False(t, it.Done())
+
+	settlements, err = it.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, SettlementTypeDelivery, settlements[0].Type)
+	assert.True(t, it.Done())
+
+	assert.Equal(t, map[string]interface{}{"instrument_name": "BTC-PERPETUAL", "count": 1}, c.params[0])
+	assert.Equal(t, map[string]interface{}{"instrument_name": "BTC-PERPETUAL", "count": 1, "continuation": "abc"}, c.params[1])
+}
@@ -1,13 +1,16 @@
 package deribit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 
+	"github.com/bogdanovich/tradekit/internal/rest"
 	"github.com/bogdanovich/tradekit/lib/safe"
+	"github.com/bogdanovich/tradekit/lib/tk"
 )
 
 // Error is returned by requests to the Deribit API in the event that the request
@@ -21,77 +24,123 @@ func (e Error) Error() string {
 	return fmt.Sprintf("Deribit RPC error [%d]: %s", e.Code, e.Message)
 }
 
+// errTooManyRequests is the error code returned when a rate limit is exceeded.
+const errTooManyRequests = 10028
+
 // Iterator allows for iteration over paginated methods on the Deribit API.
 type Iterator[T any] interface {
 	// Done returns true when there are no more results in the iterator.
 	Done() bool
 	// Next returns the next batch of items from the iterator. You should stop calling
 	// Next after Done returns true.
-	Next() (T, error)
+	Next(ctx context.Context) (T, error)
+}
+
+// rpcCaller sends JSON-RPC requests to Deribit. It's implemented by both Api and WsApi
+// so that they share the implementation of their methods.
+type rpcCaller interface {
+	// rpcCall sends a request and decodes its result into the value pointed to by
+	// result.
+	rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error
+}
+
+func call[T any](ctx context.Context, c rpcCaller, method rpcMethod, params map[string]interface{}) (T, error) {
+	var result T
+	if err := c.rpcCall(ctx, method, params, &result); err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
 }
 
 // Api allows for sending requests to the Deribit JSON-RPC API over HTTP.
 type Api struct {
 	baseUrl *url.URL
+	client  *rest.Client
 }
 
+// Deribit allows 20 non-matching engine requests per second, with a burst of 100, for
+// most accounts. For details see: https://www.deribit.com/kb/deribit-rate-limits
+const (
+	requestsPerSecond = 20
+	requestsBurst     = 100
+)
+
 // NewApi creates a new Api to either the prod or testing Deribit server.
-func NewApi(apiUrl string) (*Api, error) {
+//
+// Requests are rate limited on the client side, and requests which are rejected with a
+// too_many_requests error are retried after a backoff. The HTTP client, request timeout
+// and number of retries may be configured with the params.
+func NewApi(apiUrl string, paramFuncs ...tk.Param) (*Api, error) {
 	baseUrl, err := url.Parse(apiUrl)
 	if err != nil {
 		return nil, err
 	}
-	return &Api{baseUrl: baseUrl}, nil
+	limiter := rest.NewLimiter(requestsPerSecond, requestsBurst)
+	return &Api{baseUrl: baseUrl, client: rest.NewClient(limiter, tk.ApplyParams(paramFuncs))}, nil
 }
 
-func apiPublicGet[T any](api *Api, method rpcMethod, params map[string]string) (T, error) {
-	u := urlWithParams(api.baseUrl, method, params)
-	r, err := http.Get(u)
-	if err != nil {
-		var zero T
-		return zero, apiErr(method, err)
-	}
-	defer r.Body.Close()
+func (api *Api) rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error {
+	return apiGet(ctx, api, method, stringParams(params), nil, result)
+}
 
-	var resp RpcResponse[T]
-	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+func apiPrivateGet[T any](ctx context.Context, api *Api, method rpcMethod, params map[string]interface{}, c Credentials) (T, error) {
+	var result T
+	if err := apiGet(ctx, api, method, stringParams(params), &c, &result); err != nil {
 		var zero T
-		return zero, apiErr(method, err)
-	}
-	if resp.Error != nil {
-		var zero T
-		return zero, apiErr(method, *resp.Error)
+		return zero, err
 	}
-
-	return resp.Result, nil
+	return result, nil
 }
 
-func apiPrivateGet[T any](api *Api, method rpcMethod, params map[string]string, c Credentials) (T, error) {
-	u := urlWithParams(api.baseUrl, method, params)
-	req, err := http.NewRequest("GET", u, nil)
-	req.SetBasicAuth(c.ClientId, c.ClientSecret)
-	if err != nil {
-		var zero T
-		return zero, err
+// apiGet sends a request to the HTTP API and decodes its result into the value pointed to
+// by result. Credentials are optional, and only required for private methods.
+func apiGet(ctx context.Context, api *Api, method rpcMethod, params map[string]string, c *Credentials, result interface{}) error {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, urlWithParams(api.baseUrl, method, params), nil)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			req.SetBasicAuth(c.ClientId, c.ClientSecret)
+		}
+		return req, nil
 	}
-	r, err := http.DefaultClient.Do(req)
+
+	err := api.client.Do(ctx, 1, newRequest, func(r *http.Response) error {
+		resp := RpcResponse[interface{}]{Result: result}
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			return err
+		}
+		if resp.Error != nil {
+			if resp.Error.Code == errTooManyRequests {
+				return rest.RateLimited(*resp.Error)
+			}
+			return *resp.Error
+		}
+		return nil
+	})
 	if err != nil {
-		var zero T
-		return zero, apiErr(method, err)
+		return apiErr(method, err)
 	}
-	defer r.Body.Close()
+	return nil
+}
 
-	var resp RpcResponse[T]
-	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
-		var zero T
-		return zero, apiErr(method, err)
-	}
-	if resp.Error != nil {
-		var zero T
-		return zero, apiErr(method, *resp.Error)
+// stringParams converts the params of a request to strings so that they can be sent in
+// a URL query.
+func stringParams(params map[string]interface{}) map[string]string {
+	m := make(map[string]string, len(params))
+	for k, v := range params {
+		switch v := v.(type) {
+		case string:
+			m[k] = v
+		case float64:
+			m[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			m[k] = fmt.Sprint(v)
+		}
 	}
-
-	return resp.Result, nil
+	return m
 }
 
 type GetPositionsParams struct {
@@ -101,27 +150,41 @@ type GetPositionsParams struct {
 	Kind         *InstrumentKind
 }
 
-// GetPositions retrieves all positions for specific currency, kind, and subaccount_id.
-func (api *Api) GetPositions(p GetPositionsParams) ([]DeribitPosition, error) {
-	params := map[string]string{}
+func (p GetPositionsParams) params() map[string]interface{} {
+	params := map[string]interface{}{}
 	if p.Currency != nil {
 		params["currency"] = *p.Currency
 	}
 	if p.Kind != nil {
 		params["kind"] = string(*p.Kind)
 	}
-	return apiPrivateGet[[]DeribitPosition](api, methodPrivateGetPositions, params, p.Credentials)
+	return params
+}
+
+// GetPositions retrieves all positions for specific currency, kind, and subaccount_id.
+func (api *Api) GetPositions(ctx context.Context, p GetPositionsParams) ([]DeribitPosition, error) {
+	return apiPrivateGet[[]DeribitPosition](ctx, api, methodPrivateGetPositions, p.params(), p.Credentials)
 }
 
 // GetOptionInstruments retrieves all Deribit option instruments on the given currency.
 // Set expired to true to show recently expired options instead of active ones.
-func (api *Api) GetOptionInstruments(currency string, expired bool) ([]Option, error) {
-	exp := "false"
-	if expired {
-		exp = "true"
-	}
-	params := map[string]string{"currency": currency, "kind": "option", "expired": exp}
-	return apiPublicGet[[]Option](api, methodPublicGetInstruments, params)
+func (api *Api) GetOptionInstruments(ctx context.Context, currency string, expired bool) ([]Option, error) {
+	return getOptionInstruments(ctx, api, currency, expired)
+}
+
+func getOptionInstruments(ctx context.Context, c rpcCaller, currency string, expired bool) ([]Option, error) {
+	params := map[string]interface{}{"currency": currency, "kind": "option", "expired": expired}
+	return call[[]Option](ctx, c, methodPublicGetInstruments, params)
+}
+
+// GetOptionInstrument retrieves a Deribit option instrument by its name.
+func (api *Api) GetOptionInstrument(ctx context.Context, name string) (Option, error) {
+	return getOptionInstrument(ctx, api, name)
+}
+
+func getOptionInstrument(ctx context.Context, c rpcCaller, name string) (Option, error) {
+	params := map[string]interface{}{"instrument_name": name}
+	return call[Option](ctx, c, methodPublicGetInstrument, params)
 }
 
 type GetInstrumentsParams struct {
@@ -132,27 +195,31 @@ type GetInstrumentsParams struct {
 
 // GetOptionInstruments retrieves all Deribit option instruments on the given currency.
 // Set expired to true to show recently expired options instead of active ones.
-func (api *Api) GetInstruments(p GetInstrumentsParams) ([]Instrument, error) {
-	params := map[string]string{
+func (api *Api) GetInstruments(ctx context.Context, p GetInstrumentsParams) ([]Instrument, error) {
+	return getInstruments(ctx, api, p)
+}
+
+func getInstruments(ctx context.Context, c rpcCaller, p GetInstrumentsParams) ([]Instrument, error) {
+	params := map[string]interface{}{
 		"currency": p.Currency,
-		"expired":  strconv.FormatBool(safe.Bool(p.Expired)),
+		"expired":  safe.Bool(p.Expired),
 	}
 	if p.Kind != nil {
 		params["kind"] = *p.Kind
 	}
-	return apiPublicGet[[]Instrument](api, methodPublicGetInstruments, params)
+	return call[[]Instrument](ctx, c, methodPublicGetInstruments, params)
 }
 
 // GetCurrencies returns a slice of the supported currencies on Deribit from the
 // /public/get_currencies endpoint.
 // For details see https://docs.deribit.com/#public-get_currencies
-func (api *Api) GetCurrencies() ([]CurrencyInfo, error) {
-	return apiPublicGet[[]CurrencyInfo](api, methodPublicGetCurrencies, nil)
+func (api *Api) GetCurrencies(ctx context.Context) ([]CurrencyInfo, error) {
+	return call[[]CurrencyInfo](ctx, api, methodPublicGetCurrencies, nil)
 }
 
 type deliveryPricesIterator struct {
 	done      bool
-	api       *Api
+	c         rpcCaller
 	count     int
 	offset    int
 	indexName string
@@ -162,12 +229,12 @@ func (it *deliveryPricesIterator) Done() bool {
 	return it.done
 }
 
-func (it *deliveryPricesIterator) Next() ([]DeliveryPrice, error) {
+func (it *deliveryPricesIterator) Next(ctx context.Context) ([]DeliveryPrice, error) {
 	method := methodPublicGetDeliveryPrices
-	params := map[string]string{"index_name": it.indexName, "offset": strconv.Itoa(it.offset), "count": strconv.Itoa(it.count)}
-	resp, err := apiPublicGet[deliveryPrices](it.api, method, params)
+	params := map[string]interface{}{"index_name": it.indexName, "offset": it.offset, "count": it.count}
+	resp, err := call[deliveryPrices](ctx, it.c, method, params)
 	if err != nil {
-		return nil, apiErr(method, err)
+		return nil, err
 	}
 
 	if len(resp.Prices) == 0 {
@@ -183,11 +250,15 @@ func (it *deliveryPricesIterator) Next() ([]DeliveryPrice, error) {
 // For more details see: https://docs.deribit.com/#public-get_delivery_prices. Results
 // are returned in descending order from the most recent delivery price.
 func (api *Api) GetDeliveryPrices(indexName string, p *OptionsGetDeliveryPrices) Iterator[[]DeliveryPrice] {
+	return getDeliveryPrices(api, indexName, p)
+}
+
+func getDeliveryPrices(c rpcCaller, indexName string, p *OptionsGetDeliveryPrices) Iterator[[]DeliveryPrice] {
 	count := 10
 	if p != nil && p.Count != 0 {
 		count = p.Count
 	}
-	return &deliveryPricesIterator{api: api, count: count, indexName: indexName}
+	return &deliveryPricesIterator{c: c, count: count, indexName: indexName}
 }
 
 type IndexPrice struct {
@@ -197,13 +268,17 @@ type IndexPrice struct {
 
 // GetIndexPrice returns the current price of a given index from the /public/get_index_price
 // endpoint.
-func (api *Api) GetIndexPrice(indexName string) (IndexPrice, error) {
-	params := map[string]string{"index_name": indexName}
-	return apiPublicGet[IndexPrice](api, methodPublicGetIndexPrice, params)
+func (api *Api) GetIndexPrice(ctx context.Context, indexName string) (IndexPrice, error) {
+	return getIndexPrice(ctx, api, indexName)
+}
+
+func getIndexPrice(ctx context.Context, c rpcCaller, indexName string) (IndexPrice, error) {
+	params := map[string]interface{}{"index_name": indexName}
+	return call[IndexPrice](ctx, c, methodPublicGetIndexPrice, params)
 }
 
-func (p GetTradesOptions) methodAndParams() (rpcMethod, map[string]string, error) {
-	params := make(map[string]string)
+func (p GetTradesOptions) methodAndParams() (rpcMethod, map[string]interface{}, error) {
+	params := make(map[string]interface{})
 	var method rpcMethod
 	if p.currency != "" {
 		params["currency"] = p.currency
@@ -229,9 +304,9 @@ func (p GetTradesOptions) methodAndParams() (rpcMethod, map[string]string, error
 		if p.startSequence != 0 || p.endSequence != 0 {
 			method = methodPublicGetLastTradesByInstrument
 			if p.startSequence != 0 {
-				params["start_seq"] = strconv.Itoa(p.startSequence)
+				params["start_seq"] = p.startSequence
 			} else {
-				params["end_seq"] = strconv.Itoa(p.endSequence)
+				params["end_seq"] = p.endSequence
 			}
 		} else {
 			if !p.StartTimestamp.IsZero() && !p.EndTimestamp.IsZero() {
@@ -251,16 +326,16 @@ func (p GetTradesOptions) methodAndParams() (rpcMethod, map[string]string, error
 	}
 
 	if !p.StartTimestamp.IsZero() && p.startSequence == 0 && p.startTradeId == "" {
-		params["start_timestamp"] = strconv.Itoa(int(p.StartTimestamp.UnixMilli()))
+		params["start_timestamp"] = p.StartTimestamp.UnixMilli()
 	}
 	if !p.EndTimestamp.IsZero() {
-		params["end_timestamp"] = strconv.Itoa(int(p.EndTimestamp.UnixMilli()))
+		params["end_timestamp"] = p.EndTimestamp.UnixMilli()
 	}
 
 	if p.Count != 0 {
-		params["count"] = strconv.Itoa(p.Count)
+		params["count"] = p.Count
 	} else {
-		params["count"] = "10"
+		params["count"] = 10
 	}
 
 	return method, params, nil
@@ -278,24 +353,24 @@ type getTradesResponse struct {
 type tradesIterator struct {
 	done      bool
 	params    GetTradesOptions
-	api       *Api
+	c         rpcCaller
 	doneFirst bool
 }
 
-func (it *tradesIterator) Next() ([]PublicTrade, error) {
+func (it *tradesIterator) Next(ctx context.Context) ([]PublicTrade, error) {
 	method, params, err := it.params.methodAndParams()
 	if err != nil {
 		it.done = true
 		return nil, err
 	}
-	resp, err := apiPublicGet[getTradesResponse](it.api, method, params)
+	resp, err := call[getTradesResponse](ctx, it.c, method, params)
 	if err != nil {
 		it.done = true
-		return nil, apiErr(method, err)
+		return nil, err
 	}
 
 	trades := resp.Trades
-	if it.doneFirst {
+	if it.doneFirst && len(trades) > 0 {
 		// After the first request, we skip the first trade. This is because subsequent
 		// requests set either the start trade Id, or start sequence (depending it we're
 		// querying the currency or instrument), and we need to exclude it, otherwise
@@ -337,19 +412,20 @@ func (it *tradesIterator) Done() bool {
 }
 
 func (api *Api) GetLastTradesByCurrencyAndKind(currency string, kind InstrumentKind, opts *GetTradesOptions) Iterator[[]PublicTrade] {
-	return api.getLastTrades("", currency, kind, opts)
+	return getLastTrades(api, "", currency, kind, opts)
 }
 
 func (api *Api) GetLastTradesByInstrument(instrument string, opts *GetTradesOptions) Iterator[[]PublicTrade] {
-	return api.getLastTrades(instrument, "", "", opts)
+	return getLastTrades(api, instrument, "", "", opts)
 }
 
-func (api *Api) getLastTrades(instrument string, currency string, kind InstrumentKind, opts *GetTradesOptions) Iterator[[]PublicTrade] {
+func getLastTrades(c rpcCaller, instrument string, currency string, kind InstrumentKind, opts *GetTradesOptions) Iterator[[]PublicTrade] {
 	var options GetTradesOptions
 	if opts == nil {
 		options.Count = 10
 	} else {
-		if opts.Count != 0 {
+		options.Count = opts.Count
+		if options.Count == 0 {
 			options.Count = 10
 		}
 		if !opts.StartTimestamp.IsZero() {
@@ -365,7 +441,7 @@ func (api *Api) getLastTrades(instrument string, currency string, kind Instrumen
 		options.currency = currency
 		options.kind = kind
 	}
-	return &tradesIterator{api: api, params: options}
+	return &tradesIterator{c: c, params: options}
 }
 
 func urlWithParams(baseUrl *url.URL, method rpcMethod, params map[string]string) string {
@@ -384,10 +460,14 @@ func apiErr(method rpcMethod, err error) error {
 
 // GetBookSummaryByCurrency retrieves the summary information for all instruments of a currency
 // For details see: https://docs.deribit.com/#public-get_book_summary_by_currency
-func (api *Api) GetBookSummaryByCurrency(currency string, kind InstrumentKind) ([]BookSummary, error) {
-	params := map[string]string{
+func (api *Api) GetBookSummaryByCurrency(ctx context.Context, currency string, kind InstrumentKind) ([]BookSummary, error) {
+	return getBookSummaryByCurrency(ctx, api, currency, kind)
+}
+
+func getBookSummaryByCurrency(ctx context.Context, c rpcCaller, currency string, kind InstrumentKind) ([]BookSummary, error) {
+	params := map[string]interface{}{
 		"currency": currency,
 		"kind":     string(kind),
 	}
-	return apiPublicGet[[]BookSummary](api, methodPublicGetBookSummaryByCurrency, params)
+	return call[[]BookSummary](ctx, c, methodPublicGetBookSummaryByCurrency, params)
 }
@@ -0,0 +1,135 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitIncrementalTickerSub represents a subscription to a Deribit incremental ticker stream created using [NewIncrementalTickerStream].
+// For details see: https://docs.deribit.com/#incremental_ticker-instrument_name
+type DeribitIncrementalTickerSub struct {
+	Instrument string
+}
+
+func (sub DeribitIncrementalTickerSub) channel() string {
+	return fmt.Sprintf("incremental_ticker.%s", sub.Instrument)
+}
+
+// NewIncrementalTickerStream creates a new [Stream] which produces a stream of ticker
+// updates. Deribit sends a snapshot of the ticker followed by messages containing only the
+// fields which changed. The changes are merged into the last ticker of the instrument, so
+// each message is a complete [DeribitTicker]. For details see:
+// https://docs.deribit.com/#incremental_ticker-instrument_name
+func NewIncrementalTickerStream(wsUrl string, subscriptions []DeribitIncrementalTickerSub, paramFuncs ...tk.Param) Stream[DeribitTicker, DeribitIncrementalTickerSub] {
+	m := newIncrementalTickerMerger()
+	p := streamParams[DeribitTicker, DeribitIncrementalTickerSub]{
+		name:         "IncrementalTickerStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: m.merge,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+// incrementalTickerMerger keeps the last ticker of each instrument to merge incremental
+// ticker changes into.
+type incrementalTickerMerger struct {
+	tickers map[string]DeribitTicker
+}
+
+func newIncrementalTickerMerger() *incrementalTickerMerger {
+	return &incrementalTickerMerger{tickers: make(map[string]DeribitTicker)}
+}
+
+func (m *incrementalTickerMerger) merge(v *fastjson.Value) DeribitTicker {
+	instrument := string(v.GetStringBytes("instrument_name"))
+	var t DeribitTicker
+	if string(v.GetStringBytes("type")) == "snapshot" {
+		t = ParseDeribitTicker(v)
+	} else {
+		t = m.tickers[instrument]
+		if t.Greeks != nil {
+			// Copy the greeks so that tickers which were already produced aren't modified.
+			greeks := *t.Greeks
+			t.Greeks = &greeks
+		}
+		MergeDeribitTicker(&t, v)
+	}
+	m.tickers[instrument] = t
+	return t
+}
+
+// MergeDeribitTicker updates the ticker with the fields present in v. Fields which are not
+// present are unchanged.
+func MergeDeribitTicker(t *DeribitTicker, v *fastjson.Value) {
+	mergeInt64(&t.Timestamp, v, "timestamp")
+	mergeString(&t.Instrument, v, "instrument_name")
+	mergeFloat(&t.BestAskPrice, v, "best_ask_price")
+	mergeFloat(&t.BestAskAmount, v, "best_ask_amount")
+	mergeFloat(&t.BestBidPrice, v, "best_bid_price")
+	mergeFloat(&t.BestBidAmount, v, "best_bid_amount")
+	mergeFloat(&t.LastPrice, v, "last_price")
+	mergeFloat(&t.MarkPrice, v, "mark_price")
+	mergeFloat(&t.IndexPrice, v, "index_price")
+	mergeFloat(&t.OpenInterest, v, "open_interest")
+	mergeString(&t.State, v, "state")
+	if stats := v.Get("stats"); stats != nil {
+		mergeFloat(&t.Stats.High, stats, "high")
+		mergeFloat(&t.Stats.Low, stats, "low")
+		mergeFloat(&t.Stats.PriceChange, stats, "price_change")
+		mergeFloat(&t.Stats.Volume, stats, "volume")
+		mergeFloat(&t.Stats.VolumeUSD, stats, "volume_usd")
+	}
+	if greeks := v.Get("greeks"); greeks != nil {
+		if t.Greeks == nil {
+			t.Greeks = &TickerGreeks{}
+		}
+		mergeFloat(&t.Greeks.Delta, greeks, "delta")
+		mergeFloat(&t.Greeks.Gamma, greeks, "gamma")
+		mergeFloat(&t.Greeks.Theta, greeks, "theta")
+		mergeFloat(&t.Greeks.Vega, greeks, "vega")
+		mergeFloat(&t.Greeks.Rho, greeks, "rho")
+	}
+	mergeFloat(&t.EstimatedDeliveryPrice, v, "estimated_delivery_price")
+	mergeOptionalFloat(&t.UnderlyingPrice, v, "underlying_price")
+	mergeOptionalFloat(&t.AskIV, v, "ask_iv")
+	mergeOptionalFloat(&t.BidIV, v, "bid_iv")
+	mergeOptionalFloat(&t.CurrentFunding, v, "current_funding")
+	mergeOptionalFloat(&t.DeliveryPrice, v, "delivery_price")
+	mergeOptionalFloat(&t.Funding8h, v, "funding_8h")
+	mergeOptionalFloat(&t.InterestRate, v, "interest_rate")
+	mergeOptionalFloat(&t.InterestValue, v, "interest_value")
+	mergeOptionalFloat(&t.MarkIV, v, "mark_iv")
+	mergeOptionalFloat(&t.MaxPrice, v, "max_price")
+	mergeOptionalFloat(&t.MinPrice, v, "min_price")
+	mergeOptionalFloat(&t.SettlementPrice, v, "settlement_price")
+	mergeString(&t.UnderlyingIndex, v, "underlying_index")
+}
+
+func mergeFloat(f *float64, v *fastjson.Value, key string) {
+	if value := v.Get(key); value != nil {
+		*f = value.GetFloat64()
+	}
+}
+
+func mergeOptionalFloat(f **float64, v *fastjson.Value, key string) {
+	if value := parseOptionalFloat(v, key); value != nil {
+		*f = value
+	}
+}
+
+func mergeInt64(i *int64, v *fastjson.Value, key string) {
+	if value := v.Get(key); value != nil {
+		*i = value.GetInt64()
+	}
+}
+
+func mergeString(s *string, v *fastjson.Value, key string) {
+	if value := v.Get(key); value != nil {
+		*s = string(value.GetStringBytes())
+	}
+}
@@ -0,0 +1,197 @@
+package deribit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expiryHour is the hour, in UTC, at which Deribit futures and options expire.
+const expiryHour = 8
+
+// perpetual is the suffix of the name of perpetual futures.
+const perpetual = "PERPETUAL"
+
+// InstrumentName is a parsed Deribit instrument name. Names have the forms:
+//   - Spot: BTC_USDC
+//   - Perpetual: BTC-PERPETUAL, or BTC_USDC-PERPETUAL for linear perpetuals
+//   - Future: BTC-27DEC24
+//   - Option: BTC-27DEC24-50000-C, or XRP_USDC-27DEC24-0d5-P for linear options, where
+//     "d" is the decimal point of the strike
+//   - Combo: BTC-FS-27DEC24_PERP or BTC-CS-27DEC24-50000_60000, where FS is the type of
+//     the combo, and the rest of the name specifies its legs
+//
+// Use [ParseInstrumentName] to parse a name, and String to format it.
+type InstrumentName struct {
+	// Base is the base currency e.g. BTC.
+	Base string
+	// Quote is the quote currency of linear and spot instruments e.g. USDC. It's empty for
+	// inverse instruments.
+	Quote string
+	Kind  InstrumentKind
+	// Perpetual is true for perpetual futures.
+	Perpetual bool
+	// Expiry is the expiration time of futures and options. It's zero for perpetuals and
+	// spot.
+	Expiry     time.Time
+	Strike     float64
+	OptionType OptionType
+	// ComboType is the type of a combo e.g. "FS" for a future spread, or "CS" for a call
+	// spread.
+	ComboType string
+	// ComboLegs is the part of a combo's name following its type, which specifies the
+	// combo's legs e.g. "27DEC24_PERP".
+	ComboLegs string
+}
+
+// ParseInstrumentName parses a Deribit instrument name.
+func ParseInstrumentName(name string) (InstrumentName, error) {
+	var n InstrumentName
+	parts := strings.Split(name, "-")
+	n.Base, n.Quote, _ = strings.Cut(parts[0], "_")
+	if n.Base == "" {
+		return InstrumentName{}, instrumentNameErr(name)
+	}
+	for _, part := range parts[1:] {
+		if part == "" {
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+	}
+
+	if len(parts) == 1 {
+		if n.Quote == "" {
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+		n.Kind = SpotInstrument
+		return n, nil
+	}
+
+	if parts[1] == perpetual {
+		if len(parts) != 2 {
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+		n.Kind = FutureInstrument
+		n.Perpetual = true
+		return n, nil
+	}
+
+	if isLetter(parts[1][0]) {
+		// Combos have a type in place of an expiry, such as "FS" or "CS".
+		if len(parts) < 3 {
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+		n.ComboType = parts[1]
+		n.ComboLegs = strings.Join(parts[2:], "-")
+		if n.ComboType == "FS" {
+			n.Kind = FutureComboInstrument
+		} else {
+			n.Kind = OptionComboInstrument
+		}
+		return n, nil
+	}
+
+	expiry, err := parseExpiry(parts[1])
+	if err != nil {
+		return InstrumentName{}, instrumentNameErr(name)
+	}
+	n.Expiry = expiry
+
+	switch len(parts) {
+	case 2:
+		n.Kind = FutureInstrument
+	case 4:
+		n.Kind = OptionInstrument
+		strike, err := strconv.ParseFloat(strings.Replace(parts[2], "d", ".", 1), 64)
+		if err != nil {
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+		n.Strike = strike
+		switch parts[3] {
+		case "C":
+			n.OptionType = CallOption
+		case "P":
+			n.OptionType = PutOption
+		default:
+			return InstrumentName{}, instrumentNameErr(name)
+		}
+	default:
+		return InstrumentName{}, instrumentNameErr(name)
+	}
+	return n, nil
+}
+
+// String formats the instrument name as it's named by Deribit.
+func (n InstrumentName) String() string {
+	var b strings.Builder
+	b.WriteString(n.Base)
+	if n.Quote != "" {
+		b.WriteString("_")
+		b.WriteString(n.Quote)
+	}
+	switch {
+	case n.Kind == SpotInstrument:
+	case n.Perpetual:
+		b.WriteString("-")
+		b.WriteString(perpetual)
+	case n.ComboType != "":
+		b.WriteString("-")
+		b.WriteString(n.ComboType)
+		b.WriteString("-")
+		b.WriteString(n.ComboLegs)
+	default:
+		b.WriteString("-")
+		b.WriteString(formatExpiry(n.Expiry))
+		if n.Kind == OptionInstrument {
+			b.WriteString("-")
+			b.WriteString(strings.Replace(strconv.FormatFloat(n.Strike, 'f', -1, 64), ".", "d", 1))
+			if n.OptionType == CallOption {
+				b.WriteString("-C")
+			} else {
+				b.WriteString("-P")
+			}
+		}
+	}
+	return b.String()
+}
+
+// SettlementCurrency returns the currency the instrument is settled in. Inverse
+// instruments are settled in their base currency, and linear instruments in their quote
+// currency.
+func (n InstrumentName) SettlementCurrency() string {
+	if n.Quote != "" {
+		return n.Quote
+	}
+	return n.Base
+}
+
+// IndexName returns the name of the index the instrument is priced on e.g. btc_usd, or
+// btc_usdc for linear instruments.
+func (n InstrumentName) IndexName() string {
+	quote := n.Quote
+	if quote == "" {
+		quote = "usd"
+	}
+	return strings.ToLower(n.Base + "_" + quote)
+}
+
+// parseExpiry parses an expiry date such as 27DEC24 or 3JAN25.
+func parseExpiry(s string) (time.Time, error) {
+	t, err := time.Parse("2Jan06", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(expiryHour * time.Hour), nil
+}
+
+func formatExpiry(t time.Time) string {
+	return strings.ToUpper(t.UTC().Format("2Jan06"))
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+func instrumentNameErr(name string) error {
+	return fmt.Errorf("invalid Deribit instrument name %q", name)
+}
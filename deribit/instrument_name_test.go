@@ -0,0 +1,115 @@
+package deribit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInstrumentName(t *testing.T) {
+	expiry := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		expected InstrumentName
+	}{
+		{"BTC_USDC", InstrumentName{Base: "BTC", Quote: "USDC", Kind: SpotInstrument}},
+		{"ETH-PERPETUAL", InstrumentName{Base: "ETH", Kind: FutureInstrument, Perpetual: true}},
+		{"BTC_USDC-PERPETUAL", InstrumentName{Base: "BTC", Quote: "USDC", Kind: FutureInstrument, Perpetual: true}},
+		{"BTC-27DEC24", InstrumentName{Base: "BTC", Kind: FutureInstrument, Expiry: expiry}},
+		{"BTC-3JAN25", InstrumentName{Base: "BTC", Kind: FutureInstrument, Expiry: time.Date(2025, 1, 3, 8, 0, 0, 0, time.UTC)}},
+		{"BTC-27DEC24-50000-C", InstrumentName{Base: "BTC", Kind: OptionInstrument, Expiry: expiry, Strike: 50000, OptionType: CallOption}},
+		{"XRP_USDC-27DEC24-0d5-P", InstrumentName{Base: "XRP", Quote: "USDC", Kind: OptionInstrument, Expiry: expiry, Strike: 0.5, OptionType: PutOption}},
+		{"BTC-FS-27DEC24_PERP", InstrumentName{Base: "BTC", Kind: FutureComboInstrument, ComboType: "FS", ComboLegs: "27DEC24_PERP"}},
+		{"BTC-CS-27DEC24-50000_60000", InstrumentName{Base: "BTC", Kind: OptionComboInstrument, ComboType: "CS", ComboLegs: "27DEC24-50000_60000"}},
+	}
+	for _, test := range tests {
+		n, err := ParseInstrumentName(test.name)
+		assert.Nil(t, err, test.name)
+		assert.Equal(t, test.expected, n, test.name)
+		assert.Equal(t, test.name, n.String())
+	}
+
+	for _, name := range []string{"", "BTC", "-PERPETUAL", "BTC-PERPETUAL-X", "BTC-32DEC24", "BTC-27DEC24-X-C", "BTC-27DEC24-50000-X", "BTC-27DEC24-50000", "BTC-FS", "BTC-", "BTC--1", "BTC-27DEC24--C"} {
+		_, err := ParseInstrumentName(name)
+		assert.NotNil(t, err, name)
+	}
+
+	n, _ := ParseInstrumentName("SOL_USDC-PERPETUAL")
+	assert.Equal(t, "USDC", n.SettlementCurrency())
+	assert.Equal(t, "sol_usdc", n.IndexName())
+	n, _ = ParseInstrumentName("BTC-27DEC24-50000-C")
+	assert.Equal(t, "BTC", n.SettlementCurrency())
+	assert.Equal(t, "btc_usd", n.IndexName())
+}
+
+type fakeInstrumentsGetter []Instrument
+
+func (g fakeInstrumentsGetter) GetInstruments(ctx context.Context, p GetInstrumentsParams) ([]Instrument, error) {
+	return g, nil
+}
+
+func TestInstrumentRegistry(t *testing.T) {
+	r := NewInstrumentRegistry()
+	api := fakeInstrumentsGetter{
+		{InstrumentName: "BTC-27DEC24-60000-C", Kind: "option", TickSize: 0.0005, IsActive: true},
+		{InstrumentName: "BTC-27DEC24-50000-P", Kind: "option", IsActive: true},
+		{InstrumentName: "BTC-27DEC24-50000-C", Kind: "option", IsActive: true},
+		{InstrumentName: "BTC-28MAR25-50000-C", Kind: "option", IsActive: true},
+		{InstrumentName: "BTC-27DEC24", Kind: "future", IsActive: true},
+		{InstrumentName: "BTC-PERPETUAL", Kind: "future", IsActive: true},
+		{InstrumentName: "ETH-27DEC24-3000-C", Kind: "option", IsActive: true},
+	}
+	assert.Nil(t, r.Load(context.Background(), api, GetInstrumentsParams{Currency: "any"}))
+
+	inst, ok := r.Get("BTC-27DEC24-60000-C")
+	assert.True(t, ok)
+	assert.Equal(t, 0.0005, inst.TickSize)
+
+	dec := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{dec, mar}, r.Expiries("BTC", OptionInstrument))
+	assert.Equal(t, []float64{50000, 60000}, r.Strikes("BTC", dec))
+	assert.Equal(t, []string{"BTC-27DEC24", "BTC-27DEC24-50000-C", "BTC-27DEC24-50000-P", "BTC-27DEC24-60000-C"}, instrumentNames(r.ByExpiry("BTC", dec)))
+	assert.Equal(t, []string{"BTC-27DEC24-50000-C", "BTC-27DEC24-50000-P"}, instrumentNames(r.ByStrike("BTC", dec, 50000)))
+	assert.Equal(t, []string{"BTC-PERPETUAL", "BTC-27DEC24"}, instrumentNames(r.ByKind("BTC", FutureInstrument)))
+
+	// A new instrument is created, and then started.
+	r.Apply(InstrumentState{Timestamp: 1, State: "created", Instrument: "BTC-28MAR25-70000-P"})
+	inst, ok = r.Get("BTC-28MAR25-70000-P")
+	assert.True(t, ok)
+	assert.False(t, inst.IsActive)
+	assert.Equal(t, Instrument{
+		BaseCurrency:        "BTC",
+		CreationTimestamp:   1,
+		ExpirationTimestamp: time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC).UnixMilli(),
+		InstrumentName:      "BTC-28MAR25-70000-P",
+		Kind:                "option",
+		OptionType:          "put",
+		PriceIndex:          "btc_usd",
+		QuoteCurrency:       "USD",
+		SettlementCurrency:  "BTC",
+		Strike:              70000,
+	}, inst)
+	r.Apply(InstrumentState{State: "started", Instrument: "BTC-28MAR25-70000-P"})
+	inst, _ = r.Get("BTC-28MAR25-70000-P")
+	assert.True(t, inst.IsActive)
+	assert.Equal(t, []float64{50000, 70000}, r.Strikes("BTC", mar))
+
+	// Expired instruments are settled, and then closed.
+	r.Apply(InstrumentState{State: "settled", Instrument: "BTC-27DEC24-60000-C"})
+	inst, _ = r.Get("BTC-27DEC24-60000-C")
+	assert.False(t, inst.IsActive)
+	r.Apply(InstrumentState{State: "closed", Instrument: "BTC-27DEC24-60000-C"})
+	_, ok = r.Get("BTC-27DEC24-60000-C")
+	assert.False(t, ok)
+}
+
+func instrumentNames(instruments []Instrument) []string {
+	names := make([]string, len(instruments))
+	for i, inst := range instruments {
+		names[i] = inst.InstrumentName
+	}
+	return names
+}
@@ -0,0 +1,206 @@
+package deribit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// instrumentsGetter is implemented by both the [Api] and [WsApi].
+type instrumentsGetter interface {
+	GetInstruments(ctx context.Context, p GetInstrumentsParams) ([]Instrument, error)
+}
+
+// InstrumentRegistry holds the instruments available on Deribit, indexed by their parsed
+// names for lookups by currency, kind, expiry and strike. It's populated with Load, and
+// kept current by applying the states produced by [NewInstrumentStateStream] with Apply.
+// An InstrumentRegistry is safe for concurrent use.
+type InstrumentRegistry struct {
+	mu          sync.RWMutex
+	instruments map[string]registryEntry
+}
+
+type registryEntry struct {
+	instrument Instrument
+	name       InstrumentName
+}
+
+// NewInstrumentRegistry creates an empty InstrumentRegistry.
+func NewInstrumentRegistry() *InstrumentRegistry {
+	return &InstrumentRegistry{instruments: make(map[string]registryEntry)}
+}
+
+// Load adds the instruments returned by GetInstruments on api, which may be either an
+// [Api] or [WsApi].
+func (r *InstrumentRegistry) Load(ctx context.Context, api instrumentsGetter, p GetInstrumentsParams) error {
+	instruments, err := api.GetInstruments(ctx, p)
+	if err != nil {
+		return err
+	}
+	r.Add(instruments...)
+	return nil
+}
+
+// Add adds or replaces instruments in the registry. Instruments with names which can't be
+// parsed are ignored.
+func (r *InstrumentRegistry) Add(instruments ...Instrument) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, inst := range instruments {
+		name, err := ParseInstrumentName(inst.InstrumentName)
+		if err != nil {
+			continue
+		}
+		r.instruments[inst.InstrumentName] = registryEntry{instrument: inst, name: name}
+	}
+}
+
+// Apply updates the registry with an instrument state change. A created instrument is
+// added with the details which can be parsed from its name; its remaining details, such
+// as its tick size, are only available after it's reloaded with Load. Started instruments
+// are marked active, and settled instruments inactive. Closed, deactivated and
+// terminated instruments are removed.
+func (r *InstrumentRegistry) Apply(s InstrumentState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch s.State {
+	case "created", "started":
+		e, ok := r.instruments[s.Instrument]
+		if !ok {
+			name, err := ParseInstrumentName(s.Instrument)
+			if err != nil {
+				return
+			}
+			e = registryEntry{instrument: instrumentFromName(name), name: name}
+			e.instrument.CreationTimestamp = s.Timestamp
+		}
+		e.instrument.IsActive = s.State == "started"
+		r.instruments[s.Instrument] = e
+	case "settled":
+		if e, ok := r.instruments[s.Instrument]; ok {
+			e.instrument.IsActive = false
+			r.instruments[s.Instrument] = e
+		}
+	case "closed", "deactivated", "terminated":
+		delete(r.instruments, s.Instrument)
+	}
+}
+
+// Get returns an instrument by name, and false if the instrument isn't in the registry.
+func (r *InstrumentRegistry) Get(name string) (Instrument, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.instruments[name]
+	return e.instrument, ok
+}
+
+// ByKind returns the instruments of a kind on a base currency, ordered by expiry, strike
+// and name.
+func (r *InstrumentRegistry) ByKind(currency string, kind InstrumentKind) []Instrument {
+	return r.filter(func(n InstrumentName) bool {
+		return n.Base == currency && n.Kind == kind
+	})
+}
+
+// ByExpiry returns the futures and options on a base currency which expire at the given
+// time, ordered by strike and name.
+func (r *InstrumentRegistry) ByExpiry(currency string, expiry time.Time) []Instrument {
+	return r.filter(func(n InstrumentName) bool {
+		return n.Base == currency && n.ComboType == "" && n.Expiry.Equal(expiry)
+	})
+}
+
+// ByStrike returns the call and put options on a base currency with the given expiry and
+// strike.
+func (r *InstrumentRegistry) ByStrike(currency string, expiry time.Time, strike float64) []Instrument {
+	return r.filter(func(n InstrumentName) bool {
+		return n.Base == currency && n.Kind == OptionInstrument && n.Expiry.Equal(expiry) && n.Strike == strike
+	})
+}
+
+// Expiries returns the distinct expiries of the instruments of a kind on a base currency,
+// in ascending order.
+func (r *InstrumentRegistry) Expiries(currency string, kind InstrumentKind) []time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[int64]bool)
+	var expiries []time.Time
+	for _, e := range r.instruments {
+		n := e.name
+		if n.Base != currency || n.Kind != kind || n.Expiry.IsZero() || seen[n.Expiry.UnixMilli()] {
+			continue
+		}
+		seen[n.Expiry.UnixMilli()] = true
+		expiries = append(expiries, n.Expiry)
+	}
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].Before(expiries[j]) })
+	return expiries
+}
+
+// Strikes returns the distinct strikes of the options on a base currency with the given
+// expiry, in ascending order.
+func (r *InstrumentRegistry) Strikes(currency string, expiry time.Time) []float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[float64]bool)
+	var strikes []float64
+	for _, e := range r.instruments {
+		n := e.name
+		if n.Base != currency || n.Kind != OptionInstrument || !n.Expiry.Equal(expiry) || seen[n.Strike] {
+			continue
+		}
+		seen[n.Strike] = true
+		strikes = append(strikes, n.Strike)
+	}
+	sort.Float64s(strikes)
+	return strikes
+}
+
+func (r *InstrumentRegistry) filter(match func(n InstrumentName) bool) []Instrument {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var entries []registryEntry
+	for _, e := range r.instruments {
+		if match(e.name) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].name, entries[j].name
+		if !a.Expiry.Equal(b.Expiry) {
+			return a.Expiry.Before(b.Expiry)
+		}
+		if a.Strike != b.Strike {
+			return a.Strike < b.Strike
+		}
+		return entries[i].instrument.InstrumentName < entries[j].instrument.InstrumentName
+	})
+	instruments := make([]Instrument, len(entries))
+	for i, e := range entries {
+		instruments[i] = e.instrument
+	}
+	return instruments
+}
+
+// instrumentFromName returns an instrument with the details which can be parsed from its
+// name.
+func instrumentFromName(n InstrumentName) Instrument {
+	inst := Instrument{
+		BaseCurrency:       n.Base,
+		InstrumentName:     n.String(),
+		Kind:               string(n.Kind),
+		OptionType:         string(n.OptionType),
+		PriceIndex:         n.IndexName(),
+		QuoteCurrency:      n.Quote,
+		SettlementCurrency: n.SettlementCurrency(),
+		Strike:             n.Strike,
+	}
+	if inst.QuoteCurrency == "" {
+		inst.QuoteCurrency = "USD"
+	}
+	if !n.Expiry.IsZero() {
+		inst.ExpirationTimestamp = n.Expiry.UnixMilli()
+	}
+	return inst
+}
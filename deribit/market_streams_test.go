@@ -0,0 +1,97 @@
+package deribit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestIncrementalTickerMerge(t *testing.T) {
+	m := newIncrementalTickerMerger()
+	snapshot := fastjson.MustParse(`{
+		"type": "snapshot",
+		"timestamp": 1000,
+		"instrument_name": "BTC-27DEC24-60000-C",
+		"best_bid_price": 0.05,
+		"best_ask_price": 0.06,
+		"mark_price": 0.055,
+		"mark_iv": 50.1,
+		"stats": {"high": 0.07, "low": 0.04, "volume": 12},
+		"greeks": {"delta": 0.5, "gamma": 0.0001, "vega": 30}
+	}`)
+	first := m.merge(snapshot)
+	assert.Equal(t, int64(1000), first.Timestamp)
+	assert.Equal(t, 0.05, first.BestBidPrice)
+	assert.Equal(t, 50.1, *first.MarkIV)
+	assert.Equal(t, 0.5, first.Greeks.Delta)
+
+	change := fastjson.MustParse(`{
+		"type": "change",
+		"timestamp": 1100,
+		"instrument_name": "BTC-27DEC24-60000-C",
+		"best_bid_price": 0.051,
+		"stats": {"volume": 13},
+		"greeks": {"delta": 0.52}
+	}`)
+	second := m.merge(change)
+	assert.Equal(t, int64(1100), second.Timestamp)
+	assert.Equal(t, 0.051, second.BestBidPrice)
+	assert.Equal(t, 0.06, second.BestAskPrice)
+	assert.Equal(t, 50.1, *second.MarkIV)
+	assert.Equal(t, TickerStats{High: 0.07, Low: 0.04, Volume: 13}, second.Stats)
+	assert.Equal(t, &TickerGreeks{Delta: 0.52, Gamma: 0.0001, Vega: 30}, second.Greeks)
+
+	// The previous ticker is unchanged.
+	assert.Equal(t, 0.5, first.Greeks.Delta)
+
+	// A change to another instrument starts from an empty ticker.
+	other := m.merge(fastjson.MustParse(`{"type":"change","instrument_name":"ETH-PERPETUAL","last_price":3000}`))
+	assert.Equal(t, DeribitTicker{Instrument: "ETH-PERPETUAL", LastPrice: 3000}, other)
+
+	// A new snapshot replaces the ticker.
+	third := m.merge(fastjson.MustParse(`{"type":"snapshot","timestamp":1200,"instrument_name":"BTC-27DEC24-60000-C"}`))
+	assert.Equal(t, DeribitTicker{Timestamp: 1200, Instrument: "BTC-27DEC24-60000-C"}, third)
+}
+
+func TestParseMarketStreams(t *testing.T) {
+	quote := ParseDeribitQuote(fastjson.MustParse(`{"timestamp":1,"instrument_name":"BTC-PERPETUAL","best_bid_price":100,"best_bid_amount":10,"best_ask_price":101,"best_ask_amount":20}`))
+	assert.Equal(t, DeribitQuote{Timestamp: 1, Instrument: "BTC-PERPETUAL", BestBidPrice: 100, BestBidAmount: 10, BestAskPrice: 101, BestAskAmount: 20}, quote)
+
+	perp := ParseDeribitPerpetual(fastjson.MustParse(`{"timestamp":1,"interest":0.0001,"index_price":50000}`))
+	assert.Equal(t, DeribitPerpetual{Timestamp: 1, Interest: 0.0001, IndexPrice: 50000}, perp)
+
+	candle := ParseDeribitCandle(fastjson.MustParse(`{"tick":60000,"open":1,"high":3,"low":0.5,"close":2,"volume":10,"cost":15}`))
+	assert.Equal(t, DeribitCandle{Tick: 60000, Open: 1, High: 3, Low: 0.5, Close: 2, Volume: 10, Cost: 15}, candle)
+
+	marks := ParseDeribitOptionMarkPrices(fastjson.MustParse(`[{"timestamp":1,"instrument_name":"BTC-27DEC24-60000-C","mark_price":0.05,"iv":0.5}]`))
+	assert.Equal(t, []DeribitOptionMarkPrice{{Timestamp: 1, Instrument: "BTC-27DEC24-60000-C", MarkPrice: 0.05, IV: 0.5}}, marks)
+
+	dvol := ParseDeribitVolatilityIndex(fastjson.MustParse(`{"timestamp":1,"volatility":55.5,"index_name":"btc_usd"}`))
+	assert.Equal(t, DeribitVolatilityIndex{Timestamp: 1, Volatility: 55.5, IndexName: "btc_usd"}, dvol)
+}
+
+func TestEstimatedExpirationPriceStream(t *testing.T) {
+	server := newTestStreamServer(t, `{"seconds":100,"price":50000,"is_estimated":true}`, make(chan string, 10))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	subs := []DeribitEstimatedExpirationPriceSub{{IndexName: "btc_usd"}}
+	stream := NewEstimatedExpirationPriceStream(wsUrl, subs)
+	assert.Nil(t, stream.Start(ctx))
+
+	select {
+	case msg := <-stream.Messages():
+		expected := DeribitEstimatedExpirationPrice{IndexName: "btc_usd", Seconds: 100, Price: 50000, IsEstimated: true}
+		assert.Equal(t, expected, msg)
+	case err := <-stream.Err():
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("expected a message")
+	}
+}
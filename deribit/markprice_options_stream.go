@@ -0,0 +1,54 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitMarkPriceOptionsSub represents a subscription to a Deribit options mark price stream created using [NewMarkPriceOptionsStream].
+// For details see: https://docs.deribit.com/#markprice-options-index_name
+type DeribitMarkPriceOptionsSub struct {
+	IndexName string
+}
+
+// DeribitOptionMarkPrice is the mark price and implied volatility of an option.
+type DeribitOptionMarkPrice struct {
+	Timestamp  int64   `json:"timestamp" parquet:"name=timestamp, type=INT64"`
+	Instrument string  `json:"instrument_name" parquet:"name=instrument, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MarkPrice  float64 `json:"mark_price" parquet:"name=mark_price, type=DOUBLE"`
+	IV         float64 `json:"iv" parquet:"name=iv, type=DOUBLE"`
+}
+
+func (sub DeribitMarkPriceOptionsSub) channel() string {
+	return fmt.Sprintf("markprice.options.%s", sub.IndexName)
+}
+
+// NewMarkPriceOptionsStream creates a new [Stream] which produces the mark prices of all
+// options on an index. For details see: https://docs.deribit.com/#markprice-options-index_name
+func NewMarkPriceOptionsStream(wsUrl string, subscriptions []DeribitMarkPriceOptionsSub, paramFuncs ...tk.Param) Stream[[]DeribitOptionMarkPrice, DeribitMarkPriceOptionsSub] {
+	p := streamParams[[]DeribitOptionMarkPrice, DeribitMarkPriceOptionsSub]{
+		name:         "MarkPriceOptionsStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: ParseDeribitOptionMarkPrices,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitOptionMarkPrices(v *fastjson.Value) []DeribitOptionMarkPrice {
+	items := v.GetArray()
+	prices := make([]DeribitOptionMarkPrice, len(items))
+	for i, item := range items {
+		prices[i] = DeribitOptionMarkPrice{
+			Timestamp:  item.GetInt64("timestamp"),
+			Instrument: string(item.GetStringBytes("instrument_name")),
+			MarkPrice:  item.GetFloat64("mark_price"),
+			IV:         item.GetFloat64("iv"),
+		}
+	}
+	return prices
+}
@@ -0,0 +1,260 @@
+package deribit
+
+import (
+	"github.com/valyala/fastjson"
+)
+
+// QuoteCancelType specifies which quotes are cancelled by a [TradingExecutor.CancelQuotes]
+// request.
+type QuoteCancelType string
+
+const (
+	CancelAllQuotes          QuoteCancelType = "all"
+	CancelQuotesByDelta      QuoteCancelType = "delta"
+	CancelQuotesByQuoteSetId QuoteCancelType = "quote_set_id"
+	CancelQuotesByInstrument QuoteCancelType = "instrument"
+	CancelQuotesByKind       QuoteCancelType = "instrument_kind"
+	CancelQuotesByCurrency   QuoteCancelType = "currency"
+)
+
+// Sides of a quote
+const (
+	Bid string = "bid"
+	Ask string = "ask"
+)
+
+// QuoteSide is one side of a [Quote].
+type QuoteSide struct {
+	Price          float64
+	Amount         float64
+	PostOnly       bool
+	RejectPostOnly bool
+}
+
+// Quote is a two sided quote on an instrument. Either side may be nil to only quote the
+// other side.
+type Quote struct {
+	Instrument string
+	// QuoteSetId optionally identifies the quote's set, so that the set may be cancelled
+	// with [CancelQuotesByQuoteSetId].
+	QuoteSetId string
+	Bid        *QuoteSide
+	Ask        *QuoteSide
+}
+
+// MassQuoteParams specify the quotes for a [TradingExecutor.MassQuote] request. For
+// details see: https://docs.deribit.com/#private-mass_quote
+type MassQuoteParams struct {
+	// QuoteId is a user defined identifier of the request. It's required.
+	QuoteId string
+	// MmpGroup is the Market Maker Protection group of the quotes. It's required, and must
+	// be configured with [TradingExecutor.SetMmpConfig].
+	MmpGroup string
+	Quotes   []Quote
+	// Detailed requests the resulting orders, trades and errors for each quote in the
+	// response. Otherwise, only the number of errors is returned.
+	Detailed bool
+	// WaitForResponse waits for all quotes to be processed before responding.
+	WaitForResponse bool
+	// ValidUntil is the time in milliseconds after which the request is rejected if it
+	// hasn't been processed.
+	ValidUntil int64
+}
+
+// MassQuoteResult is the result of a [TradingExecutor.MassQuote] request. Quotes which
+// were rejected are reported in Errors, while the rest of the request may have succeeded.
+type MassQuoteResult struct {
+	Orders      []Order
+	Trades      []TradeExecution
+	Errors      []QuoteError
+	ErrorsCount int
+}
+
+// QuoteError is the error for one side of a quote in a mass quote request.
+type QuoteError struct {
+	Instrument string
+	// Side is either [Bid] or [Ask].
+	Side  string
+	Error Error
+}
+
+// CancelQuotesOptions specify the quotes to cancel in a [TradingExecutor.CancelQuotes]
+// request. The fields used depend on the cancel type. For details see:
+// https://docs.deribit.com/#private-cancel_quotes
+type CancelQuotesOptions struct {
+	Type       QuoteCancelType
+	Currency   string
+	Kind       InstrumentKind
+	Instrument string
+	QuoteSetId string
+	MinDelta   float64
+	MaxDelta   float64
+}
+
+// MmpConfig is the configuration of Market Maker Protection for an index and MMP group,
+// set with [TradingExecutor.SetMmpConfig]. For details see:
+// https://docs.deribit.com/#private-set_mmp_config
+type MmpConfig struct {
+	// IndexName is the index the instruments are priced on e.g. btc_usd.
+	IndexName string
+	// MmpGroup is the group to configure. If empty, the default group is configured.
+	MmpGroup string
+	// Interval is the MMP interval in seconds. An interval of 0 disables MMP.
+	Interval int
+	// FrozenTime is the time in seconds that quoting is frozen after MMP triggers. If 0,
+	// quoting is frozen until MMP is reset.
+	FrozenTime    int
+	QuantityLimit float64
+	DeltaLimit    float64
+	VegaLimit     float64
+}
+
+// MmpTrigger is produced when Market Maker Protection triggers, and the quotes of an MMP
+// group are cancelled.
+type MmpTrigger struct {
+	IndexName string
+	MmpGroup  string
+	// FrozenUntil is the time in milliseconds until which quoting is frozen. It's 0 if
+	// quoting is frozen until MMP is reset.
+	FrozenUntil int64
+}
+
+func (s *QuoteSide) params() map[string]interface{} {
+	m := map[string]interface{}{
+		"price":  s.Price,
+		"amount": s.Amount,
+	}
+	if s.PostOnly {
+		m["post_only"] = s.PostOnly
+	}
+	if s.RejectPostOnly {
+		m["reject_post_only"] = s.RejectPostOnly
+	}
+	return m
+}
+
+func (p MassQuoteParams) params() map[string]interface{} {
+	quotes := make([]map[string]interface{}, len(p.Quotes))
+	for i, q := range p.Quotes {
+		quote := map[string]interface{}{"instrument_name": q.Instrument}
+		if q.QuoteSetId != "" {
+			quote["quote_set_id"] = q.QuoteSetId
+		}
+		if q.Bid != nil {
+			quote["bid"] = q.Bid.params()
+		}
+		if q.Ask != nil {
+			quote["ask"] = q.Ask.params()
+		}
+		quotes[i] = quote
+	}
+	m := map[string]interface{}{
+		"quote_id":  p.QuoteId,
+		"mmp_group": p.MmpGroup,
+		"quotes":    quotes,
+	}
+	if p.Detailed {
+		m["detailed"] = p.Detailed
+	}
+	if p.WaitForResponse {
+		m["wait_for_response"] = p.WaitForResponse
+	}
+	if p.ValidUntil != 0 {
+		m["valid_until"] = p.ValidUntil
+	}
+	return m
+}
+
+func (o *CancelQuotesOptions) params() map[string]interface{} {
+	m := map[string]interface{}{"cancel_type": string(CancelAllQuotes)}
+	if o == nil {
+		return m
+	}
+	if o.Type != "" {
+		m["cancel_type"] = string(o.Type)
+	}
+	if o.Currency != "" {
+		m["currency"] = o.Currency
+	}
+	if o.Kind != "" {
+		m["kind"] = string(o.Kind)
+	}
+	if o.Instrument != "" {
+		m["instrument_name"] = o.Instrument
+	}
+	if o.QuoteSetId != "" {
+		m["quote_set_id"] = o.QuoteSetId
+	}
+	if o.Type == CancelQuotesByDelta {
+		m["min_delta"] = o.MinDelta
+		m["max_delta"] = o.MaxDelta
+	}
+	return m
+}
+
+func (c MmpConfig) params() map[string]interface{} {
+	m := map[string]interface{}{
+		"index_name":     c.IndexName,
+		"interval":       c.Interval,
+		"frozen_time":    c.FrozenTime,
+		"quantity_limit": c.QuantityLimit,
+	}
+	if c.MmpGroup != "" {
+		m["mmp_group"] = c.MmpGroup
+	}
+	if c.DeltaLimit != 0 {
+		m["delta_limit"] = c.DeltaLimit
+	}
+	if c.VegaLimit != 0 {
+		m["vega_limit"] = c.VegaLimit
+	}
+	return m
+}
+
+func parseMassQuoteResult(v *fastjson.Value) MassQuoteResult {
+	orderItems := v.GetArray("orders")
+	orders := make([]Order, len(orderItems))
+	for i, item := range orderItems {
+		orders[i] = parseOrder(item)
+	}
+	errorItems := v.GetArray("errors")
+	errors := make([]QuoteError, len(errorItems))
+	for i, item := range errorItems {
+		errors[i] = parseQuoteError(item)
+	}
+	errorsCount := v.GetInt("errors_count")
+	if errorsCount == 0 {
+		errorsCount = len(errors)
+	}
+	return MassQuoteResult{
+		Orders:      orders,
+		Trades:      parseTradeExecutions(v.Get("trades")),
+		Errors:      errors,
+		ErrorsCount: errorsCount,
+	}
+}
+
+func parseQuoteError(v *fastjson.Value) QuoteError {
+	// The error's code and message are either nested in an error object, or are fields of
+	// the quote error itself.
+	errField := v
+	if e := v.Get("error"); e != nil && e.Type() == fastjson.TypeObject {
+		errField = e
+	}
+	return QuoteError{
+		Instrument: string(v.GetStringBytes("instrument_name")),
+		Side:       string(v.GetStringBytes("side")),
+		Error: Error{
+			Code:    errField.GetInt("code"),
+			Message: string(errField.GetStringBytes("message")),
+		},
+	}
+}
+
+func parseMmpTrigger(v *fastjson.Value) MmpTrigger {
+	return MmpTrigger{
+		IndexName:   string(v.GetStringBytes("index_name")),
+		MmpGroup:    string(v.GetStringBytes("mmp_group")),
+		FrozenUntil: v.GetInt64("frozen_until"),
+	}
+}
@@ -0,0 +1,105 @@
+package deribit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestMassQuoteParams(t *testing.T) {
+	p := MassQuoteParams{
+		QuoteId:  "q1",
+		MmpGroup: "group",
+		Quotes: []Quote{
+			{
+				Instrument: "BTC-27DEC24-60000-C",
+				QuoteSetId: "set1",
+				Bid:        &QuoteSide{Price: 0.05, Amount: 1, PostOnly: true},
+				Ask:        &QuoteSide{Price: 0.06, Amount: 2},
+			},
+			{Instrument: "BTC-27DEC24-70000-C", Ask: &QuoteSide{Price: 0.02, Amount: 1}},
+		},
+		Detailed: true,
+	}
+	expected := map[string]interface{}{
+		"quote_id":  "q1",
+		"mmp_group": "group",
+		"detailed":  true,
+		"quotes": []map[string]interface{}{
+			{
+				"instrument_name": "BTC-27DEC24-60000-C",
+				"quote_set_id":    "set1",
+				"bid":             map[string]interface{}{"price": 0.05, "amount": float64(1), "post_only": true},
+				"ask":             map[string]interface{}{"price": 0.06, "amount": float64(2)},
+			},
+			{
+				"instrument_name": "BTC-27DEC24-70000-C",
+				"ask":             map[string]interface{}{"price": 0.02, "amount": float64(1)},
+			},
+		},
+	}
+	assert.Equal(t, expected, p.params())
+}
+
+func TestCancelQuotesOptions(t *testing.T) {
+	var opts *CancelQuotesOptions
+	assert.Equal(t, map[string]interface{}{"cancel_type": "all"}, opts.params())
+
+	opts = &CancelQuotesOptions{Type: CancelQuotesByDelta, Currency: "BTC", MinDelta: -0.3, MaxDelta: 0.3}
+	expected := map[string]interface{}{
+		"cancel_type": "delta",
+		"currency":    "BTC",
+		"min_delta":   -0.3,
+		"max_delta":   0.3,
+	}
+	assert.Equal(t, expected, opts.params())
+
+	opts = &CancelQuotesOptions{Type: CancelQuotesByQuoteSetId, QuoteSetId: "set1"}
+	expected = map[string]interface{}{"cancel_type": "quote_set_id", "quote_set_id": "set1"}
+	assert.Equal(t, expected, opts.params())
+}
+
+func TestMmpConfigParams(t *testing.T) {
+	cfg := MmpConfig{IndexName: "btc_usd", MmpGroup: "group", Interval: 1, FrozenTime: 5, QuantityLimit: 10, DeltaLimit: 2}
+	expected := map[string]interface{}{
+		"index_name":     "btc_usd",
+		"mmp_group":      "group",
+		"interval":       1,
+		"frozen_time":    5,
+		"quantity_limit": float64(10),
+		"delta_limit":    float64(2),
+	}
+	assert.Equal(t, expected, cfg.params())
+}
+
+func TestParseMassQuoteResult(t *testing.T) {
+	input := `{
+		"orders": [{"order_id": "1", "instrument_name": "BTC-27DEC24-60000-C", "direction": "buy", "price": 0.05, "amount": 1, "order_state": "open"}],
+		"trades": [{"trade_id": "2", "instrument_name": "BTC-27DEC24-60000-C", "direction": "sell", "price": 0.06, "amount": 1}],
+		"errors": [
+			{"instrument_name": "BTC-27DEC24-70000-C", "side": "ask", "error": {"code": 10041, "message": "settlement_in_progress"}},
+			{"instrument_name": "BTC-27DEC24-80000-C", "side": "bid", "code": 10009, "message": "not_enough_funds"}
+		]
+	}`
+	res := parseMassQuoteResult(fastjson.MustParse(input))
+	assert.Len(t, res.Orders, 1)
+	assert.Equal(t, "1", res.Orders[0].OrderId)
+	assert.Len(t, res.Trades, 1)
+	assert.Equal(t, "2", res.Trades[0].TradeId)
+	assert.Equal(t, []QuoteError{
+		{Instrument: "BTC-27DEC24-70000-C", Side: Ask, Error: Error{Code: 10041, Message: "settlement_in_progress"}},
+		{Instrument: "BTC-27DEC24-80000-C", Side: Bid, Error: Error{Code: 10009, Message: "not_enough_funds"}},
+	}, res.Errors)
+	assert.Equal(t, 2, res.ErrorsCount)
+
+	// Without details, only the number of errors is returned.
+	res = parseMassQuoteResult(fastjson.MustParse(`{"errors_count": 3}`))
+	assert.Equal(t, 3, res.ErrorsCount)
+	assert.Empty(t, res.Errors)
+}
+
+func TestParseMmpTrigger(t *testing.T) {
+	v := fastjson.MustParse(`{"index_name": "btc_usd", "mmp_group": "group", "frozen_until": 1700000000000}`)
+	assert.Equal(t, MmpTrigger{IndexName: "btc_usd", MmpGroup: "group", FrozenUntil: 1700000000000}, parseMmpTrigger(v))
+}
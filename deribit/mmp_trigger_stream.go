@@ -0,0 +1,35 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+// MmpTriggerSub defines a subscription channel for a Market Maker Protection trigger
+// stream created using [NewMmpTriggerStream].
+type MmpTriggerSub struct {
+	// IndexName is the index to receive triggers for e.g. btc_usd, or "all".
+	IndexName string
+}
+
+func (s MmpTriggerSub) channel() string {
+	return fmt.Sprintf("user.mmp_trigger.%s", s.IndexName)
+}
+
+// NewMmpTriggerStream creates a new [Stream] which produces a message each time Market
+// Maker Protection triggers and the quotes of an MMP group are cancelled. Credentials are
+// required for this stream. For details see:
+//   - https://docs.deribit.com/#user-mmp_trigger-index_name
+func NewMmpTriggerStream(wsUrl string, c tk.Credentials, subscriptions ...MmpTriggerSub) Stream[MmpTrigger, MmpTriggerSub] {
+	p := streamParams[MmpTrigger, MmpTriggerSub]{
+		name:         "MmpTriggerStream",
+		wsUrl:        wsUrl,
+		isPrivate:    true,
+		parseMessage: parseMmpTrigger,
+		subs:         subscriptions,
+	}
+	s := newStream[MmpTrigger](p)
+	s.Params.Credentials = &c
+	return s
+}
@@ -0,0 +1,306 @@
+package deribit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+// subscribeBatchSize is the number of ticker channels subscribed to in each request.
+const subscribeBatchSize = 100
+
+// optionInstrumentsGetter is implemented by both the [Api] and [WsApi].
+type optionInstrumentsGetter interface {
+	GetOptionInstruments(ctx context.Context, currency string, expired bool) ([]Option, error)
+	GetOptionInstrument(ctx context.Context, name string) (Option, error)
+}
+
+// OptionChain maintains the live chain of options on a currency. It's bootstrapped with
+// the active options from the Api, and subscribes to the ticker of each option. Options
+// which are created later are fetched from the Api and added, and options which are
+// settled or closed are removed, using an instrument state stream. The chain is safe for
+// concurrent use.
+type OptionChain struct {
+	currency string
+	interval string
+	api      optionInstrumentsGetter
+	tickers  Stream[DeribitTicker, DeribitTickerSub]
+	states   Stream[InstrumentState, InstrumentStateSub]
+	errc     chan error
+
+	mu      sync.RWMutex
+	options map[string]*chainEntry
+}
+
+type chainEntry struct {
+	option Option
+	name   InstrumentName
+	ticker *DeribitTicker
+}
+
+// ChainSnapshot is a snapshot of an [OptionChain], grouped by expiry in ascending order.
+type ChainSnapshot struct {
+	Expiries []ChainExpiry
+}
+
+// ChainExpiry is the options of an expiry, grouped by strike in ascending order.
+type ChainExpiry struct {
+	Expiry time.Time
+	// Forward is the underlying price of the expiry's most recent ticker, which Deribit
+	// uses as the forward price to calculate mark prices. It's 0 if no tickers have been
+	// received for the expiry.
+	Forward float64
+	Strikes []ChainStrike
+}
+
+// ChainStrike is the call and put of a strike. Either may be nil if the strike only has
+// a call or put.
+type ChainStrike struct {
+	Strike float64
+	Call   *ChainOption
+	Put    *ChainOption
+}
+
+// ChainOption is an option and its latest ticker. The ticker is nil until the first
+// ticker for the option is received. If an option created after the chain was started
+// couldn't be fetched from the Api, its Option only has the details parsed from its name,
+// and fields such as TickSize, TickSizeSteps, ContractSize and MinTradeAmount are zero.
+type ChainOption struct {
+	Option Option
+	Ticker *DeribitTicker
+}
+
+// NewOptionChain creates a new OptionChain of the options on a currency. The api is
+// either an [Api] or [WsApi], and is used to get the options when the chain is started.
+// The chain's tickers are updated at 100ms intervals.
+func NewOptionChain(wsUrl string, api optionInstrumentsGetter, currency string, paramFuncs ...tk.Param) *OptionChain {
+	tickers := NewTickerStream(wsUrl, nil, paramFuncs...)
+	states := NewInstrumentStateStream(wsUrl, InstrumentStateSub{Kind: OptionInstrument, Currency: currency})
+	return newOptionChain(api, currency, tickers, states)
+}
+
+func newOptionChain(api optionInstrumentsGetter, currency string, tickers Stream[DeribitTicker, DeribitTickerSub], states Stream[InstrumentState, InstrumentStateSub]) *OptionChain {
+	return &OptionChain{
+		currency: currency,
+		interval: string(Update100ms),
+		api:      api,
+		tickers:  tickers,
+		states:   states,
+		errc:     make(chan error, 1),
+		options:  make(map[string]*chainEntry),
+	}
+}
+
+// Start bootstraps the chain and starts its streams. The chain is updated until the
+// context is done, or an error is produced on the Err channel.
+func (c *OptionChain) Start(ctx context.Context) error {
+	if err := c.states.Start(ctx); err != nil {
+		return c.err(err)
+	}
+	options, err := c.api.GetOptionInstruments(ctx, c.currency, false)
+	if err != nil {
+		return c.err(err)
+	}
+	if err := c.tickers.Start(ctx); err != nil {
+		return c.err(err)
+	}
+
+	c.mu.Lock()
+	var subs []DeribitTickerSub
+	for _, o := range options {
+		if c.add(o) {
+			subs = append(subs, c.tickerSub(o.Name))
+		}
+	}
+	c.mu.Unlock()
+	for _, batch := range chunk(subs, subscribeBatchSize) {
+		c.tickers.Subscribe(batch...)
+	}
+
+	go c.run(ctx)
+	return nil
+}
+
+// Err returns a channel which produces an error if either of the chain's streams fails.
+// The chain stops updating after an error.
+func (c *OptionChain) Err() <-chan error {
+	return c.errc
+}
+
+func (c *OptionChain) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t, ok := <-c.tickers.Messages():
+			if !ok {
+				return
+			}
+			c.updateTicker(t)
+		case s, ok := <-c.states.Messages():
+			if !ok {
+				return
+			}
+			c.applyState(ctx, s)
+		case err := <-c.tickers.Err():
+			c.sendErr(err)
+			return
+		case err := <-c.states.Err():
+			c.sendErr(err)
+			return
+		}
+	}
+}
+
+func (c *OptionChain) updateTicker(t DeribitTicker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.options[t.Instrument]; ok {
+		e.ticker = &t
+	}
+}
+
+func (c *OptionChain) applyState(ctx context.Context, s InstrumentState) {
+	switch s.State {
+	case "created", "started":
+		name, err := ParseInstrumentName(s.Instrument)
+		if err != nil || name.Kind != OptionInstrument {
+			return
+		}
+		c.mu.RLock()
+		_, ok := c.options[s.Instrument]
+		c.mu.RUnlock()
+		if ok {
+			return
+		}
+		o, err := c.api.GetOptionInstrument(ctx, s.Instrument)
+		if err != nil {
+			o = optionFromName(name)
+		}
+		c.mu.Lock()
+		added := c.add(o)
+		c.mu.Unlock()
+		if added {
+			c.tickers.Subscribe(c.tickerSub(s.Instrument))
+		}
+	case "settled", "closed", "deactivated", "terminated":
+		c.mu.Lock()
+		_, ok := c.options[s.Instrument]
+		delete(c.options, s.Instrument)
+		c.mu.Unlock()
+		if ok {
+			c.tickers.Unsubscribe(c.tickerSub(s.Instrument))
+		}
+	}
+}
+
+// add adds an option to the chain, returning false if it's already in the chain or its
+// name can't be parsed. The mutex must be held.
+func (c *OptionChain) add(o Option) bool {
+	if _, ok := c.options[o.Name]; ok {
+		return false
+	}
+	name, err := ParseInstrumentName(o.Name)
+	if err != nil || name.Kind != OptionInstrument {
+		return false
+	}
+	c.options[o.Name] = &chainEntry{option: o, name: name}
+	return true
+}
+
+func (c *OptionChain) tickerSub(instrument string) DeribitTickerSub {
+	return DeribitTickerSub{Instrument: instrument, Interval: c.interval}
+}
+
+// Snapshot returns a snapshot of the chain.
+func (c *OptionChain) Snapshot() ChainSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type expiryGroup struct {
+		expiry      ChainExpiry
+		strikes     map[float64]*ChainStrike
+		forwardTime int64
+	}
+	groups := make(map[int64]*expiryGroup)
+	for _, e := range c.options {
+		key := e.name.Expiry.UnixMilli()
+		g, ok := groups[key]
+		if !ok {
+			g = &expiryGroup{
+				expiry:  ChainExpiry{Expiry: e.name.Expiry},
+				strikes: make(map[float64]*ChainStrike),
+			}
+			groups[key] = g
+		}
+		s, ok := g.strikes[e.name.Strike]
+		if !ok {
+			s = &ChainStrike{Strike: e.name.Strike}
+			g.strikes[e.name.Strike] = s
+		}
+		opt := &ChainOption{Option: e.option}
+		if e.ticker != nil {
+			t := *e.ticker
+			opt.Ticker = &t
+			if t.UnderlyingPrice != nil && t.Timestamp >= g.forwardTime {
+				g.expiry.Forward = *t.UnderlyingPrice
+				g.forwardTime = t.Timestamp
+			}
+		}
+		if e.name.OptionType == CallOption {
+			s.Call = opt
+		} else {
+			s.Put = opt
+		}
+	}
+
+	snapshot := ChainSnapshot{Expiries: make([]ChainExpiry, 0, len(groups))}
+	for _, g := range groups {
+		for _, s := range g.strikes {
+			g.expiry.Strikes = append(g.expiry.Strikes, *s)
+		}
+		sort.Slice(g.expiry.Strikes, func(i, j int) bool {
+			return g.expiry.Strikes[i].Strike < g.expiry.Strikes[j].Strike
+		})
+		snapshot.Expiries = append(snapshot.Expiries, g.expiry)
+	}
+	sort.Slice(snapshot.Expiries, func(i, j int) bool {
+		return snapshot.Expiries[i].Expiry.Before(snapshot.Expiries[j].Expiry)
+	})
+	return snapshot
+}
+
+// Len returns the number of options in the chain.
+func (c *OptionChain) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.options)
+}
+
+func (c *OptionChain) sendErr(err error) {
+	select {
+	case c.errc <- c.err(err):
+	default:
+	}
+}
+
+func (c *OptionChain) err(err error) error {
+	return fmt.Errorf("deribit OptionChain: %w", err)
+}
+
+// optionFromName returns an option with the details which can be parsed from its name.
+func optionFromName(n InstrumentName) Option {
+	return Option{
+		Name:                n.String(),
+		Strike:              n.Strike,
+		OptionType:          string(n.OptionType),
+		ExpirationTimestamp: n.Expiry.UnixMilli(),
+		BaseCurrency:        n.Base,
+		SettlementCurrency:  n.SettlementCurrency(),
+		PriceIndex:          n.IndexName(),
+	}
+}
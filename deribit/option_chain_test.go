@@ -0,0 +1,139 @@
+package deribit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStream[T any, U subscription] struct {
+	msgs chan T
+	errc chan error
+
+	mu     sync.Mutex
+	subs   [][]U
+	unsubs []U
+}
+
+func newFakeStream[T any, U subscription]() *fakeStream[T, U] {
+	return &fakeStream[T, U]{msgs: make(chan T), errc: make(chan error, 1)}
+}
+
+func (s *fakeStream[T, U]) SetStreamOptions(*tradekit.StreamOptions) {}
+func (s *fakeStream[T, U]) SetCredentials(*tk.Credentials)           {}
+func (s *fakeStream[T, U]) SetAuthOptions(AuthOptions)               {}
+func (s *fakeStream[T, U]) Start(context.Context) error              { return nil }
+func (s *fakeStream[T, U]) Messages() <-chan T                       { return s.msgs }
+func (s *fakeStream[T, U]) Err() <-chan error                        { return s.errc }
+func (s *fakeStream[T, U]) PendingMessagesCount() int                { return len(s.msgs) }
+
+func (s *fakeStream[T, U]) Subscribe(subs ...U) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, subs)
+}
+
+func (s *fakeStream[T, U]) Unsubscribe(subs ...U) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unsubs = append(s.unsubs, subs...)
+}
+
+type fakeOptionsGetter []Option
+
+func (f fakeOptionsGetter) GetOptionInstruments(ctx context.Context, currency string, expired bool) ([]Option, error) {
+	return f, nil
+}
+
+// GetOptionInstrument returns an option with a tick size, unless its name ends in "-P".
+func (f fakeOptionsGetter) GetOptionInstrument(ctx context.Context, name string) (Option, error) {
+	if strings.HasSuffix(name, "-P") {
+		return Option{}, fmt.Errorf("instrument not found")
+	}
+	return Option{Name: name, OptionType: "call", TickSize: 0.0005, MinTradeAmount: 0.1}, nil
+}
+
+func TestOptionChain(t *testing.T) {
+	var options fakeOptionsGetter
+	for _, expiry := range []string{"27DEC24", "29NOV24"} {
+		for i := 0; i < 60; i++ {
+			for _, typ := range []string{"C", "P"} {
+				name := fmt.Sprintf("BTC-%s-%d-%s", expiry, 50000+i*1000, typ)
+				options = append(options, Option{Name: name})
+			}
+		}
+	}
+	tickers := newFakeStream[DeribitTicker, DeribitTickerSub]()
+	states := newFakeStream[InstrumentState, InstrumentStateSub]()
+	chain := newOptionChain(options, "BTC", tickers, states)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, chain.Start(ctx))
+	assert.Equal(t, 240, chain.Len())
+
+	// Tickers are subscribed in batches.
+	assert.Len(t, tickers.subs, 3)
+	assert.Len(t, tickers.subs[0], subscribeBatchSize)
+	assert.Len(t, tickers.subs[2], 40)
+	assert.Equal(t, "ticker.BTC-27DEC24-50000-C.100ms", tickers.subs[0][0].channel())
+
+	price := func(p float64) *float64 { return &p }
+	tickers.msgs <- DeribitTicker{Timestamp: 1, Instrument: "BTC-27DEC24-60000-C", MarkPrice: 0.05, UnderlyingPrice: price(61000)}
+	tickers.msgs <- DeribitTicker{Timestamp: 2, Instrument: "BTC-27DEC24-60000-P", MarkPrice: 0.04, UnderlyingPrice: price(61100)}
+	tickers.msgs <- DeribitTicker{Timestamp: 3, Instrument: "ETH-27DEC24-3000-P"}
+
+	states.msgs <- InstrumentState{Instrument: "BTC-27DEC24-200000-C", State: "created"}
+	states.msgs <- InstrumentState{Instrument: "BTC-27DEC24-200000-P", State: "created"}
+	states.msgs <- InstrumentState{Instrument: "BTC-29NOV24-50000-C", State: "settled"}
+	states.msgs <- InstrumentState{Instrument: "BTC-29NOV24-50000-C", State: "closed"}
+	// Send a final message to ensure the previous messages have been handled.
+	states.msgs <- InstrumentState{Instrument: "BTC-PERPETUAL", State: "started"}
+
+	assert.Equal(t, 241, chain.Len())
+	tickers.mu.Lock()
+	assert.Equal(t, []DeribitTickerSub{{Instrument: "BTC-27DEC24-200000-C", Interval: "100ms"}}, tickers.subs[3])
+	assert.Equal(t, []DeribitTickerSub{{Instrument: "BTC-27DEC24-200000-P", Interval: "100ms"}}, tickers.subs[4])
+	assert.Equal(t, []DeribitTickerSub{{Instrument: "BTC-29NOV24-50000-C", Interval: "100ms"}}, tickers.unsubs)
+	tickers.mu.Unlock()
+
+	snapshot := chain.Snapshot()
+	assert.Len(t, snapshot.Expiries, 2)
+	nov, dec := snapshot.Expiries[0], snapshot.Expiries[1]
+	assert.Equal(t, time.Date(2024, 11, 29, 8, 0, 0, 0, time.UTC), nov.Expiry)
+	assert.Len(t, nov.Strikes, 60)
+	assert.Nil(t, nov.Strikes[0].Call)
+	assert.Equal(t, "BTC-29NOV24-50000-P", nov.Strikes[0].Put.Option.Name)
+	assert.Equal(t, 0.0, nov.Forward)
+
+	assert.Equal(t, time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC), dec.Expiry)
+	assert.Len(t, dec.Strikes, 61)
+	assert.Equal(t, 61100.0, dec.Forward)
+	strike := dec.Strikes[10]
+	assert.Equal(t, 60000.0, strike.Strike)
+	assert.Equal(t, 0.05, strike.Call.Ticker.MarkPrice)
+	assert.Equal(t, 0.04, strike.Put.Ticker.MarkPrice)
+	assert.Nil(t, dec.Strikes[0].Call.Ticker)
+
+	added := dec.Strikes[60]
+	assert.Equal(t, 200000.0, added.Strike)
+	assert.Equal(t, "BTC-27DEC24-200000-C", added.Call.Option.Name)
+	assert.Equal(t, "call", added.Call.Option.OptionType)
+	assert.Equal(t, 0.0005, added.Call.Option.TickSize)
+	assert.Equal(t, 0.1, added.Call.Option.MinTradeAmount)
+	// The put couldn't be fetched, so it only has the details parsed from its name.
+	assert.Equal(t, "BTC-27DEC24-200000-P", added.Put.Option.Name)
+	assert.Equal(t, "put", added.Put.Option.OptionType)
+	assert.Equal(t, 0.0, added.Put.Option.TickSize)
+
+	// Stream errors are forwarded.
+	tickers.errc <- fmt.Errorf("connection lost")
+	assert.ErrorContains(t, <-chain.Err(), "connection lost")
+}
@@ -0,0 +1,50 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitPerpetualSub represents a subscription to a Deribit perpetual stream created using [NewPerpetualStream].
+// The interval is either "100ms" or "agg2". For details see:
+// https://docs.deribit.com/#perpetual-instrument_name-interval
+type DeribitPerpetualSub struct {
+	Instrument string
+	Interval   string
+}
+
+// DeribitPerpetual is the current interest rate of a perpetual, used to calculate its
+// funding.
+type DeribitPerpetual struct {
+	Timestamp  int64   `json:"timestamp" parquet:"name=timestamp, type=INT64"`
+	Interest   float64 `json:"interest" parquet:"name=interest, type=DOUBLE"`
+	IndexPrice float64 `json:"index_price" parquet:"name=index_price, type=DOUBLE"`
+}
+
+func (sub DeribitPerpetualSub) channel() string {
+	return fmt.Sprintf("perpetual.%s.%s", sub.Instrument, sub.Interval)
+}
+
+// NewPerpetualStream creates a new [Stream] which produces a stream of perpetual interest
+// rate updates. For details see: https://docs.deribit.com/#perpetual-instrument_name-interval
+func NewPerpetualStream(wsUrl string, subscriptions []DeribitPerpetualSub, paramFuncs ...tk.Param) Stream[DeribitPerpetual, DeribitPerpetualSub] {
+	p := streamParams[DeribitPerpetual, DeribitPerpetualSub]{
+		name:         "PerpetualStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: ParseDeribitPerpetual,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitPerpetual(v *fastjson.Value) DeribitPerpetual {
+	return DeribitPerpetual{
+		Timestamp:  v.GetInt64("timestamp"),
+		Interest:   v.GetFloat64("interest"),
+		IndexPrice: v.GetFloat64("index_price"),
+	}
+}
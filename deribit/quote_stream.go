@@ -0,0 +1,53 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitQuoteSub represents a subscription to a Deribit quote stream created using [NewQuoteStream].
+// For details see: https://docs.deribit.com/#quote-instrument_name
+type DeribitQuoteSub struct {
+	Instrument string
+}
+
+// DeribitQuote is the best bid and ask of an instrument.
+type DeribitQuote struct {
+	Timestamp     int64   `json:"timestamp" parquet:"name=timestamp, type=INT64"`
+	Instrument    string  `json:"instrument_name" parquet:"name=instrument, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BestBidPrice  float64 `json:"best_bid_price" parquet:"name=best_bid_price, type=DOUBLE"`
+	BestBidAmount float64 `json:"best_bid_amount" parquet:"name=best_bid_amount, type=DOUBLE"`
+	BestAskPrice  float64 `json:"best_ask_price" parquet:"name=best_ask_price, type=DOUBLE"`
+	BestAskAmount float64 `json:"best_ask_amount" parquet:"name=best_ask_amount, type=DOUBLE"`
+}
+
+func (sub DeribitQuoteSub) channel() string {
+	return fmt.Sprintf("quote.%s", sub.Instrument)
+}
+
+// NewQuoteStream creates a new [Stream] which produces a stream of best bid and ask
+// updates. For details see: https://docs.deribit.com/#quote-instrument_name
+func NewQuoteStream(wsUrl string, subscriptions []DeribitQuoteSub, paramFuncs ...tk.Param) Stream[DeribitQuote, DeribitQuoteSub] {
+	p := streamParams[DeribitQuote, DeribitQuoteSub]{
+		name:         "QuoteStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: ParseDeribitQuote,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitQuote(v *fastjson.Value) DeribitQuote {
+	return DeribitQuote{
+		Timestamp:     v.GetInt64("timestamp"),
+		Instrument:    string(v.GetStringBytes("instrument_name")),
+		BestBidPrice:  v.GetFloat64("best_bid_price"),
+		BestBidAmount: v.GetFloat64("best_bid_amount"),
+		BestAskPrice:  v.GetFloat64("best_ask_price"),
+		BestAskAmount: v.GetFloat64("best_ask_amount"),
+	}
+}
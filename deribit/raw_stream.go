@@ -0,0 +1,91 @@
+package deribit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// RawSub is a subscription to a Deribit channel by its name e.g. "ticker.BTC-PERPETUAL.100ms".
+// It's used with streams created by [NewRawStream] and [NewRawValueStream].
+type RawSub string
+
+func (s RawSub) channel() string {
+	return string(s)
+}
+
+// RawMessage is a message produced by a stream created with [NewRawStream].
+type RawMessage struct {
+	Channel string
+	// Data is the JSON of the message's "params.data" field.
+	Data json.RawMessage
+}
+
+// RawValue is a message produced by a stream created with [NewRawValueStream].
+type RawValue struct {
+	Channel string
+	// Data is the parsed "params.data" field of the message. It isn't copied: it's the
+	// stream's own value, which is only valid until Release is called.
+	Data    *fastjson.Value
+	release chan struct{}
+}
+
+// Release must be called once, when the message's Data is no longer used. The stream
+// doesn't read its next message until then, or until its context is done. While a value
+// is held, the stream's connection also doesn't answer heartbeats, refresh its auth or
+// change its subscriptions, so a value held for longer than the heartbeat interval
+// makes the stream reconnect.
+func (v RawValue) Release() {
+	close(v.release)
+}
+
+// NewRawStream creates a new [Stream] which produces the unparsed data of messages from
+// any Deribit channels. It's useful for channels which don't have a typed stream. Private
+// channels require credentials set with SetCredentials. An authenticated stream makes
+// all of its subscriptions with private/subscribe. For details see:
+//   - https://docs.deribit.com/#subscriptions
+func NewRawStream(wsUrl string, channels ...string) Stream[RawMessage, RawSub] {
+	p := streamParams[RawMessage, RawSub]{
+		name:  "RawStream",
+		wsUrl: wsUrl,
+		parseChannelMessage: func(channel string, v *fastjson.Value) RawMessage {
+			return RawMessage{Channel: channel, Data: v.MarshalTo(nil)}
+		},
+		subs:   rawSubs(channels),
+		Params: tk.DefaultParams(),
+	}
+	return newStream(p)
+}
+
+// NewRawValueStream creates a new [Stream] like [NewRawStream], except that it produces
+// the data of messages as a *fastjson.Value, without copying it. As the stream's parser
+// reuses its values for the next message, each message must be released with
+// RawValue.Release before the stream continues.
+func NewRawValueStream(wsUrl string, channels ...string) Stream[RawValue, RawSub] {
+	p := streamParams[RawValue, RawSub]{
+		name:  "RawValueStream",
+		wsUrl: wsUrl,
+		parseChannelMessage: func(channel string, v *fastjson.Value) RawValue {
+			return RawValue{Channel: channel, Data: v, release: make(chan struct{})}
+		},
+		awaitRelease: func(ctx context.Context, v RawValue) {
+			select {
+			case <-v.release:
+			case <-ctx.Done():
+			}
+		},
+		subs:   rawSubs(channels),
+		Params: tk.DefaultParams(),
+	}
+	return newStream(p)
+}
+
+func rawSubs(channels []string) []RawSub {
+	subs := make([]RawSub, len(channels))
+	for i, c := range channels {
+		subs[i] = RawSub(c)
+	}
+	return subs
+}
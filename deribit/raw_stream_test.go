@@ -0,0 +1,137 @@
+package deribit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestStreamServer starts a websocket server which sends a notification with the
+// given data on each channel subscribed to. The server sends a heartbeat test_request
+// after a heartbeat is set, and records the methods requested in methods.
+func newTestStreamServer(t *testing.T, data string, methods chan<- string) *httptest.Server {
+	var upgrader websocket.Upgrader
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req struct {
+				Id     int64                  `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			methods <- req.Method
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": "ok"}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+			switch req.Method {
+			case "public/set_heartbeat":
+				msg := `{"jsonrpc":"2.0","method":"heartbeat","params":{"type":"test_request"}}`
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+					return
+				}
+			case "public/subscribe":
+				for _, c := range req.Params["channels"].([]interface{}) {
+					msg := `{"jsonrpc":"2.0","method":"subscription","params":{"channel":"` + c.(string) + `","data":` + data + `}}`
+					if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+						return
+					}
+				}
+			}
+		}
+	}))
+}
+
+func TestRawStream(t *testing.T) {
+	methods := make(chan string, 10)
+	server := newTestStreamServer(t, `{"best_bid_price":100.5}`, methods)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	stream := NewRawStream(wsUrl, "ticker.BTC-PERPETUAL.100ms")
+	assert.Nil(t, stream.Start(ctx))
+
+	select {
+	case msg := <-stream.Messages():
+		assert.Equal(t, "ticker.BTC-PERPETUAL.100ms", msg.Channel)
+		assert.JSONEq(t, `{"best_bid_price":100.5}`, string(msg.Data))
+	case err := <-stream.Err():
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("expected a message")
+	}
+
+	// The stream answers the heartbeat test_request.
+	expected := []string{"public/set_heartbeat", "public/subscribe", "public/test"}
+	var received []string
+	for len(received) < len(expected) {
+		select {
+		case m := <-methods:
+			received = append(received, m)
+		case <-time.After(time.Second):
+			t.Fatalf("expected methods %v, received %v", expected, received)
+		}
+	}
+	assert.ElementsMatch(t, expected, received)
+}
+
+func TestRawValueStream(t *testing.T) {
+	server := newTestStreamServer(t, `{"index_name":"btc_usd","price":50000}`, make(chan string, 10))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	stream := NewRawValueStream(wsUrl, "deribit_price_index.btc_usd", "deribit_price_index.eth_usd")
+	assert.Nil(t, stream.Start(ctx))
+
+	for i := 0; i < 2; i++ {
+		var v RawValue
+		select {
+		case v = <-stream.Messages():
+		case <-time.After(time.Second):
+			t.Fatal("expected a message")
+		}
+		// The stream waits for the value to be released before reading the next message.
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 0, len(stream.Messages()))
+		assert.True(t, strings.HasPrefix(v.Channel, "deribit_price_index."))
+		assert.Equal(t, "btc_usd", string(v.Data.GetStringBytes("index_name")))
+		assert.Equal(t, float64(50000), v.Data.GetFloat64("price"))
+		v.Release()
+	}
+}
+
+func TestRawValueStreamContext(t *testing.T) {
+	// The stream stops waiting for a value which is never released when its context is
+	// done.
+	s := NewRawValueStream("wss://example.com").(*stream[RawValue, RawSub])
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan struct{})
+	go func() {
+		s.awaitRelease(ctx, RawValue{release: make(chan struct{})})
+		close(released)
+	}()
+	cancel()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to stop waiting")
+	}
+}
@@ -13,6 +13,7 @@ const (
 	methodPublicSubscribe                        rpcMethod = "public/subscribe"
 	methodPublicUnsubscribe                      rpcMethod = "public/unsubscribe"
 	methodPublicGetInstruments                   rpcMethod = "public/get_instruments"
+	methodPublicGetInstrument                    rpcMethod = "public/get_instrument"
 	methodPublicGetCurrencies                    rpcMethod = "public/get_currencies"
 	methodPublicGetLastTradesByCurrency          rpcMethod = "public/get_last_trades_by_currency"
 	methodPublicGetLastTradesByCurrencyAndTime   rpcMethod = "public/get_last_trades_by_currency_and_time"
@@ -21,6 +22,14 @@ const (
 	methodPublicGetIndexPrice                    rpcMethod = "public/get_index_price"
 	methodPublicGetDeliveryPrices                rpcMethod = "public/get_delivery_prices"
 	methodPublicGetBookSummaryByCurrency         rpcMethod = "public/get_book_summary_by_currency"
+	methodPublicSetHeartbeat                     rpcMethod = "public/set_heartbeat"
+	methodPublicTest                             rpcMethod = "public/test"
+	methodPublicGetCombos                        rpcMethod = "public/get_combos"
+	methodPublicGetTradingviewChartData          rpcMethod = "public/get_tradingview_chart_data"
+	methodPublicGetFundingRateHistory            rpcMethod = "public/get_funding_rate_history"
+	methodPublicGetFundingChartData              rpcMethod = "public/get_funding_chart_data"
+	methodPublicGetHistoricalVolatility          rpcMethod = "public/get_historical_volatility"
+	methodPublicGetVolatilityIndexData           rpcMethod = "public/get_volatility_index_data"
 	// private methods
 	methodPrivateSubscribe           rpcMethod = "private/subscribe"
 	methodPrivateUnsubscribe         rpcMethod = "private/unsubscribe"
@@ -35,6 +44,27 @@ const (
 	methodPrivateClosePosition       rpcMethod = "private/close_position"
 	methodPrivateGetPositions        rpcMethod = "private/get_positions"
 	methodPrivateGetPosition         rpcMethod = "private/get_position"
+
+	methodPrivateEnableCancelOnDisconnect rpcMethod = "private/enable_cancel_on_disconnect"
+	methodPrivateMassQuote                rpcMethod = "private/mass_quote"
+	methodPrivateCancelQuotes             rpcMethod = "private/cancel_quotes"
+	methodPrivateSetMmpConfig             rpcMethod = "private/set_mmp_config"
+
+	methodPrivateGetOpenOrders               rpcMethod = "private/get_open_orders"
+	methodPrivateGetOpenOrdersByCurrency     rpcMethod = "private/get_open_orders_by_currency"
+	methodPrivateGetOpenOrdersByInstrument   rpcMethod = "private/get_open_orders_by_instrument"
+	methodPrivateGetOpenOrdersByLabel        rpcMethod = "private/get_open_orders_by_label"
+	methodPrivateGetOrderState               rpcMethod = "private/get_order_state"
+	methodPrivateGetOrderHistoryByCurrency   rpcMethod = "private/get_order_history_by_currency"
+	methodPrivateGetOrderHistoryByInstrument rpcMethod = "private/get_order_history_by_instrument"
+	methodPrivateGetUserTradesByCurrency     rpcMethod = "private/get_user_trades_by_currency"
+	methodPrivateGetUserTradesByInstrument   rpcMethod = "private/get_user_trades_by_instrument"
+	methodPrivateGetUserTradesByOrder        rpcMethod = "private/get_user_trades_by_order"
+	methodPrivateGetAccountSummary           rpcMethod = "private/get_account_summary"
+	methodPrivateGetMargins                  rpcMethod = "private/get_margins"
+	methodPrivateCreateCombo                 rpcMethod = "private/create_combo"
+
+	methodPrivateGetSettlementHistoryByInstrument rpcMethod = "private/get_settlement_history_by_instrument"
 )
 
 // rpcRequestMsg creates a new request JSON-RPC request
@@ -60,6 +60,13 @@ type Order struct {
 	Triggered           bool        `json:"triggered"`
 	Trigger             string      `json:"trigger"`
 	TriggerPrice        float64     `json:"trigger_price"`
+	// Linked order fields. For details see: https://docs.deribit.com/#private-buy
+	OcoRef               string   `json:"oco_ref"`
+	PrimaryOrderId       string   `json:"primary_order_id"`
+	OtoOrderIds          []string `json:"oto_order_ids"`
+	IsPrimaryOtoco       bool     `json:"is_primary_otoco"`
+	IsSecondaryOto       bool     `json:"is_secondary_oto"`
+	TriggerFillCondition string   `json:"trigger_fill_condition"`
 }
 
 func parseOrderUpdate(v *fastjson.Value) OrderUpdate {
@@ -117,5 +124,24 @@ func parseOrder(v *fastjson.Value) Order {
 		Triggered:           v.GetBool("triggered"),
 		Trigger:             string(v.GetStringBytes("trigger")),
 		TriggerPrice:        v.GetFloat64("trigger_price"),
+
+		OcoRef:               string(v.GetStringBytes("oco_ref")),
+		PrimaryOrderId:       string(v.GetStringBytes("primary_order_id")),
+		OtoOrderIds:          parseStrings(v.GetArray("oto_order_ids")),
+		IsPrimaryOtoco:       v.GetBool("is_primary_otoco"),
+		IsSecondaryOto:       v.GetBool("is_secondary_oto"),
+		TriggerFillCondition: string(v.GetStringBytes("trigger_fill_condition")),
+	}
+}
+
+// parseStrings returns the strings of a JSON array, or nil if it's empty.
+func parseStrings(items []*fastjson.Value) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	res := make([]string, len(items))
+	for i, item := range items {
+		res[i] = string(item.GetStringBytes())
 	}
+	return res
 }
@@ -24,10 +24,20 @@ import (
 //   - [NewOrderbookStream]
 //   - [NewOrderbookDepthStream]
 //   - [NewInstrumentStateStream]
+//   - [NewQuoteStream]
+//   - [NewIncrementalTickerStream]
+//   - [NewPerpetualStream]
+//   - [NewChartTradesStream]
+//   - [NewMarkPriceOptionsStream]
+//   - [NewVolatilityIndexStream]
+//   - [NewEstimatedExpirationPriceStream]
+//   - [NewRawStream] and [NewRawValueStream], for any channel
 //
 // The following functions create Streams to private user channels:
 //   - [NewUserTradesStream]
 //   - [NewUserOrdersStream]
+//   - [NewUserChangesStream]
+//   - [NewMmpTriggerStream]
 type Stream[T any, U subscription] interface {
 	// SetStreamOptions sets optional parameters for the stream. If used, it should be
 	// called before Start.
@@ -37,6 +47,10 @@ type Stream[T any, U subscription] interface {
 	// documentation to see if authentication is required for a particular stream.
 	SetCredentials(*tk.Credentials)
 
+	// SetAuthOptions sets optional parameters for authenticating with the stream's
+	// credentials. If used, it should be called before Start.
+	SetAuthOptions(AuthOptions)
+
 	// Start the stream. The stream must be started before any messages will be received
 	// or any new subscriptions may be made.
 	Start(context.Context) error
@@ -75,6 +89,16 @@ type stream[T any, U subscription] struct {
 	subscriptions set.Set[string]
 	opts          *tradekit.StreamOptions
 	credentials   *tk.Credentials
+	authOpts      AuthOptions
+	auth          *authSession
+	heartbeat     *heartbeat
+
+	// parseChannelMessage, if set, is used instead of parseMessage for streams which need
+	// the channel of each message.
+	parseChannelMessage func(channel string, v *fastjson.Value) T
+	// awaitRelease, if set, is called after a message is sent, and blocks until the
+	// message no longer refers to the stream's parser or the context is done.
+	awaitRelease func(context.Context, T)
 
 	subRequests          chan []U
 	unsubRequests        chan []U
@@ -90,11 +114,13 @@ type subscription interface {
 }
 
 type streamParams[T any, U subscription] struct {
-	name         string
-	wsUrl        string
-	isPrivate    bool
-	parseMessage func(*fastjson.Value) T
-	subs         []U
+	name                string
+	wsUrl               string
+	isPrivate           bool
+	parseMessage        func(*fastjson.Value) T
+	parseChannelMessage func(channel string, v *fastjson.Value) T
+	awaitRelease        func(context.Context, T)
+	subs                []U
 	*tk.Params
 }
 
@@ -121,11 +147,14 @@ func newStream[T any, U subscription](p streamParams[T, U]) *stream[T, U] {
 		msgs:                 make(chan T, p.ChannelBufferSize),
 		errc:                 make(chan error, 1),
 		parseMessage:         p.parseMessage,
+		parseChannelMessage:  p.parseChannelMessage,
+		awaitRelease:         p.awaitRelease,
 		subscriptions:        set.New[string](channels...),
 		isPrivate:            p.isPrivate,
 		subRequests:          make(chan []U, 10),
 		unsubRequests:        make(chan []U, 10),
 		subscribeAllRequests: make(chan struct{}, 10),
+		heartbeat:            newHeartbeat(),
 		Params:               p.Params,
 	}
 }
@@ -138,7 +167,15 @@ func (s *stream[T, U]) SetCredentials(c *tk.Credentials) {
 	s.Params.Credentials = c
 }
 
+func (s *stream[T, U]) SetAuthOptions(opts AuthOptions) {
+	s.authOpts = opts
+}
+
 func (s *stream[T, U]) Start(ctx context.Context) error {
+	if s.Params.Credentials != nil {
+		s.auth = newAuthSession(s.Params.ClientId, s.Params.ClientSecret, s.authOpts)
+	}
+
 	// Create a channel to signal stream restart
 	restartChan := make(chan struct{}, 1)
 
@@ -150,6 +187,9 @@ func (s *stream[T, U]) Start(ctx context.Context) error {
 			close(s.subRequests)
 			close(s.unsubRequests)
 			close(s.subscribeAllRequests)
+			if s.auth != nil {
+				s.auth.stop()
+			}
 		}()
 		for {
 			select {
@@ -187,24 +227,32 @@ func (s *stream[T, U]) startWebsocketStream(
 	ws := websocket.New(s.url, s.opts)
 
 	ws.OnConnect = func() error {
-		if s.Params.Credentials != nil {
+		if s.auth != nil {
 			if s.closed.Load() {
 				return nil
 			}
-			// we don't need to wait for response, just send the auth request
-			// since handleMessage skips all incoming messages without a method field
-			_, err := s.sendAuthRequest(&ws)
+			// We don't need to wait for the response. It's handled by handleMessage, which
+			// reports an auth failure on the stream's error channel.
+			_, msg, err := s.auth.authRequest()
 			if err != nil {
 				return fmt.Errorf("auth failure: %w", err)
 			}
+			ws.Send(msg)
 		}
+		_, msg, err := s.heartbeat.setRequest()
+		if err != nil {
+			return fmt.Errorf("set heartbeat: %w", err)
+		}
+		ws.Send(msg)
 
 		s.subscribeAllRequests <- struct{}{}
 		return nil
 	}
 
 	go func() {
+		heartbeatTicker := time.NewTicker(s.heartbeat.interval)
 		defer func() {
+			heartbeatTicker.Stop()
 			ws.Close()
 		}()
 		for {
@@ -212,7 +260,7 @@ func (s *stream[T, U]) startWebsocketStream(
 			case <-ctx.Done():
 				return
 			case msg := <-ws.Messages():
-				if err := s.handleMessage(msg); err != nil {
+				if err := s.handleMessage(ctx, &ws, msg); err != nil {
 					s.errc <- s.nameErr(err)
 					return
 				}
@@ -226,11 +274,27 @@ func (s *stream[T, U]) startWebsocketStream(
 					s.errc <- s.nameErr(err)
 					return
 				}
+			case <-s.authRefreshes():
+				_, msg, err := s.auth.refreshRequest()
+				if err != nil {
+					s.errc <- s.nameErr(fmt.Errorf("auth refresh: %w", err))
+					return
+				}
+				ws.Send(msg)
 			case <-s.subscribeAllRequests:
 				if err := s.subscribeAll(&ws); err != nil {
 					s.errc <- s.nameErr(err)
 					return
 				}
+			case <-heartbeatTicker.C:
+				if s.heartbeat.missed() {
+					s.Logger.Error(s.namePrefix("missed heartbeat"))
+					select {
+					case restartChan <- struct{}{}:
+					default:
+					}
+					return
+				}
 			case err := <-ws.Err():
 				// Check if the error requires a reconnection attempt
 				if s.shouldReconnect(err) {
@@ -269,17 +333,33 @@ func (s *stream[T, U]) shouldReconnect(err error) bool {
 	return false
 }
 
-func (s *stream[T, U]) handleMessage(msg websocket.Message) error {
+func (s *stream[T, U]) handleMessage(ctx context.Context, ws *websocket.Websocket, msg websocket.Message) error {
 	defer msg.Release()
 
 	v, err := s.p.ParseBytes(msg.Data())
 	if err != nil {
 		return fmt.Errorf("invalid message: %s", string(msg.Data()))
 	}
+	s.heartbeat.received()
+
+	if isHeartbeat(v) {
+		_, reply, err := s.heartbeat.reply(v)
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			ws.Send(reply)
+		}
+		return nil
+	}
 
 	method := v.GetStringBytes("method")
 	if method == nil {
-		// This might be a subscribe/unsubscribe response or some other non-method message.
+		// This might be an auth or subscribe/unsubscribe response or some other non-method
+		// message.
+		if s.auth != nil && s.auth.isResponse(v.GetInt64("id")) {
+			return s.auth.handleResponse(v)
+		}
 		if v.Get("error") != nil {
 			return fmt.Errorf("received error response: %s", string(msg.Data()))
 		}
@@ -303,7 +383,15 @@ func (s *stream[T, U]) handleMessage(msg websocket.Message) error {
 	if data == nil {
 		return fmt.Errorf(`field "params.data" is missing: %s`, string(msg.Data()))
 	}
-	s.msgs <- s.parseMessage(data)
+	if s.parseChannelMessage != nil {
+		m := s.parseChannelMessage(channel, data)
+		s.msgs <- m
+		if s.awaitRelease != nil {
+			s.awaitRelease(ctx, m)
+		}
+	} else {
+		s.msgs <- s.parseMessage(data)
+	}
 	return nil
 }
 
@@ -317,7 +405,7 @@ func (s *stream[T, U]) subscribeAll(ws *websocket.Websocket) error {
 	}
 
 	var method rpcMethod
-	if s.isPrivate {
+	if s.private() {
 		method = methodPrivateSubscribe
 	} else {
 		method = methodPublicSubscribe
@@ -372,7 +460,7 @@ func (s *stream[T, U]) subscribe(ws *websocket.Websocket, subs ...U) error {
 	}
 
 	var method rpcMethod
-	if s.isPrivate {
+	if s.private() {
 		method = methodPrivateSubscribe
 	} else {
 		method = methodPublicSubscribe
@@ -412,7 +500,7 @@ func (s *stream[T, U]) unsubscribe(ws *websocket.Websocket, subs ...U) error {
 	}
 
 	var method rpcMethod
-	if s.isPrivate {
+	if s.private() {
 		method = methodPrivateUnsubscribe
 	} else {
 		method = methodPublicUnsubscribe
@@ -433,6 +521,12 @@ func (s *stream[T, U]) unsubscribe(ws *websocket.Websocket, subs ...U) error {
 	return nil
 }
 
+// private returns true if the stream's subscriptions are made with the private methods.
+// This is the case for streams of private channels, and for any authenticated stream.
+func (s *stream[T, U]) private() bool {
+	return s.isPrivate || s.auth != nil
+}
+
 func (s *stream[T, U]) Err() <-chan error {
 	return s.errc
 }
@@ -453,23 +547,28 @@ func (s *stream[T, U]) namePrefix(msg string) string {
 	return fmt.Sprintf("deribit %s: %s", s.name, msg)
 }
 
-// sendAuthRequest sends auth request along the stream's websocket
-func (s *stream[T, U]) sendAuthRequest(ws *websocket.Websocket) (id int64, err error) {
-	id = genId()
-	method := methodPublicAuth
-	params := map[string]string{
-		"grant_type":    "client_credentials",
-		"client_id":     s.Params.ClientId,
-		"client_secret": s.Params.ClientSecret,
-	}
-	msg, err := rpcRequestMsg(method, id, params)
-	if err != nil {
-		return
+// authRefreshes returns a channel which is signalled when the stream's access token
+// should be refreshed. The channel is nil if the stream doesn't authenticate.
+func (s *stream[T, U]) authRefreshes() <-chan struct{} {
+	if s.auth == nil {
+		return nil
 	}
-	ws.Send(msg)
-	return
+	return s.auth.refreshRequests()
 }
 
+var lastId atomic.Int64
+
+// genId returns a unique, increasing request id. Ids must be unique because requests
+// may be sent concurrently on the same connection.
 func genId() int64 {
-	return time.Now().UnixNano()
+	for {
+		last := lastId.Load()
+		id := time.Now().UnixNano()
+		if id <= last {
+			id = last + 1
+		}
+		if lastId.CompareAndSwap(last, id) {
+			return id
+		}
+	}
 }
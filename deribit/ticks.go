@@ -0,0 +1,135 @@
+package deribit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode specifies the direction in which a price or amount is rounded.
+type RoundingMode int
+
+const (
+	RoundNearest RoundingMode = iota
+	RoundDown
+	RoundUp
+)
+
+// TickSizeAt returns the tick size at a price. Instruments, such as options, which have
+// tick size steps use the tick size of the highest step below the price, and otherwise
+// the base tick size.
+func TickSizeAt(price float64, tickSize float64, steps []TickSizeStep) float64 {
+	tick := tickSize
+	above := math.Inf(-1)
+	for _, step := range steps {
+		if math.Abs(price) > step.AbovePrice && step.AbovePrice > above {
+			tick = step.TickSize
+			above = step.AbovePrice
+		}
+	}
+	return tick
+}
+
+// RoundPrice rounds a price to a multiple of its tick size, taking into account the tick
+// size steps.
+func RoundPrice(price float64, tickSize float64, steps []TickSizeStep, mode RoundingMode) float64 {
+	rounded := roundToStep(price, TickSizeAt(price, tickSize, steps), mode)
+	// Rounding up may cross into a step with a larger tick size.
+	if tick := TickSizeAt(rounded, tickSize, steps); !isMultiple(rounded, tick) {
+		rounded = roundToStep(rounded, tick, mode)
+	}
+	return rounded
+}
+
+// IsValidPrice returns true if a price is a multiple of its tick size, taking into
+// account the tick size steps.
+func IsValidPrice(price float64, tickSize float64, steps []TickSizeStep) bool {
+	return isMultiple(price, TickSizeAt(price, tickSize, steps))
+}
+
+// RoundAmount rounds an amount to a multiple of the step, which is usually an
+// instrument's minimum trade amount.
+func RoundAmount(amount float64, step float64, mode RoundingMode) float64 {
+	return roundToStep(amount, step, mode)
+}
+
+// RoundPrice rounds a price to a valid tick of the instrument.
+func (i Instrument) RoundPrice(price float64, mode RoundingMode) float64 {
+	return RoundPrice(price, i.TickSize, i.TickSizeSteps, mode)
+}
+
+// IsValidPrice returns true if a price is a valid tick of the instrument.
+func (i Instrument) IsValidPrice(price float64) bool {
+	return IsValidPrice(price, i.TickSize, i.TickSizeSteps)
+}
+
+// RoundAmount rounds an amount to a multiple of the instrument's minimum trade amount, or
+// its contract size if it has no minimum trade amount.
+func (i Instrument) RoundAmount(amount float64, mode RoundingMode) float64 {
+	return RoundAmount(amount, amountStep(i.MinTradeAmount, i.ContractSize), mode)
+}
+
+// RoundPrice rounds a price to a valid tick of the option.
+func (o Option) RoundPrice(price float64, mode RoundingMode) float64 {
+	return RoundPrice(price, o.TickSize, o.TickSizeSteps, mode)
+}
+
+// IsValidPrice returns true if a price is a valid tick of the option.
+func (o Option) IsValidPrice(price float64) bool {
+	return IsValidPrice(price, o.TickSize, o.TickSizeSteps)
+}
+
+// RoundAmount rounds an amount to a multiple of the option's minimum trade amount, or its
+// contract size if it has no minimum trade amount.
+func (o Option) RoundAmount(amount float64, mode RoundingMode) float64 {
+	return RoundAmount(amount, amountStep(o.MinTradeAmount, o.ContractSize), mode)
+}
+
+func amountStep(minTradeAmount, contractSize float64) float64 {
+	if minTradeAmount != 0 {
+		return minTradeAmount
+	}
+	return contractSize
+}
+
+// roundToStep rounds x to a multiple of step. The result is rounded to the number of
+// decimals in the step so that it doesn't carry floating point error, such as
+// 0.30000000000000004.
+func roundToStep(x float64, step float64, mode RoundingMode) float64 {
+	if step <= 0 {
+		return x
+	}
+	n := x / step
+	if isMultiple(x, step) {
+		// Remove floating point error which would otherwise move an exact multiple to the
+		// next step.
+		n = math.Round(n)
+	}
+	switch mode {
+	case RoundDown:
+		n = math.Floor(n)
+	case RoundUp:
+		n = math.Ceil(n)
+	default:
+		n = math.Round(n)
+	}
+	scale := math.Pow(10, float64(decimals(step)))
+	return math.Round(n*step*scale) / scale
+}
+
+func isMultiple(x float64, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	n := x / step
+	return math.Abs(n-math.Round(n)) < 1e-9
+}
+
+// decimals returns the number of decimal places of x.
+func decimals(x float64) int {
+	s := strconv.FormatFloat(x, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
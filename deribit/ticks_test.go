@@ -0,0 +1,88 @@
+package deribit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundPrice(t *testing.T) {
+	// The tick size steps of a BTC option.
+	steps := []TickSizeStep{{AbovePrice: 0.005, TickSize: 0.0005}}
+	tick := 0.0001
+
+	assert.Equal(t, 0.0001, TickSizeAt(0.0042, tick, steps))
+	assert.Equal(t, 0.0005, TickSizeAt(0.0123, tick, steps))
+
+	tests := []struct {
+		price    float64
+		mode     RoundingMode
+		expected float64
+	}{
+		{0.00423, RoundNearest, 0.0042},
+		{0.00423, RoundUp, 0.0043},
+		{0.00427, RoundDown, 0.0042},
+		{0.0123, RoundNearest, 0.0125},
+		{0.0123, RoundDown, 0.012},
+		{0.0121, RoundUp, 0.0125},
+		{0.0125, RoundUp, 0.0125},
+		// Rounding up crosses into the step with the larger tick size.
+		{0.00499, RoundUp, 0.005},
+		{0.00501, RoundUp, 0.0055},
+		{0.3, RoundDown, 0.3},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, RoundPrice(test.price, tick, steps, test.mode), test.price)
+	}
+
+	assert.True(t, IsValidPrice(0.0042, tick, steps))
+	assert.True(t, IsValidPrice(0.0125, tick, steps))
+	assert.False(t, IsValidPrice(0.0123, tick, steps))
+	assert.False(t, IsValidPrice(0.00425, tick, steps))
+
+	// Futures without steps.
+	perp := Instrument{TickSize: 0.5, MinTradeAmount: 10, ContractSize: 10}
+	assert.Equal(t, 50000.5, perp.RoundPrice(50000.3, RoundNearest))
+	assert.Equal(t, -12.5, perp.RoundPrice(-12.3, RoundDown))
+	assert.True(t, perp.IsValidPrice(50000.5))
+	assert.Equal(t, float64(120), perp.RoundAmount(123, RoundNearest))
+	assert.Equal(t, float64(130), perp.RoundAmount(123, RoundUp))
+
+	opt := Option{TickSize: tick, TickSizeSteps: steps, MinTradeAmount: 0.1, ContractSize: 1}
+	assert.Equal(t, 0.0125, opt.RoundPrice(0.0123, RoundNearest))
+	assert.Equal(t, 0.7, opt.RoundAmount(0.74, RoundDown))
+	assert.Equal(t, 0.3, opt.RoundAmount(0.1+0.2, RoundDown))
+}
+
+func TestExecutorPriceCheck(t *testing.T) {
+	registry := NewInstrumentRegistry()
+	registry.Add(Instrument{
+		InstrumentName: "BTC-27DEC24-60000-C",
+		TickSize:       0.0001,
+		TickSizeSteps:  []TickSizeStep{{AbovePrice: 0.005, TickSize: 0.0005}},
+	})
+	ex := &liveTradeExecutor{}
+	WithPriceCheck(registry, RoundPrices)(ex)
+
+	opts := &OrderOptions{Price: 0.0123}
+	rounded, err := ex.checkPrice("BTC-27DEC24-60000-C", opts, RoundDown)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.012, rounded.Price)
+	rounded, err = ex.checkPrice("BTC-27DEC24-60000-C", opts, RoundUp)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0125, rounded.Price)
+	// The caller's options are unchanged.
+	assert.Equal(t, 0.0123, opts.Price)
+
+	// Unknown instruments are unchecked.
+	unchecked, err := ex.checkPrice("ETH-PERPETUAL", opts, RoundDown)
+	assert.Nil(t, err)
+	assert.Equal(t, opts, unchecked)
+
+	ex.priceCheck = ValidatePrices
+	_, err = ex.checkPrice("BTC-27DEC24-60000-C", opts, RoundDown)
+	assert.NotNil(t, err)
+	valid, err := ex.checkPrice("BTC-27DEC24-60000-C", &OrderOptions{Price: 0.0125}, RoundDown)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0125, valid.Price)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bogdanovich/tradekit/internal/websocket"
 	"github.com/valyala/fastjson"
@@ -32,6 +33,30 @@ type OrderOptions struct {
 	TriggerOffset  float64
 	Trigger        string
 	ValidUntil     int64
+
+	// LinkedOrderType links the order with the secondary orders in OtocoConfig. For
+	// details see: https://docs.deribit.com/#private-buy
+	LinkedOrderType LinkedOrderType
+	// TriggerFillCondition specifies when the secondary orders of a linked order are
+	// placed. Deribit's default is FirstHit.
+	TriggerFillCondition TriggerFillCondition
+	OtocoConfig          []OtocoOrder
+}
+
+// OtocoOrder is a secondary order of a linked order, placed or cancelled according to
+// the primary order's LinkedOrderType. The direction is either [Buy] or [Sell].
+type OtocoOrder struct {
+	Direction     string
+	Amount        float64
+	Type          OrderType
+	Label         string
+	Price         float64
+	TimeInForce   TimeInForce
+	PostOnly      bool
+	ReduceOnly    bool
+	Trigger       string
+	TriggerPrice  float64
+	TriggerOffset float64
 }
 
 // CancelOrderOptions specify the options for a [TradingExecutor.CancelMany] request.
@@ -63,6 +88,10 @@ type EditOrderOptions struct {
 // is received. You can set the callback function to nil if you wish to ignore the
 // response, however, it is recommended that you supply it so that you can properly
 // handle RPC errors. Requests return an error if the executor is closed.
+//
+// If the connection is reset, requests awaiting a response are called back with a
+// "connection reset" error, and should be retried by the caller. Requests made while the
+// executor reconnects are sent once the new connection is authenticated.
 type TradingExecutor interface {
 	// Start the executor. The executor must be started before any requests can be made.
 	Start(ctx context.Context) error
@@ -106,6 +135,59 @@ type TradingExecutor interface {
 	// https://docs.deribit.com/#private-close_position
 	EditOrder(orderId string, amount float64, p *EditOrderOptions, cb func(res RpcResponse[OrderUpdate])) error
 
+	// MassQuote places or replaces two sided quotes on many instruments in a single
+	// request. Quotes which are rejected are reported with per-quote errors in the
+	// result. For details see: https://docs.deribit.com/#private-mass_quote
+	MassQuote(p MassQuoteParams, cb func(res RpcResponse[MassQuoteResult])) error
+
+	// CancelQuotes cancels quotes placed with MassQuote. If the options are nil, then
+	// *all* quotes are cancelled. If successful, the result contains the number of quotes
+	// cancelled. For details see: https://docs.deribit.com/#private-cancel_quotes
+	CancelQuotes(opts *CancelQuotesOptions, cb func(res RpcResponse[int])) error
+
+	// SetMmpConfig configures Market Maker Protection for an index and MMP group. MMP
+	// triggers may be received with a stream from [NewMmpTriggerStream]. For details see:
+	// https://docs.deribit.com/#private-set_mmp_config
+	SetMmpConfig(cfg MmpConfig, cb func(res RpcResponse[struct{}])) error
+
+	// GetPositions retrieves the positions in instruments of a currency. For details see:
+	// https://docs.deribit.com/#private-get_positions
+	GetPositions(currency string, opts *GetPositionsOptions, cb func(res RpcResponse[[]DeribitPosition])) error
+
+	// GetPosition retrieves the position in an instrument. For details see:
+	// https://docs.deribit.com/#private-get_position
+	GetPosition(instrument string, cb func(res RpcResponse[DeribitPosition])) error
+
+	// GetOpenOrders retrieves the open orders matching the options. If the options are
+	// nil, all open orders are returned.
+	GetOpenOrders(opts *GetOpenOrdersOptions, cb func(res RpcResponse[[]Order])) error
+
+	// GetOrderState retrieves the state of an order. For details see:
+	// https://docs.deribit.com/#private-get_order_state
+	GetOrderState(orderId string, cb func(res RpcResponse[Order])) error
+
+	// GetOrderHistory retrieves the history of orders on an instrument or currency. Use
+	// the Count and Offset options to page through the history.
+	GetOrderHistory(opts GetOrderHistoryOptions, cb func(res RpcResponse[[]Order])) error
+
+	// GetUserTrades returns an iterator over the user's trades on an instrument or
+	// currency, or the trades of an order. Unlike the other requests, the iterator's Next method waits for the
+	// response.
+	GetUserTrades(opts GetUserTradesOptions) Iterator[[]TradeExecution]
+
+	// GetAccountSummary retrieves the summary of the account for a currency. For details
+	// see: https://docs.deribit.com/#private-get_account_summary
+	GetAccountSummary(currency string, cb func(res RpcResponse[DeribitUserPortfolioCurrency])) error
+
+	// GetMargins retrieves the margins required to buy or sell an amount of an instrument
+	// at a price. For details see: https://docs.deribit.com/#private-get_margins
+	GetMargins(instrument string, amount float64, price float64, cb func(res RpcResponse[Margins])) error
+
+	// CreateCombo creates a combo instrument from the given legs, or returns the existing
+	// combo with the same legs. Combo orders are placed with Buy and Sell on the combo's
+	// instrument name. For details see: https://docs.deribit.com/#private-create_combo
+	CreateCombo(trades []ComboTrade, cb func(res RpcResponse[Combo])) error
+
 	// Err returns a channel of errors. This does not include errors arising from
 	// malformed RPC requests, which are included in the RpcResponse of reqeusts, but
 	// rather internal errors which could not be handled by the executor. If this channel
@@ -116,14 +198,39 @@ type TradingExecutor interface {
 type liveTradeExecutor struct {
 	ws       *websocket.Websocket
 	creds    Credentials
-	errc     chan error
-	p        fastjson.Parser
-	isClosed bool
+	authOpts AuthOptions
+	auth     *authSession
+	// authenticated is closed once the first connection is authenticated.
+	authenticated chan struct{}
+	authOnce      sync.Once
+	heartbeat     *heartbeat
+	// cancelOnDisconnect enables cancel on disconnect for each connection.
+	cancelOnDisconnect bool
+	errc               chan error
+	p                  fastjson.Parser
+	isClosed           bool
+
+	// priceCheck is applied to the price of orders on instruments in the registry.
+	priceCheck PriceCheck
+	registry   *InstrumentRegistry
+
+	// connects is signalled each time the websocket connects.
+	connects chan struct{}
 
 	// Use a mutex to prevent race conditions when storing/removing callbacks & checking
 	// or setting isClosed
 	m sync.Mutex
 
+	// connected is true once the websocket has first connected.
+	connected bool
+	// ready is true while the connection is authenticated. Requests made while it isn't
+	// are queued, and sent once the connection is authenticated.
+	ready  bool
+	queued []queuedRequest
+	// resetIds are the requests which failed when the connection was reset. Their
+	// responses, if any arrive, are ignored.
+	resetIds map[int64]struct{}
+
 	// Used to identify the request method when receiving responses
 	requestIdMethod map[int64]rpcMethod
 
@@ -133,17 +240,83 @@ type liveTradeExecutor struct {
 	cancelManyCallbacks map[int64]func(RpcResponse[int])
 	positionCallbacks   map[int64]func(RpcResponse[DeribitPosition])
 	positionsCallbacks  map[int64]func(RpcResponse[[]DeribitPosition])
+	massQuoteCallbacks  map[int64]func(RpcResponse[MassQuoteResult])
+	mmpConfigCallbacks  map[int64]func(RpcResponse[struct{}])
+	ordersCallbacks     map[int64]func(RpcResponse[[]Order])
+	orderCallbacks      map[int64]func(RpcResponse[Order])
+	accountCallbacks    map[int64]func(RpcResponse[DeribitUserPortfolioCurrency])
+	marginsCallbacks    map[int64]func(RpcResponse[Margins])
+	comboCallbacks      map[int64]func(RpcResponse[Combo])
+
+	// Requests made through rpcCall wait for their response on a channel. The channel is
+	// nil if the request's context was done before the response arrived.
+	rpcCalls map[int64]chan wsResponse
+}
+
+// queuedRequest is a request held by the executor until its connection is authenticated.
+type queuedRequest struct {
+	id  int64
+	msg []byte
+}
+
+// ExecutorOption sets an optional parameter of a TradingExecutor created with
+// [NewTradingExecutor].
+type ExecutorOption func(*liveTradeExecutor)
+
+// WithAuthOptions sets how the executor authenticates with its credentials.
+func WithAuthOptions(opts AuthOptions) ExecutorOption {
+	return func(ex *liveTradeExecutor) {
+		ex.authOpts = opts
+	}
+}
+
+// WithCancelOnDisconnect enables cancel on disconnect for the executor's connection, so
+// that Deribit cancels all of the account's open orders if the connection is lost. For
+// details see: https://docs.deribit.com/#private-enable_cancel_on_disconnect
+func WithCancelOnDisconnect() ExecutorOption {
+	return func(ex *liveTradeExecutor) {
+		ex.cancelOnDisconnect = true
+	}
+}
+
+// PriceCheck specifies how a TradingExecutor created with [WithPriceCheck] checks the
+// price of orders before they're sent.
+type PriceCheck int
+
+const (
+	// ValidatePrices rejects orders with a price which isn't a valid tick of the
+	// instrument.
+	ValidatePrices PriceCheck = iota + 1
+	// RoundPrices rounds the price of orders to a valid tick of the instrument. Buy
+	// orders are rounded down, and sell orders up, so that the order is never more
+	// aggressive than requested.
+	RoundPrices
+)
+
+// WithPriceCheck checks the price of Buy and Sell orders against the tick size of the
+// instrument in the registry. Orders on instruments which aren't in the registry, and
+// orders without a price, are sent unchecked.
+func WithPriceCheck(registry *InstrumentRegistry, check PriceCheck) ExecutorOption {
+	return func(ex *liveTradeExecutor) {
+		ex.registry = registry
+		ex.priceCheck = check
+	}
 }
 
 // NewTradeExecutor creates a new Deribit TradingExecutor with the given websocket URL
-// and client credentials.
-func NewTradingExecutor(wsUrl string, credentials Credentials) TradingExecutor {
+// and client credentials. The executor's connection requests heartbeats from Deribit,
+// and reconnects if a heartbeat is missed.
+func NewTradingExecutor(wsUrl string, credentials Credentials, opts ...ExecutorOption) TradingExecutor {
 	ws := websocket.New(wsUrl, nil)
 
-	return &liveTradeExecutor{
+	ex := &liveTradeExecutor{
 		ws:              &ws,
 		creds:           credentials,
 		errc:            make(chan error, 1),
+		authenticated:   make(chan struct{}),
+		heartbeat:       newHeartbeat(),
+		connects:        make(chan struct{}, 1),
+		resetIds:        make(map[int64]struct{}),
 		requestIdMethod: make(map[int64]rpcMethod),
 
 		orderStateCallbacks: make(map[int64]func(RpcResponse[OrderUpdate])),
@@ -151,15 +324,28 @@ func NewTradingExecutor(wsUrl string, credentials Credentials) TradingExecutor {
 		cancelManyCallbacks: make(map[int64]func(RpcResponse[int])),
 		positionCallbacks:   make(map[int64]func(RpcResponse[DeribitPosition])),
 		positionsCallbacks:  make(map[int64]func(RpcResponse[[]DeribitPosition])),
+		massQuoteCallbacks:  make(map[int64]func(RpcResponse[MassQuoteResult])),
+		mmpConfigCallbacks:  make(map[int64]func(RpcResponse[struct{}])),
+		ordersCallbacks:     make(map[int64]func(RpcResponse[[]Order])),
+		orderCallbacks:      make(map[int64]func(RpcResponse[Order])),
+		accountCallbacks:    make(map[int64]func(RpcResponse[DeribitUserPortfolioCurrency])),
+		marginsCallbacks:    make(map[int64]func(RpcResponse[Margins])),
+		comboCallbacks:      make(map[int64]func(RpcResponse[Combo])),
+		rpcCalls:            make(map[int64]chan wsResponse),
 	}
+	for _, opt := range opts {
+		opt(ex)
+	}
+	ex.auth = newAuthSession(ex.creds.ClientId, ex.creds.ClientSecret, ex.authOpts)
+	return ex
 }
 
 func isRpcError(v *fastjson.Value) *Error {
 	errField := v.Get("error")
 	if errField != nil {
 		err := Error{
-			Code:    v.GetInt("code"),
-			Message: string(v.GetStringBytes("message")),
+			Code:    errField.GetInt("code"),
+			Message: string(errField.GetStringBytes("message")),
 		}
 		return &err
 	}
@@ -174,11 +360,53 @@ func (ex *liveTradeExecutor) handleResponse(msg websocket.Message) error {
 		return fmt.Errorf("invalid JSON: %s", string(msg.Data()))
 	}
 
+	ex.heartbeat.received()
+
+	if isHeartbeat(v) {
+		id, reply, err := ex.heartbeat.reply(v)
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			ex.requestIdMethod[id] = methodPublicTest
+			ex.ws.Send(reply)
+		}
+		return nil
+	}
+
 	id := v.GetInt64("id")
 	if id == 0 {
 		return fmt.Errorf("missing request id: %s", string(msg.Data()))
 	}
 
+	if ex.auth.isResponse(id) {
+		if err := ex.auth.handleResponse(v); err != nil {
+			return err
+		}
+		ex.authOnce.Do(func() { close(ex.authenticated) })
+		if !ex.ready {
+			return ex.sendQueued()
+		}
+		return nil
+	}
+
+	if _, ok := ex.resetIds[id]; ok {
+		delete(ex.resetIds, id)
+		return nil
+	}
+
+	if c, ok := ex.rpcCalls[id]; ok {
+		delete(ex.rpcCalls, id)
+		delete(ex.requestIdMethod, id)
+		if c != nil {
+			// The message's data is only valid until it's released.
+			data := make([]byte, len(msg.Data()))
+			copy(data, msg.Data())
+			c <- wsResponse{data: data}
+		}
+		return nil
+	}
+
 	method, ok := ex.requestIdMethod[id]
 	if !ok {
 		return fmt.Errorf("response from unknown request: %s", msg.Data())
@@ -218,7 +446,8 @@ func (ex *liveTradeExecutor) handleResponse(msg websocket.Message) error {
 	} else if method == methodPrivateCancelAll ||
 		method == methodPrivateCancelAllCurrency ||
 		method == methodPrivateCancelAllInstrument ||
-		method == methodPrivateCancelByLabel {
+		method == methodPrivateCancelByLabel ||
+		method == methodPrivateCancelQuotes {
 		cb, ok := ex.cancelManyCallbacks[id]
 		if ok {
 			delete(ex.cancelManyCallbacks, id)
@@ -248,6 +477,87 @@ func (ex *liveTradeExecutor) handleResponse(msg websocket.Message) error {
 				cb(RpcResponse[DeribitPosition]{Result: parsePosition(result)})
 			}
 		}
+	} else if method == methodPrivateGetOpenOrders ||
+		method == methodPrivateGetOpenOrdersByCurrency ||
+		method == methodPrivateGetOpenOrdersByInstrument ||
+		method == methodPrivateGetOpenOrdersByLabel ||
+		method == methodPrivateGetOrderHistoryByCurrency ||
+		method == methodPrivateGetOrderHistoryByInstrument {
+		cb, ok := ex.ordersCallbacks[id]
+		if ok {
+			delete(ex.ordersCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[[]Order]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[[]Order]{Result: parseOrders(result)})
+			}
+		}
+	} else if method == methodPrivateGetOrderState {
+		cb, ok := ex.orderCallbacks[id]
+		if ok {
+			delete(ex.orderCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[Order]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[Order]{Result: parseOrder(result)})
+			}
+		}
+	} else if method == methodPrivateGetAccountSummary {
+		cb, ok := ex.accountCallbacks[id]
+		if ok {
+			delete(ex.accountCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[DeribitUserPortfolioCurrency]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[DeribitUserPortfolioCurrency]{Result: parsePortfolioData(result)})
+			}
+		}
+	} else if method == methodPrivateGetMargins {
+		cb, ok := ex.marginsCallbacks[id]
+		if ok {
+			delete(ex.marginsCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[Margins]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[Margins]{Result: parseMargins(result)})
+			}
+		}
+	} else if method == methodPrivateCreateCombo {
+		cb, ok := ex.comboCallbacks[id]
+		if ok {
+			delete(ex.comboCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[Combo]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[Combo]{Result: parseCombo(result)})
+			}
+		}
+	} else if method == methodPrivateMassQuote {
+		cb, ok := ex.massQuoteCallbacks[id]
+		if ok {
+			delete(ex.massQuoteCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[MassQuoteResult]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[MassQuoteResult]{Result: parseMassQuoteResult(result)})
+			}
+		}
+	} else if method == methodPrivateSetMmpConfig {
+		cb, ok := ex.mmpConfigCallbacks[id]
+		if ok {
+			delete(ex.mmpConfigCallbacks, id)
+			if rpcErr != nil {
+				cb(RpcResponse[struct{}]{Error: rpcErr})
+			} else {
+				cb(RpcResponse[struct{}]{Result: struct{}{}})
+			}
+		}
+	} else if method == methodPublicSetHeartbeat ||
+		method == methodPublicTest ||
+		method == methodPrivateEnableCancelOnDisconnect {
+		if rpcErr != nil {
+			return fmt.Errorf("%s: %w", method, rpcErr)
+		}
 	} else {
 		return fmt.Errorf("unknown method %q", method)
 	}
@@ -256,61 +566,183 @@ func (ex *liveTradeExecutor) handleResponse(msg websocket.Message) error {
 }
 
 func (ex *liveTradeExecutor) Start(ctx context.Context) error {
-	ex.ws.OnConnect = func() error {
-		ex.m.Lock()
-		defer ex.m.Unlock()
+	ex.ws.OnConnect = ex.onConnect
+	if err := ex.ws.Start(ctx); err != nil {
+		return tradingExErr(fmt.Errorf("websocket connect: %w", err))
+	}
+	go ex.run(ctx)
 
-		// Authenticate and wait for response. We don't need to do anything with it,
-		// just make sure it was successful.
-		id, err := ex.authenticate()
-		if err != nil {
-			return tradingExErr(fmt.Errorf("auth failure: %w", err))
+	// Wait until the connection is authenticated, so that no request is sent before it
+	// is.
+	select {
+	case <-ctx.Done():
+		return tradingExErr(ctx.Err())
+	case <-ex.authenticated:
+		return nil
+	case err, ok := <-ex.errc:
+		if !ok {
+			return tradingExErr(errors.New("executor closed before authenticating"))
 		}
-		msg := <-ex.ws.Messages()
-		defer msg.Release()
-		v, err := ex.p.ParseBytes(msg.Data())
-		if err != nil {
-			return err
+		return err
+	}
+}
+
+// onConnect signals run to set up a new connection. The websocket doesn't send any
+// messages until onConnect returns, so it mustn't block on sending them.
+func (ex *liveTradeExecutor) onConnect() error {
+	select {
+	case ex.connects <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// setupConnection authenticates a new connection and sets its heartbeat. Requests which
+// were awaiting a response on the previous connection fail, and requests made until the
+// connection is authenticated are queued.
+func (ex *liveTradeExecutor) setupConnection() error {
+	ex.m.Lock()
+	if ex.connected {
+		// Responses to requests sent on the previous connection will never arrive.
+		ex.failPending(&Error{Message: "connection reset"})
+	}
+	ex.connected = true
+	ex.ready = false
+
+	_, authMsg, err := ex.auth.authRequest()
+	if err != nil {
+		ex.m.Unlock()
+		return fmt.Errorf("auth failure: %w", err)
+	}
+	id, heartbeatMsg, err := ex.heartbeat.setRequest()
+	if err != nil {
+		ex.m.Unlock()
+		return fmt.Errorf("set heartbeat: %w", err)
+	}
+	ex.requestIdMethod[id] = methodPublicSetHeartbeat
+	ex.m.Unlock()
+
+	ex.ws.Send(authMsg)
+	ex.ws.Send(heartbeatMsg)
+	return nil
+}
+
+// sendQueued sends the requests queued while the connection wasn't authenticated, after
+// enabling cancel on disconnect if it's set. The mutex must be held.
+func (ex *liveTradeExecutor) sendQueued() error {
+	ex.ready = true
+	if ex.cancelOnDisconnect {
+		params := map[string]string{"scope": "connection"}
+		if err := ex.sendRPC(genId(), methodPrivateEnableCancelOnDisconnect, params); err != nil {
+			return fmt.Errorf("enable cancel on disconnect: %w", err)
 		}
-		if id != v.GetInt64("id") {
-			return tradingExErr(fmt.Errorf("expected auth response but received: %s", msg.Data()))
+	}
+	for _, req := range ex.queued {
+		ex.ws.Send(req.msg)
+	}
+	ex.queued = nil
+	return nil
+}
+
+// failPending returns an error to all requests awaiting a response, except for queued
+// requests which haven't been sent. The mutex must be held.
+func (ex *liveTradeExecutor) failPending(err *Error) {
+	queued := make(map[int64]bool, len(ex.queued))
+	for _, req := range ex.queued {
+		queued[req.id] = true
+	}
+	ex.resetIds = make(map[int64]struct{})
+	for id := range ex.requestIdMethod {
+		if queued[id] {
+			continue
 		}
 		delete(ex.requestIdMethod, id)
-		if err := isRpcError(v); err != nil {
-			return tradingExErr(fmt.Errorf("auth failure: %w", err))
+		ex.resetIds[id] = struct{}{}
+		if c, ok := ex.rpcCalls[id]; ok {
+			delete(ex.rpcCalls, id)
+			if c != nil {
+				c <- wsResponse{err: err}
+			}
+			continue
 		}
-		return nil
+		failCallback(ex.orderStateCallbacks, id, err)
+		failCallback(ex.cancelCallbacks, id, err)
+		failCallback(ex.cancelManyCallbacks, id, err)
+		failCallback(ex.positionCallbacks, id, err)
+		failCallback(ex.positionsCallbacks, id, err)
+		failCallback(ex.massQuoteCallbacks, id, err)
+		failCallback(ex.mmpConfigCallbacks, id, err)
+		failCallback(ex.ordersCallbacks, id, err)
+		failCallback(ex.orderCallbacks, id, err)
+		failCallback(ex.accountCallbacks, id, err)
+		failCallback(ex.marginsCallbacks, id, err)
+		failCallback(ex.comboCallbacks, id, err)
 	}
+}
 
-	if err := ex.ws.Start(ctx); err != nil {
-		return tradingExErr(fmt.Errorf("websocket connect: %w", err))
+// failCallback calls the callback of a request, if it has one, with an error.
+func failCallback[T any](callbacks map[int64]func(RpcResponse[T]), id int64, err *Error) {
+	if cb, ok := callbacks[id]; ok {
+		delete(callbacks, id)
+		cb(RpcResponse[T]{Error: err})
 	}
+}
 
-	go func() {
-		defer func() {
-			ex.isClosed = true
-			ex.ws.Close()
-			close(ex.errc)
-			ex.m.Unlock()
-		}()
-		for {
+// run handles responses and keeps the connection alive until the context is done or an
+// error occurs.
+func (ex *liveTradeExecutor) run(ctx context.Context) {
+	heartbeatTicker := time.NewTicker(ex.heartbeat.interval)
+	defer func() {
+		heartbeatTicker.Stop()
+		ex.m.Lock()
+		ex.isClosed = true
+		ex.queued = nil
+		ex.failPending(&Error{Message: "executor is closed"})
+		ex.m.Unlock()
+		ex.auth.stop()
+		ex.ws.Close()
+		close(ex.errc)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok := <-ex.ws.Messages():
+			if !ok {
+				return
+			}
 			ex.m.Lock()
-			select {
-			case <-ctx.Done():
+			err := ex.handleResponse(data)
+			ex.m.Unlock()
+			if err != nil {
+				ex.errc <- tradingExErr(err)
+				return
+			}
+		case <-ex.connects:
+			if err := ex.setupConnection(); err != nil {
+				ex.errc <- tradingExErr(err)
 				return
-			case data := <-ex.ws.Messages():
-				if err := ex.handleResponse(data); err != nil {
-					ex.errc <- tradingExErr(err)
-					return
-				}
-			case err := <-ex.ws.Err():
-				ex.errc <- tradingExErr(fmt.Errorf("websocket: %w", err))
+			}
+		case <-ex.auth.refreshRequests():
+			_, msg, err := ex.auth.refreshRequest()
+			if err != nil {
+				ex.errc <- tradingExErr(fmt.Errorf("auth refresh: %w", err))
 				return
 			}
-			ex.m.Unlock()
+			ex.ws.Send(msg)
+		case <-heartbeatTicker.C:
+			if ex.heartbeat.missed() {
+				// Pending requests fail, and the connection is re-authenticated, when it
+				// reconnects. The heartbeat is restarted so that the reconnection isn't
+				// reset again before it's set up.
+				ex.heartbeat.received()
+				ex.ws.Reset()
+			}
+		case err := <-ex.ws.Err():
+			ex.errc <- tradingExErr(fmt.Errorf("websocket: %w", err))
+			return
 		}
-	}()
-	return nil
+	}
 }
 
 func (ex *liveTradeExecutor) Buy(instrument string, amount float64, opts *OrderOptions, cb func(RpcResponse[OrderUpdate])) error {
@@ -319,6 +751,10 @@ func (ex *liveTradeExecutor) Buy(instrument string, amount float64, opts *OrderO
 	if ex.isClosed {
 		return tradingExErr(errors.New("attempted Buy but executor is closed"))
 	}
+	opts, err := ex.checkPrice(instrument, opts, RoundDown)
+	if err != nil {
+		return err
+	}
 	id := genId()
 	method := methodPrivateBuy
 	params := opts.params()
@@ -337,6 +773,10 @@ func (ex *liveTradeExecutor) Sell(instrument string, amount float64, opts *Order
 	if ex.isClosed {
 		return tradingExErr(errors.New("attempted Sell but executor is closed"))
 	}
+	opts, err := ex.checkPrice(instrument, opts, RoundUp)
+	if err != nil {
+		return err
+	}
 	id := genId()
 	method := methodPrivateSell
 	params := opts.params()
@@ -349,6 +789,24 @@ func (ex *liveTradeExecutor) Sell(instrument string, amount float64, opts *Order
 	return nil
 }
 
+// checkPrice applies the executor's price check to the options of an order. Rounded
+// prices are set on a copy of the options.
+func (ex *liveTradeExecutor) checkPrice(instrument string, opts *OrderOptions, mode RoundingMode) (*OrderOptions, error) {
+	if ex.registry == nil || opts == nil || opts.Price == 0 {
+		return opts, nil
+	}
+	inst, ok := ex.registry.Get(instrument)
+	if !ok || inst.IsValidPrice(opts.Price) {
+		return opts, nil
+	}
+	if ex.priceCheck == ValidatePrices {
+		return nil, tradingExErr(fmt.Errorf("price %v is not a valid tick of %s", opts.Price, instrument))
+	}
+	rounded := *opts
+	rounded.Price = inst.RoundPrice(opts.Price, mode)
+	return &rounded, nil
+}
+
 func (ex *liveTradeExecutor) Cancel(orderId string, cb func(RpcResponse[struct{}])) error {
 	ex.m.Lock()
 	defer ex.m.Unlock()
@@ -437,6 +895,51 @@ func (ex *liveTradeExecutor) EditOrder(orderId string, amount float64, opts *Edi
 	return nil
 }
 
+func (ex *liveTradeExecutor) MassQuote(p MassQuoteParams, cb func(RpcResponse[MassQuoteResult])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted MassQuote but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateMassQuote
+	if cb != nil {
+		ex.massQuoteCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, p.params())
+	return nil
+}
+
+func (ex *liveTradeExecutor) CancelQuotes(opts *CancelQuotesOptions, cb func(RpcResponse[int])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted CancelQuotes but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateCancelQuotes
+	if cb != nil {
+		ex.cancelManyCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, opts.params())
+	return nil
+}
+
+func (ex *liveTradeExecutor) SetMmpConfig(cfg MmpConfig, cb func(RpcResponse[struct{}])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted SetMmpConfig but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateSetMmpConfig
+	if cb != nil {
+		ex.mmpConfigCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, cfg.params())
+	return nil
+}
+
 type GetPositionsOptions struct {
 	Kind InstrumentKind
 }
@@ -474,6 +977,152 @@ func (ex *liveTradeExecutor) GetPosition(instrument string, cb func(RpcResponse[
 	return nil
 }
 
+func (ex *liveTradeExecutor) GetOpenOrders(opts *GetOpenOrdersOptions, cb func(RpcResponse[[]Order])) error {
+	method, params, err := opts.methodAndParams()
+	if err != nil {
+		return tradingExErr(err)
+	}
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted GetOpenOrders but executor is closed"))
+	}
+	id := genId()
+	if cb != nil {
+		ex.ordersCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, params)
+	return nil
+}
+
+func (ex *liveTradeExecutor) GetOrderState(orderId string, cb func(RpcResponse[Order])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted GetOrderState but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateGetOrderState
+	params := map[string]string{"order_id": orderId}
+	if cb != nil {
+		ex.orderCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, params)
+	return nil
+}
+
+func (ex *liveTradeExecutor) GetOrderHistory(opts GetOrderHistoryOptions, cb func(RpcResponse[[]Order])) error {
+	method, params, err := opts.methodAndParams()
+	if err != nil {
+		return tradingExErr(err)
+	}
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted GetOrderHistory but executor is closed"))
+	}
+	id := genId()
+	if cb != nil {
+		ex.ordersCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, params)
+	return nil
+}
+
+func (ex *liveTradeExecutor) GetUserTrades(opts GetUserTradesOptions) Iterator[[]TradeExecution] {
+	if opts.Count == 0 {
+		opts.Count = 10
+	}
+	return &userTradesIterator{c: ex, params: opts}
+}
+
+func (ex *liveTradeExecutor) GetAccountSummary(currency string, cb func(RpcResponse[DeribitUserPortfolioCurrency])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted GetAccountSummary but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateGetAccountSummary
+	params := map[string]interface{}{"currency": currency, "extended": true}
+	if cb != nil {
+		ex.accountCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, params)
+	return nil
+}
+
+func (ex *liveTradeExecutor) GetMargins(instrument string, amount float64, price float64, cb func(RpcResponse[Margins])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted GetMargins but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateGetMargins
+	params := map[string]interface{}{
+		"instrument_name": instrument,
+		"amount":          amount,
+		"price":           price,
+	}
+	if cb != nil {
+		ex.marginsCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, params)
+	return nil
+}
+
+func (ex *liveTradeExecutor) CreateCombo(trades []ComboTrade, cb func(RpcResponse[Combo])) error {
+	ex.m.Lock()
+	defer ex.m.Unlock()
+	if ex.isClosed {
+		return tradingExErr(errors.New("attempted CreateCombo but executor is closed"))
+	}
+	id := genId()
+	method := methodPrivateCreateCombo
+	if cb != nil {
+		ex.comboCallbacks[id] = cb
+	}
+	ex.sendRPC(id, method, comboTradesParams(trades))
+	return nil
+}
+
+// rpcCall sends a request and waits for its response. It implements rpcCaller, so that
+// the executor may be used with the Api's iterators.
+func (ex *liveTradeExecutor) rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error {
+	ex.m.Lock()
+	if ex.isClosed {
+		ex.m.Unlock()
+		return apiErr(method, errors.New("executor is closed"))
+	}
+	id := genId()
+	c := make(chan wsResponse, 1)
+	ex.rpcCalls[id] = c
+	err := ex.sendRPC(id, method, params)
+	ex.m.Unlock()
+	if err != nil {
+		return apiErr(method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		ex.m.Lock()
+		if _, ok := ex.rpcCalls[id]; ok {
+			ex.rpcCalls[id] = nil
+		}
+		ex.m.Unlock()
+		return apiErr(method, ctx.Err())
+	case res := <-c:
+		if res.err != nil {
+			return apiErr(method, res.err)
+		}
+		if err := decodeRpcResponse(res.data, result); err != nil {
+			return apiErr(method, err)
+		}
+		return nil
+	}
+}
+
 func (ex *liveTradeExecutor) Err() <-chan error {
 	return ex.errc
 }
@@ -484,22 +1133,14 @@ func (ex *liveTradeExecutor) sendRPC(id int64, method rpcMethod, params interfac
 		return err
 	}
 	ex.requestIdMethod[id] = method
+	if !ex.ready {
+		ex.queued = append(ex.queued, queuedRequest{id: id, msg: msg})
+		return nil
+	}
 	ex.ws.Send(msg)
 	return nil
 }
 
-func (ex *liveTradeExecutor) authenticate() (id int64, err error) {
-	id = genId()
-	method := methodPublicAuth
-	params := map[string]string{
-		"grant_type":    "client_credentials",
-		"client_id":     ex.creds.ClientId,
-		"client_secret": ex.creds.ClientSecret,
-	}
-	err = ex.sendRPC(id, method, params)
-	return
-}
-
 func tradingExErr(err error) error {
 	return fmt.Errorf("Deribit TradingExecutor: %w", err)
 }
@@ -545,6 +1186,54 @@ func (o *OrderOptions) params() map[string]interface{} {
 	if o.ValidUntil != 0 {
 		m["valid_until"] = o.ValidUntil
 	}
+	if o.LinkedOrderType != "" {
+		m["linked_order_type"] = string(o.LinkedOrderType)
+	}
+	if o.TriggerFillCondition != "" {
+		m["trigger_fill_condition"] = string(o.TriggerFillCondition)
+	}
+	if len(o.OtocoConfig) > 0 {
+		config := make([]map[string]interface{}, len(o.OtocoConfig))
+		for i, oo := range o.OtocoConfig {
+			config[i] = oo.params()
+		}
+		m["otoco_config"] = config
+	}
+	return m
+}
+
+func (o OtocoOrder) params() map[string]interface{} {
+	m := map[string]interface{}{
+		"direction": o.Direction,
+		"amount":    o.Amount,
+	}
+	if o.Type != "" {
+		m["type"] = string(o.Type)
+	}
+	if o.Label != "" {
+		m["label"] = o.Label
+	}
+	if o.Price != 0 {
+		m["price"] = o.Price
+	}
+	if o.TimeInForce != "" {
+		m["time_in_force"] = string(o.TimeInForce)
+	}
+	if o.PostOnly {
+		m["post_only"] = o.PostOnly
+	}
+	if o.ReduceOnly {
+		m["reduce_only"] = o.ReduceOnly
+	}
+	if o.Trigger != "" {
+		m["trigger"] = o.Trigger
+	}
+	if o.TriggerPrice != 0 {
+		m["trigger_price"] = o.TriggerPrice
+	}
+	if o.TriggerOffset != 0 {
+		m["trigger_offset"] = o.TriggerOffset
+	}
 	return m
 }
 
@@ -148,3 +148,27 @@ func mapsEqual(m1 map[string]interface{}, m2 map[string]interface{}) bool {
 	}
 	return true
 }
+
+func TestLinkedOrderOptions(t *testing.T) {
+	opts := OrderOptions{
+		Type:                 LimitOrder,
+		Price:                100,
+		LinkedOrderType:      OneTriggersOneCancelsOther,
+		TriggerFillCondition: IncrementalFill,
+		OtocoConfig: []OtocoOrder{
+			{Direction: Sell, Amount: 10, Type: LimitOrder, Price: 110, ReduceOnly: true},
+			{Direction: Sell, Amount: 10, Type: StopMarketOrder, Trigger: "mark_price", TriggerPrice: 95},
+		},
+	}
+	expected := map[string]interface{}{
+		"type":                   "limit",
+		"price":                  float64(100),
+		"linked_order_type":      "one_triggers_one_cancels_other",
+		"trigger_fill_condition": "incremental",
+		"otoco_config": []map[string]interface{}{
+			{"direction": "sell", "amount": float64(10), "type": "limit", "price": float64(110), "reduce_only": true},
+			{"direction": "sell", "amount": float64(10), "type": "stop_market", "trigger": "mark_price", "trigger_price": float64(95)},
+		},
+	}
+	assert.Equal(t, expected, opts.params())
+}
@@ -0,0 +1,63 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// UserChangesSub defines a subscription channel for a user changes stream created using
+// [NewUserChangesStream]. Either Instrument or Kind & Currency must be specified. Kind
+// and Currency may be "any".
+type UserChangesSub struct {
+	Instrument string
+
+	Kind     InstrumentKind
+	Currency string
+}
+
+// UserChanges are the changes to a user's orders, trades and positions in an instrument
+// caused by a single event, such as an order being filled.
+type UserChanges struct {
+	Instrument string
+	Orders     []Order
+	Trades     []TradeExecution
+	Positions  []DeribitPosition
+}
+
+func (s UserChangesSub) channel() string {
+	if s.Instrument != "" {
+		return fmt.Sprintf("user.changes.%s.raw", s.Instrument)
+	} else {
+		return fmt.Sprintf("user.changes.%s.%s.raw", s.Kind, s.Currency)
+	}
+}
+
+// NewUserChangesStream creates a new [Stream] returning the changes to a user's orders,
+// trades and positions. Unlike [NewUserOrdersStream] and [NewUserTradesStream], the
+// changes caused by an event are delivered together in one message. Credentials are
+// required for this stream. For details see:
+//   - https://docs.deribit.com/#user-changes-instrument_name-interval
+//   - https://docs.deribit.com/#user-changes-kind-currency-interval
+func NewUserChangesStream(wsUrl string, c tk.Credentials, subscriptions ...UserChangesSub) Stream[UserChanges, UserChangesSub] {
+	p := streamParams[UserChanges, UserChangesSub]{
+		name:         "UserChangesStream",
+		wsUrl:        wsUrl,
+		isPrivate:    true,
+		parseMessage: parseUserChanges,
+		subs:         subscriptions,
+	}
+	s := newStream[UserChanges](p)
+	s.SetCredentials(&c)
+	return s
+}
+
+func parseUserChanges(v *fastjson.Value) UserChanges {
+	return UserChanges{
+		Instrument: string(v.GetStringBytes("instrument_name")),
+		Orders:     parseOrders(v.Get("orders")),
+		Trades:     parseTradeExecutions(v.Get("trades")),
+		Positions:  parsePositions(v.Get("positions")),
+	}
+}
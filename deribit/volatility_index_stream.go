@@ -0,0 +1,47 @@
+package deribit
+
+import (
+	"fmt"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// DeribitVolatilityIndexSub represents a subscription to a Deribit volatility index stream created using [NewVolatilityIndexStream].
+// For details see: https://docs.deribit.com/#deribit_volatility_index-index_name
+type DeribitVolatilityIndexSub struct {
+	IndexName string
+}
+
+// DeribitVolatilityIndex is a value of a Deribit volatility index, such as the DVOL.
+type DeribitVolatilityIndex struct {
+	Timestamp  int64   `json:"timestamp" parquet:"name=timestamp, type=INT64"`
+	Volatility float64 `json:"volatility" parquet:"name=volatility, type=DOUBLE"`
+	IndexName  string  `json:"index_name" parquet:"name=index_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+func (sub DeribitVolatilityIndexSub) channel() string {
+	return fmt.Sprintf("deribit_volatility_index.%s", sub.IndexName)
+}
+
+// NewVolatilityIndexStream creates a new [Stream] which produces a stream of volatility
+// index updates. For details see: https://docs.deribit.com/#deribit_volatility_index-index_name
+func NewVolatilityIndexStream(wsUrl string, subscriptions []DeribitVolatilityIndexSub, paramFuncs ...tk.Param) Stream[DeribitVolatilityIndex, DeribitVolatilityIndexSub] {
+	p := streamParams[DeribitVolatilityIndex, DeribitVolatilityIndexSub]{
+		name:         "VolatilityIndexStream",
+		wsUrl:        wsUrl,
+		isPrivate:    false,
+		parseMessage: ParseDeribitVolatilityIndex,
+		subs:         subscriptions,
+		Params:       tk.ApplyParams(paramFuncs),
+	}
+	return newStream(p)
+}
+
+func ParseDeribitVolatilityIndex(v *fastjson.Value) DeribitVolatilityIndex {
+	return DeribitVolatilityIndex{
+		Timestamp:  v.GetInt64("timestamp"),
+		Volatility: v.GetFloat64("volatility"),
+		IndexName:  string(v.GetStringBytes("index_name")),
+	}
+}
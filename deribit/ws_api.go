@@ -0,0 +1,497 @@
+package deribit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bogdanovich/tradekit"
+	"github.com/bogdanovich/tradekit/internal/websocket"
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/valyala/fastjson"
+)
+
+// WsApi allows for sending requests to the Deribit JSON-RPC API over a websocket
+// connection. It has the same methods as [Api], but avoids the overhead of making a new
+// HTTP request for every call. Requests may be sent concurrently, and each response is
+// matched to its request by id.
+//
+// Every method takes a context, and returns when either the response is received or the
+// context is done. Requests which are awaiting a response when the connection is reset
+// return an error, and should be retried by the caller. Private requests made while the
+// connection is re-authenticated are sent once it is.
+type WsApi struct {
+	url  string
+	opts *tradekit.StreamOptions
+	ws   *websocket.Websocket
+	errc chan error
+	p    fastjson.Parser
+
+	authOpts  AuthOptions
+	auth      *authSession
+	heartbeat *heartbeat
+	// authErrc receives an auth failure from the goroutine authenticating the connection.
+	authErrc chan error
+	// authenticated is closed once the first connection is authenticated.
+	authenticated chan struct{}
+	authOnce      sync.Once
+
+	// sendLock is held for reading while sending a request, and for writing when the
+	// WsApi closes, so that no request is sent after the websocket is closed.
+	sendLock sync.RWMutex
+	// m protects the fields below.
+	m       sync.Mutex
+	closed  bool
+	pending map[int64]chan wsResponse
+	// ready is true while the connection is authenticated, or if the WsApi doesn't
+	// authenticate. Private requests made while it isn't are queued.
+	ready  bool
+	queued []queuedRequest
+
+	*tk.Params
+}
+
+// wsResponse is the response to a request made through a WsApi.
+type wsResponse struct {
+	data []byte
+	err  error
+}
+
+// NewWsApi creates a new WsApi to either the prod or testing Deribit websocket server.
+// Credentials may be set with [tk.WithCredentials] to make calls to private methods. The
+// connection authenticates with them every time it connects.
+func NewWsApi(wsUrl string, paramFuncs ...tk.Param) *WsApi {
+	return &WsApi{
+		url:           wsUrl,
+		errc:          make(chan error, 1),
+		authErrc:      make(chan error, 1),
+		authenticated: make(chan struct{}),
+		heartbeat:     newHeartbeat(),
+		closed:        true,
+		pending:       make(map[int64]chan wsResponse),
+		Params:        tk.ApplyParams(paramFuncs),
+	}
+}
+
+// SetStreamOptions sets optional parameters for the websocket connection. If used, it
+// should be called before Start.
+func (api *WsApi) SetStreamOptions(opts *tradekit.StreamOptions) {
+	api.opts = opts
+}
+
+// SetAuthOptions sets optional parameters for authenticating with the WsApi's
+// credentials. If used, it should be called before Start.
+func (api *WsApi) SetAuthOptions(opts AuthOptions) {
+	api.authOpts = opts
+}
+
+// Start the WsApi's websocket connection. The WsApi must be started before any requests
+// can be made. If the WsApi has credentials, Start returns once the connection is
+// authenticated. The connection is closed when the context is done.
+func (api *WsApi) Start(ctx context.Context) error {
+	ws := websocket.New(api.url, api.opts)
+	api.ws = &ws
+	if api.Params.Credentials != nil {
+		api.auth = newAuthSession(api.Params.ClientId, api.Params.ClientSecret, api.authOpts)
+	}
+	connected := false
+	ws.OnConnect = func() error {
+		api.m.Lock()
+		if connected {
+			// Responses to requests sent on the previous connection will never arrive.
+			api.failPending(errors.New("connection reset"))
+		}
+		connected = true
+		api.ready = api.auth == nil
+		api.m.Unlock()
+		_, msg, err := api.heartbeat.setRequest()
+		if err != nil {
+			return wsApiErr(fmt.Errorf("set heartbeat: %w", err))
+		}
+		// The requests are sent asynchronously because the websocket doesn't send any
+		// messages until OnConnect returns.
+		go api.sendUntracked(msg)
+		if api.auth != nil {
+			go api.authenticate(api.auth.authRequest)
+		}
+		return nil
+	}
+
+	api.m.Lock()
+	api.closed = false
+	api.m.Unlock()
+
+	// The websocket's context is cancelled only after the WsApi is closed, so that no
+	// request is sent after the websocket's request channel is closed.
+	wsCtx, cancel := context.WithCancel(context.Background())
+	if err := ws.Start(wsCtx); err != nil {
+		cancel()
+		return wsApiErr(fmt.Errorf("connecting to websocket: %w", err))
+	}
+
+	go func() {
+		heartbeatTicker := time.NewTicker(api.heartbeat.interval)
+		defer func() {
+			heartbeatTicker.Stop()
+			api.sendLock.Lock()
+			api.m.Lock()
+			api.closed = true
+			api.queued = nil
+			api.failPending(errors.New("WsApi is closed"))
+			api.m.Unlock()
+			api.sendLock.Unlock()
+			cancel()
+			close(api.errc)
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ws.Messages():
+				if !ok {
+					return
+				}
+				api.handleMessage(msg)
+			case <-heartbeatTicker.C:
+				if api.heartbeat.missed() {
+					api.Logger.Error(wsApiErr(errors.New("missed heartbeat, reconnecting")).Error())
+					ws.Reset()
+				}
+			case <-api.authRefreshes():
+				go api.authenticate(api.auth.refreshRequest)
+			case err := <-api.authErrc:
+				api.errc <- wsApiErr(err)
+				return
+			case err, ok := <-ws.Err():
+				if ok {
+					api.errc <- wsApiErr(fmt.Errorf("websocket: %w", err))
+				}
+				return
+			}
+		}
+	}()
+
+	if api.auth == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return wsApiErr(ctx.Err())
+	case <-api.authenticated:
+		return nil
+	case err, ok := <-api.errc:
+		if !ok {
+			return wsApiErr(errors.New("closed before authenticating"))
+		}
+		return err
+	}
+}
+
+// Err returns a channel which produces an error when there is an irrecoverable failure
+// with the WsApi's connection. If the channel produces an error, the WsApi is closed and
+// all further requests return an error.
+func (api *WsApi) Err() <-chan error {
+	return api.errc
+}
+
+// handleMessage passes a response to the request awaiting it and answers heartbeats.
+// Other messages which aren't a response to a request, such as subscription
+// notifications, are ignored.
+func (api *WsApi) handleMessage(msg websocket.Message) {
+	defer msg.Release()
+
+	v, err := api.p.ParseBytes(msg.Data())
+	if err != nil {
+		api.Logger.Error(wsApiErr(fmt.Errorf("invalid JSON: %s", msg.Data())).Error())
+		return
+	}
+	api.heartbeat.received()
+	if isHeartbeat(v) {
+		_, reply, err := api.heartbeat.reply(v)
+		if err != nil {
+			api.Logger.Error(wsApiErr(err).Error())
+		} else if reply != nil {
+			api.ws.Send(reply)
+		}
+		return
+	}
+	id := v.GetInt64("id")
+	if id == 0 {
+		return
+	}
+
+	api.m.Lock()
+	defer api.m.Unlock()
+	c, ok := api.pending[id]
+	if !ok {
+		// The request's context was done before its response arrived.
+		return
+	}
+	delete(api.pending, id)
+	// The message's data is only valid until it's released.
+	data := make([]byte, len(msg.Data()))
+	copy(data, msg.Data())
+	c <- wsResponse{data: data}
+}
+
+// failPending returns an error to all requests awaiting a response, except for queued
+// requests which haven't been sent. The lock must be held.
+func (api *WsApi) failPending(err error) {
+	queued := make(map[int64]bool, len(api.queued))
+	for _, req := range api.queued {
+		queued[req.id] = true
+	}
+	for id, c := range api.pending {
+		if queued[id] {
+			continue
+		}
+		c <- wsResponse{err: err}
+		delete(api.pending, id)
+	}
+}
+
+// send registers a request as awaiting a response and sends it on the websocket.
+func (api *WsApi) send(id int64, method rpcMethod, params interface{}) (chan wsResponse, error) {
+	msg, err := rpcRequestMsg(method, id, params)
+	if err != nil {
+		return nil, err
+	}
+	return api.sendMsg(id, msg, strings.HasPrefix(string(method), "private/"))
+}
+
+// sendMsg registers a request message as awaiting a response and sends it on the
+// websocket. Private requests are queued until the connection is authenticated.
+func (api *WsApi) sendMsg(id int64, msg []byte, private bool) (chan wsResponse, error) {
+	api.sendLock.RLock()
+	defer api.sendLock.RUnlock()
+	api.m.Lock()
+	if api.closed {
+		api.m.Unlock()
+		return nil, errors.New("WsApi is closed")
+	}
+	c := make(chan wsResponse, 1)
+	api.pending[id] = c
+	if private && !api.ready {
+		api.queued = append(api.queued, queuedRequest{id: id, msg: msg})
+		api.m.Unlock()
+		return c, nil
+	}
+	api.m.Unlock()
+	api.ws.Send(msg)
+	return c, nil
+}
+
+// sendUntracked sends a request message whose response isn't awaited.
+func (api *WsApi) sendUntracked(msg []byte) {
+	api.sendLock.RLock()
+	defer api.sendLock.RUnlock()
+	api.m.Lock()
+	closed := api.closed
+	api.m.Unlock()
+	if !closed {
+		api.ws.Send(msg)
+	}
+}
+
+// sendQueued marks the connection as authenticated and sends the queued requests.
+func (api *WsApi) sendQueued() {
+	api.sendLock.RLock()
+	defer api.sendLock.RUnlock()
+	api.m.Lock()
+	if api.closed {
+		api.m.Unlock()
+		return
+	}
+	api.ready = true
+	queued := api.queued
+	api.queued = nil
+	api.m.Unlock()
+	for _, req := range queued {
+		api.ws.Send(req.msg)
+	}
+}
+
+// authenticate the connection with a request created by the WsApi's auth session. An
+// auth failure stops the WsApi.
+func (api *WsApi) authenticate(newRequest func() (int64, []byte, error)) {
+	id, msg, err := newRequest()
+	if err == nil {
+		var c chan wsResponse
+		if c, err = api.sendMsg(id, msg, false); err != nil {
+			// The WsApi is closed.
+			return
+		}
+		res := <-c
+		if res.err != nil {
+			// The connection was reset, and is authenticated again when it reconnects.
+			return
+		}
+		var v *fastjson.Value
+		if v, err = fastjson.ParseBytes(res.data); err == nil {
+			err = api.auth.handleResponse(v)
+		}
+		if err == nil {
+			api.sendQueued()
+			api.authOnce.Do(func() { close(api.authenticated) })
+			return
+		}
+	}
+	if err != nil {
+		select {
+		case api.authErrc <- err:
+		default:
+		}
+	}
+}
+
+// authRefreshes returns a channel which is signalled when the access token should be
+// refreshed. The channel is nil if the WsApi doesn't authenticate.
+func (api *WsApi) authRefreshes() <-chan struct{} {
+	if api.auth == nil {
+		return nil
+	}
+	return api.auth.refreshRequests()
+}
+
+func (api *WsApi) rpcCall(ctx context.Context, method rpcMethod, params map[string]interface{}, result interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	id := genId()
+	c, err := api.send(id, method, params)
+	if err != nil {
+		return apiErr(method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		api.m.Lock()
+		delete(api.pending, id)
+		api.m.Unlock()
+		return apiErr(method, ctx.Err())
+	case res := <-c:
+		if res.err != nil {
+			return apiErr(method, res.err)
+		}
+		if err := decodeRpcResponse(res.data, result); err != nil {
+			return apiErr(method, err)
+		}
+		return nil
+	}
+}
+
+// decodeRpcResponse decodes the result of a JSON-RPC response into the value pointed to
+// by result, or returns the response's error.
+func decodeRpcResponse(data []byte, result interface{}) error {
+	resp := RpcResponse[interface{}]{Result: result}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return *resp.Error
+	}
+	return nil
+}
+
+func wsApiErr(err error) error {
+	return fmt.Errorf("Deribit WsApi: %w", err)
+}
+
+// GetPositions retrieves all positions for specific currency, kind, and subaccount_id.
+// The WsApi must have been created with credentials. The Credentials field of the params
+// is ignored.
+func (api *WsApi) GetPositions(ctx context.Context, p GetPositionsParams) ([]DeribitPosition, error) {
+	return call[[]DeribitPosition](ctx, api, methodPrivateGetPositions, p.params())
+}
+
+// GetOptionInstruments retrieves all Deribit option instruments on the given currency.
+// Set expired to true to show recently expired options instead of active ones.
+func (api *WsApi) GetOptionInstruments(ctx context.Context, currency string, expired bool) ([]Option, error) {
+	return getOptionInstruments(ctx, api, currency, expired)
+}
+
+// GetOptionInstrument retrieves a Deribit option instrument by its name.
+func (api *WsApi) GetOptionInstrument(ctx context.Context, name string) (Option, error) {
+	return getOptionInstrument(ctx, api, name)
+}
+
+// GetInstruments retrieves all Deribit instruments matching the params.
+func (api *WsApi) GetInstruments(ctx context.Context, p GetInstrumentsParams) ([]Instrument, error) {
+	return getInstruments(ctx, api, p)
+}
+
+// GetCurrencies returns a slice of the supported currencies on Deribit.
+// For details see https://docs.deribit.com/#public-get_currencies
+func (api *WsApi) GetCurrencies(ctx context.Context) ([]CurrencyInfo, error) {
+	return call[[]CurrencyInfo](ctx, api, methodPublicGetCurrencies, nil)
+}
+
+// GetDeliveryPrices returns an iterator over delivery prices for a given index.
+// For more details see: https://docs.deribit.com/#public-get_delivery_prices. Results
+// are returned in descending order from the most recent delivery price.
+func (api *WsApi) GetDeliveryPrices(indexName string, p *OptionsGetDeliveryPrices) Iterator[[]DeliveryPrice] {
+	return getDeliveryPrices(api, indexName, p)
+}
+
+// GetIndexPrice returns the current price of a given index.
+func (api *WsApi) GetIndexPrice(ctx context.Context, indexName string) (IndexPrice, error) {
+	return getIndexPrice(ctx, api, indexName)
+}
+
+func (api *WsApi) GetLastTradesByCurrencyAndKind(currency string, kind InstrumentKind, opts *GetTradesOptions) Iterator[[]PublicTrade] {
+	return getLastTrades(api, "", currency, kind, opts)
+}
+
+func (api *WsApi) GetLastTradesByInstrument(instrument string, opts *GetTradesOptions) Iterator[[]PublicTrade] {
+	return getLastTrades(api, instrument, "", "", opts)
+}
+
+// GetBookSummaryByCurrency retrieves the summary information for all instruments of a currency
+// For details see: https://docs.deribit.com/#public-get_book_summary_by_currency
+func (api *WsApi) GetBookSummaryByCurrency(ctx context.Context, currency string, kind InstrumentKind) ([]BookSummary, error) {
+	return getBookSummaryByCurrency(ctx, api, currency, kind)
+}
+
+// GetChartData returns an iterator over the OHLCV candles of an instrument. Candles are
+// returned in ascending order from the start time. For details see:
+// https://docs.deribit.com/#public-get_tradingview_chart_data
+func (api *WsApi) GetChartData(instrument string, opts GetChartDataOptions) Iterator[[]DeribitCandle] {
+	return getChartData(api, instrument, opts)
+}
+
+// GetFundingRateHistory returns an iterator over the hourly funding rates of a perpetual.
+// Funding rates are returned in ascending order from the start time. For details see:
+// https://docs.deribit.com/#public-get_funding_rate_history
+func (api *WsApi) GetFundingRateHistory(instrument string, opts GetFundingRateHistoryOptions) Iterator[[]FundingRate] {
+	return getFundingRateHistory(api, instrument, opts)
+}
+
+// GetFundingChartData returns the funding of a perpetual over a length of time, which is
+// one of "8h", "24h" or "1m".
+func (api *WsApi) GetFundingChartData(ctx context.Context, instrument string, length string) (FundingChartData, error) {
+	return getFundingChartData(ctx, api, instrument, length)
+}
+
+// GetHistoricalVolatility returns the hourly realised volatility of a currency over the
+// past 15 days.
+func (api *WsApi) GetHistoricalVolatility(ctx context.Context, currency string) ([]HistoricalVolatility, error) {
+	return getHistoricalVolatility(ctx, api, currency)
+}
+
+// GetVolatilityIndexData returns an iterator over the candles of a currency's volatility
+// index (DVOL). Candles are returned in pages from the end time backwards, with each page
+// in ascending order.
+func (api *WsApi) GetVolatilityIndexData(currency string, opts GetVolatilityIndexDataOptions) Iterator[[]VolatilityCandle] {
+	return getVolatilityIndexData(api, currency, opts)
+}
+
+// GetSettlementHistoryByInstrument returns an iterator over the settlement events of an
+// instrument, from the most recent. The WsApi must have been created with credentials.
+// The Credentials field of the params is ignored.
+func (api *WsApi) GetSettlementHistoryByInstrument(instrument string, p GetSettlementHistoryParams) Iterator[[]Settlement] {
+	return getSettlementHistory(api, instrument, p)
+}
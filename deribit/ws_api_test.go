@@ -0,0 +1,209 @@
+package deribit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestWsServer starts a websocket server which responds to each JSON-RPC request with
+// the result returned by respond. No response is sent if respond returns nil.
+func newTestWsServer(t *testing.T, respond func(method string, params map[string]interface{}) interface{}) *httptest.Server {
+	var upgrader websocket.Upgrader
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req struct {
+				Id     int64                  `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			result := respond(req.Method, req.Params)
+			if result == nil {
+				continue
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestWsApi(t *testing.T) {
+	server := newTestWsServer(t, func(method string, params map[string]interface{}) interface{} {
+		switch method {
+		case "public/get_index_price":
+			assert.Equal(t, "btc_usd", params["index_name"])
+			return map[string]interface{}{"index_price": 50000.5, "estimated_delivery_price": 50001}
+		case "public/get_last_trades_by_instrument":
+			// Numeric params are sent as JSON numbers.
+			assert.Equal(t, float64(10), params["count"])
+			return map[string]interface{}{
+				"trades":   []map[string]interface{}{{"trade_id": "1", "trade_seq": 5, "price": 100}},
+				"has_more": false,
+			}
+		}
+		return nil
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := NewWsApi("ws" + strings.TrimPrefix(server.URL, "http"))
+	assert.Nil(t, api.Start(ctx))
+
+	price, err := api.GetIndexPrice(ctx, "btc_usd")
+	assert.Nil(t, err)
+	assert.Equal(t, IndexPrice{IndexPrice: 50000.5, EstimatedDeliveryPrice: 50001}, price)
+
+	it := api.GetLastTradesByInstrument("BTC-PERPETUAL", nil)
+	trades, err := it.Next(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, trades, 1)
+	assert.Equal(t, "1", trades[0].TradeId)
+	assert.True(t, it.Done())
+
+	// The server never responds to get_currencies so the request times out.
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer timeoutCancel()
+	_, err = api.GetCurrencies(timeoutCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	api.m.Lock()
+	assert.Len(t, api.pending, 0)
+	api.m.Unlock()
+
+	cancel()
+	<-api.Err()
+	_, err = api.GetIndexPrice(context.Background(), "btc_usd")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeRpcResponse(t *testing.T) {
+	var price IndexPrice
+	err := decodeRpcResponse([]byte(`{"id": 1, "result": {"index_price": 1.5}}`), &price)
+	assert.Nil(t, err)
+	assert.Equal(t, IndexPrice{IndexPrice: 1.5}, price)
+
+	err = decodeRpcResponse([]byte(`{"id": 1, "error": {"code": 10028, "message": "too_many_requests"}}`), &price)
+	assert.Equal(t, Error{Code: 10028, Message: "too_many_requests"}, err)
+}
+
+func TestStringParams(t *testing.T) {
+	params := map[string]interface{}{"a": "x", "b": true, "c": 10, "d": int64(1700000000000), "e": 0.25}
+	expected := map[string]string{"a": "x", "b": "true", "c": "10", "d": "1700000000000", "e": "0.25"}
+	assert.Equal(t, expected, stringParams(params))
+}
+
+func TestWsApiAuth(t *testing.T) {
+	// The server answers auth requests on the first connection immediately, and on later
+	// connections once released.
+	release := make(chan struct{})
+	var m sync.Mutex
+	var methods []string
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var wm sync.Mutex
+		write := func(id int64, result interface{}) {
+			wm.Lock()
+			defer wm.Unlock()
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+		}
+		for {
+			var req struct {
+				Id     int64  `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			m.Lock()
+			methods = append(methods, req.Method)
+			auths := 0
+			for _, method := range methods {
+				if method == "public/auth" {
+					auths++
+				}
+			}
+			m.Unlock()
+			switch req.Method {
+			case "public/auth":
+				token := map[string]interface{}{"access_token": "access", "expires_in": 3600}
+				if auths == 1 {
+					write(req.Id, token)
+				} else {
+					go func(id int64) {
+						<-release
+						write(id, token)
+					}(req.Id)
+				}
+			case "private/get_positions":
+				write(req.Id, []interface{}{})
+			}
+		}
+	}))
+	defer server.Close()
+	received := func(method string) bool {
+		m.Lock()
+		defer m.Unlock()
+		for _, got := range methods {
+			if got == method {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := NewWsApi("ws"+strings.TrimPrefix(server.URL, "http"), tk.WithCredentials(tk.Credentials{ClientId: "id", ClientSecret: "secret"}))
+	assert.Nil(t, api.Start(ctx))
+	// Start returns once the connection is authenticated.
+	api.auth.m.Lock()
+	assert.Equal(t, "access", api.auth.token.AccessToken)
+	api.auth.m.Unlock()
+
+	// Private requests are held until the new connection is authenticated.
+	api.ws.Reset()
+	assert.Eventually(t, func() bool {
+		api.m.Lock()
+		defer api.m.Unlock()
+		return !api.ready
+	}, time.Second, time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.GetPositions(ctx, GetPositionsParams{})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, received("private/get_positions"))
+	close(release)
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected a response to the private request")
+	}
+}
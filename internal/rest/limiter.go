@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter. Tokens are added at a constant rate up to a
+// maximum of burst tokens. It's safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewLimiter creates a Limiter which adds rate tokens per second, up to a maximum of
+// burst tokens. The bucket starts full.
+func NewLimiter(rate float64, burst float64) *Limiter {
+	return &Limiter{rate: rate, burst: burst, tokens: burst, now: time.Now}
+}
+
+// refill adds the tokens accumulated since the last call. The lock must be held.
+func (l *Limiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller must wait
+// before the tokens are available.
+func (l *Limiter) reserve(n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens -= n
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until n tokens are available, or the context is done.
+func (l *Limiter) Wait(ctx context.Context, n float64) error {
+	wait := l.reserve(n)
+	if wait == 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		// Give the tokens back, we didn't use them.
+		l.mu.Lock()
+		l.tokens += n
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Observe reports the number of tokens the server says are remaining. The bucket is
+// reduced to match if it holds more tokens than this, but it's never increased.
+func (l *Limiter) Observe(remaining float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens > remaining {
+		l.tokens = remaining
+	}
+}
@@ -0,0 +1,178 @@
+// Package rest provides the HTTP layer shared by the exchange Api types. It applies
+// client side rate limiting, per-request timeouts, and retries with exponential backoff.
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+)
+
+const (
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+// rateLimitError marks an error as being caused by a rate limit. See [RateLimited].
+type rateLimitError struct {
+	err error
+}
+
+func (e rateLimitError) Error() string {
+	return e.err.Error()
+}
+
+func (e rateLimitError) Unwrap() error {
+	return e.err
+}
+
+// RateLimited wraps an error to indicate that the server rejected a request because a
+// rate limit was exceeded. A handler passed to [Client.Do] should return it for rate
+// limit errors which aren't signalled by a 429 status code.
+func RateLimited(err error) error {
+	return rateLimitError{err}
+}
+
+// Client sends HTTP requests to an exchange API. It's safe for concurrent use.
+type Client struct {
+	http       *http.Client
+	limiter    *Limiter
+	timeout    time.Duration
+	maxRetries int
+	logger     tk.Logger
+}
+
+// NewClient creates a new Client which limits its requests with the provided Limiter.
+// The HttpClient, HttpTimeout, MaxRetries and Logger are taken from the params.
+func NewClient(limiter *Limiter, p *tk.Params) *Client {
+	client := p.HttpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		http:       client,
+		limiter:    limiter,
+		timeout:    p.HttpTimeout,
+		maxRetries: p.MaxRetries,
+		logger:     p.Logger,
+	}
+}
+
+// Limiter returns the rate limiter of the client.
+func (c *Client) Limiter() *Limiter {
+	return c.limiter
+}
+
+// Do sends the request created by newRequest and passes the response to handle. A new
+// request is created for every attempt so that signed requests have a fresh timestamp.
+// The response body is closed after handle returns. Each attempt takes weight tokens from
+// the limiter, which is the cost of the request towards the server's rate limit.
+//
+// Requests are retried after a backoff if the server responds with status 429, or if
+// handle returns an error wrapped with [RateLimited]. Idempotent requests are also
+// retried on network errors and 5xx status codes. A request isn't retried more than
+// MaxRetries times. If the request can't be retried, the last response is passed to
+// handle so that the error can be decoded.
+func (c *Client) Do(ctx context.Context, weight float64, newRequest func() (*http.Request, error), handle func(*http.Response) error) error {
+	for attempt := 0; ; attempt++ {
+		canRetry := attempt < c.maxRetries
+		if err := c.limiter.Wait(ctx, weight); err != nil {
+			return err
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		retryAfter, err := c.do(ctx, req, canRetry, handle)
+		if err == nil {
+			return nil
+		}
+		var rl rateLimitError
+		if errors.As(err, &rl) {
+			c.limiter.Observe(0)
+			err = rl.err
+		}
+		if !canRetry || retryAfter < 0 {
+			return err
+		}
+
+		wait := retryAfter
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		c.logger.Info(fmt.Sprintf("%s %s: %s, retrying in %s", req.Method, req.URL.Path, err, wait))
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// do performs a single attempt of a request. If the request may be retried it returns
+// an error along with the time to wait, where zero means the default backoff. A negative
+// wait means the error is final.
+func (c *Client) do(ctx context.Context, req *http.Request, canRetry bool, handle func(*http.Response) error) (time.Duration, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	r, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		if !isIdempotent(req.Method) {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer r.Body.Close()
+
+	retryable := r.StatusCode == http.StatusTooManyRequests ||
+		(r.StatusCode >= 500 && isIdempotent(req.Method))
+	if retryable && canRetry {
+		if r.StatusCode == http.StatusTooManyRequests {
+			c.limiter.Observe(0)
+		}
+		return retryAfter(r), fmt.Errorf("HTTP %d", r.StatusCode)
+	}
+
+	if err := handle(r); err != nil {
+		var rl rateLimitError
+		if errors.As(err, &rl) {
+			return retryAfter(r), err
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+// backoff returns the time to wait before a retry.
+func backoff(attempt int) time.Duration {
+	d := minBackoff << attempt
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+// retryAfter returns the wait time from the Retry-After header of a response, or zero
+// if it's not set.
+func retryAfter(r *http.Response) time.Duration {
+	if s, err := strconv.Atoi(r.Header.Get("Retry-After")); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return 0
+}
+
+func isIdempotent(method string) bool {
+	return method != http.MethodPost
+}
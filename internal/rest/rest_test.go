@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/lib/tk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(10, 5)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, time.Duration(0), l.reserve(1))
+	}
+	assert.Equal(t, 100*time.Millisecond, l.reserve(1))
+
+	// After a second the bucket holds 10 - 1 = 9 tokens, capped at the burst of 5.
+	now = now.Add(time.Second)
+	l.Observe(100)
+	assert.Equal(t, float64(5), l.tokens)
+	l.Observe(2)
+	assert.Equal(t, float64(2), l.tokens)
+	l.Observe(0)
+	assert.Equal(t, 200*time.Millisecond, l.reserve(2))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 500*time.Millisecond, backoff(0))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, maxBackoff, backoff(10))
+	assert.Equal(t, maxBackoff, backoff(100))
+}
+
+func newTestClient(maxRetries int) *Client {
+	p := tk.DefaultParams()
+	p.MaxRetries = maxRetries
+	return NewClient(NewLimiter(1000, 1000), p)
+}
+
+func TestClientRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := newTestClient(3)
+	c.timeout = time.Second
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}
+	var status int
+	err := c.Do(context.Background(), 1, newRequest, func(r *http.Response) error {
+		status = r.StatusCode
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 3, attempts)
+
+	// POST requests aren't retried on server errors.
+	attempts = 0
+	newRequest = func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, server.URL, nil)
+	}
+	err = c.Do(context.Background(), 1, newRequest, func(r *http.Response) error {
+		status = r.StatusCode
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestClientRateLimitedHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	c := newTestClient(0)
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}
+	limitErr := errors.New("too many requests")
+	err := c.Do(context.Background(), 1, newRequest, func(r *http.Response) error {
+		return RateLimited(limitErr)
+	})
+	assert.Equal(t, limitErr, err)
+	// The limiter is drained when the server reports a rate limit.
+	assert.Greater(t, c.limiter.reserve(1), time.Duration(0))
+}
+
+func TestClientWeight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	now := time.Unix(0, 0)
+	c := newTestClient(0)
+	c.limiter.now = func() time.Time { return now }
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}
+	err := c.Do(context.Background(), 250, newRequest, func(r *http.Response) error { return nil })
+	assert.Nil(t, err)
+	// The request takes its weight from the limiter.
+	assert.Equal(t, float64(750), c.limiter.tokens)
+}
@@ -117,6 +117,7 @@ type Websocket struct {
 	responses chan Message
 	requests  chan []byte
 	close     chan struct{}
+	reset     chan struct{}
 	errc      chan error
 	closed    atomic.Bool
 	wg        sync.WaitGroup
@@ -133,6 +134,7 @@ func New(url string, opts *tradekit.StreamOptions) Websocket {
 		responses: make(chan Message, 10),
 		requests:  make(chan []byte, 10),
 		close:     make(chan struct{}, 1),
+		reset:     make(chan struct{}, 1),
 		errc:      make(chan error, 1),
 		OnConnect: func() error { return nil },
 		opts:      wsOpts,
@@ -167,6 +169,10 @@ func (ws *Websocket) run(ctx context.Context, errc chan error, done chan<- struc
 			}
 			messageType, r, err := conn.NextReader()
 			if err != nil {
+				if stop.Load() {
+					// The connection was closed to reset it.
+					return
+				}
 				errc <- err
 				return
 			}
@@ -195,6 +201,8 @@ func (ws *Websocket) run(ctx context.Context, errc chan error, done chan<- struc
 		pingTicker := time.NewTicker(ws.opts.PingInterval)
 		defer func() {
 			pingTicker.Stop()
+			// Closing the connection unblocks the reader if it's waiting for a message.
+			conn.Close()
 			wg.Done()
 		}()
 		for {
@@ -249,7 +257,6 @@ func (ws *Websocket) Start(ctx context.Context) error {
 			close(ws.responses)
 			close(ws.requests)
 			close(ws.errc)
-			close(ws.close)
 			resetTicker.Stop()
 		}()
 		for {
@@ -261,6 +268,11 @@ func (ws *Websocket) Start(ctx context.Context) error {
 				<-done
 				restartCtx, cancel = context.WithCancel(ctx)
 				go ws.run(restartCtx, errc, done)
+			case <-ws.reset:
+				cancel()
+				<-done
+				restartCtx, cancel = context.WithCancel(ctx)
+				go ws.run(restartCtx, errc, done)
 			case err := <-errc:
 				if websocket.IsCloseError(err, reconnectOn...) {
 					cancel()
@@ -298,7 +310,19 @@ func (ws *Websocket) Close() {
 	if ws.closed.Load() {
 		return
 	}
-	ws.close <- struct{}{}
+	select {
+	case ws.close <- struct{}{}:
+	default:
+	}
+}
+
+// Reset closes the websocket's connection and opens a new one. OnConnect is called again
+// once the new connection is established. This is a no-op if a reset is already pending.
+func (ws *Websocket) Reset() {
+	select {
+	case ws.reset <- struct{}{}:
+	default:
+	}
 }
 
 func (ws *Websocket) Err() <-chan error {
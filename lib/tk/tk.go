@@ -2,6 +2,8 @@ package tk
 
 import (
 	"log"
+	"net/http"
+	"time"
 )
 
 // Logger interface
@@ -42,6 +44,15 @@ type Params struct {
 	Logger Logger
 	*Credentials
 	ChannelBufferSize int
+
+	// HttpClient is the client used by an Api to send requests. Defaults to
+	// http.DefaultClient.
+	HttpClient *http.Client
+	// HttpTimeout is the timeout of each attempt of an Api request.
+	HttpTimeout time.Duration
+	// MaxRetries is the maximum number of times an Api request is retried after it's rate
+	// limited or fails with a server error.
+	MaxRetries int
 }
 
 // Param is a functional option for modifying the Params struct
@@ -66,11 +77,34 @@ func WithChannelBufferSize(size int) Param {
 	}
 }
 
+// WithHttpClient sets the HTTP client used by an Api
+func WithHttpClient(client *http.Client) Param {
+	return func(params *Params) {
+		params.HttpClient = client
+	}
+}
+
+// WithHttpTimeout sets the timeout of each attempt of an Api request
+func WithHttpTimeout(timeout time.Duration) Param {
+	return func(params *Params) {
+		params.HttpTimeout = timeout
+	}
+}
+
+// WithMaxRetries sets the maximum number of retries of an Api request
+func WithMaxRetries(n int) Param {
+	return func(params *Params) {
+		params.MaxRetries = n
+	}
+}
+
 // DefaultParams returns the default optional parameters
 func DefaultParams() *Params {
 	return &Params{
 		Logger:            &NoOpLogger{}, // Default to no-op logger
 		ChannelBufferSize: 10,
+		HttpTimeout:       10 * time.Second,
+		MaxRetries:        3,
 	}
 }
 
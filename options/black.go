@@ -0,0 +1,158 @@
+// Package options prices European options with the Black-76 model, and calculates their
+// greeks and implied volatilities. Implied volatility surfaces are fitted to the smiles
+// of each expiry with a [SurfaceBuilder], and the risk of a portfolio of Deribit options
+// and futures is calculated with a [RiskEngine].
+//
+// Prices and greeks follow Deribit's conventions. Options are priced on the forward (the
+// underlying_price of a Deribit ticker) with a zero interest rate. Volatilities are
+// decimals, so a Deribit mark_iv of 50 is a volatility of 0.5, and times to expiry are in
+// years of 365 days. Inverse (coin-settled) options, such as Deribit's BTC options, are
+// priced in the base currency, while linear options are priced in the quote currency.
+package options
+
+import (
+	"math"
+	"time"
+)
+
+// OptionType specifies whether an option is a call or a put. Its values match Deribit's
+// option_type.
+type OptionType string
+
+const (
+	Call OptionType = "call"
+	Put  OptionType = "put"
+)
+
+// Settlement specifies the currency an option is priced and settled in.
+type Settlement int
+
+const (
+	// Linear options are priced in the quote currency e.g. USDC.
+	Linear Settlement = iota
+	// Inverse options are priced in the base currency e.g. BTC, and settled in the base
+	// currency at expiry.
+	Inverse
+)
+
+// year is the length of a year used for times to expiry.
+const year = 365 * 24 * time.Hour
+
+// Option is a European option.
+type Option struct {
+	Type   OptionType
+	Strike float64
+	// T is the time to expiry in years.
+	T          float64
+	Settlement Settlement
+}
+
+// Greeks are the sensitivities of an option's price in Deribit's conventions. They're
+// the Black-76 greeks of the option's price in the quote currency, whether the option is
+// linear or inverse.
+type Greeks struct {
+	// Delta is the change in price per unit change in the forward.
+	Delta float64
+	// Gamma is the change in delta per unit change in the forward.
+	Gamma float64
+	// Vega is the change in price per 1% change in volatility.
+	Vega float64
+	// Theta is the change in price per calendar day.
+	Theta float64
+	// Rho is the change in price per 1% change in the interest rate.
+	Rho float64
+}
+
+// YearsToExpiry returns the time from now until expiry in years. It's 0 if the option has
+// expired.
+func YearsToExpiry(now, expiry time.Time) float64 {
+	if !expiry.After(now) {
+		return 0
+	}
+	return float64(expiry.Sub(now)) / float64(year)
+}
+
+// Price returns the price of the option given the forward and volatility. The price is
+// in the base currency for inverse options, and the quote currency for linear options.
+func (o Option) Price(forward, vol float64) float64 {
+	p := black(o.Type, forward, o.Strike, o.T, vol)
+	if o.Settlement == Inverse {
+		return p / forward
+	}
+	return p
+}
+
+// Greeks returns the greeks of the option given the forward and volatility.
+func (o Option) Greeks(forward, vol float64) Greeks {
+	f, k, t := forward, o.Strike, o.T
+	if t <= 0 || vol <= 0 {
+		// At expiry, the option's delta is 1 if it's in the money.
+		var g Greeks
+		if o.Type == Call && f > k {
+			g.Delta = 1
+		} else if o.Type == Put && f < k {
+			g.Delta = -1
+		}
+		return g
+	}
+	sqrtT := math.Sqrt(t)
+	d1, d2 := d1d2(f, k, t, vol)
+	pdf := normPDF(d1)
+	g := Greeks{
+		Gamma: pdf / (f * vol * sqrtT),
+		Vega:  f * pdf * sqrtT / 100,
+		Theta: -f * pdf * vol / (2 * sqrtT) / 365,
+	}
+	if o.Type == Call {
+		g.Delta = normCDF(d1)
+		g.Rho = k * t * normCDF(d2) / 100
+	} else {
+		g.Delta = normCDF(d1) - 1
+		g.Rho = -k * t * normCDF(-d2) / 100
+	}
+	return g
+}
+
+// CoinDelta returns the delta of an inverse option in the base currency, which is its
+// delta less its price in the base currency. A position in an inverse option's premium is
+// itself exposed to the base currency. For linear options, it's the same as the delta.
+func (o Option) CoinDelta(forward, vol float64) float64 {
+	delta := o.Greeks(forward, vol).Delta
+	if o.Settlement == Inverse {
+		return delta - o.Price(forward, vol)
+	}
+	return delta
+}
+
+// black returns the undiscounted Black-76 price of an option in the quote currency.
+func black(t OptionType, f, k, tau, vol float64) float64 {
+	if tau <= 0 || vol <= 0 {
+		return intrinsic(t, f, k)
+	}
+	d1, d2 := d1d2(f, k, tau, vol)
+	if t == Call {
+		return f*normCDF(d1) - k*normCDF(d2)
+	}
+	return k*normCDF(-d2) - f*normCDF(-d1)
+}
+
+func d1d2(f, k, t, vol float64) (float64, float64) {
+	sd := vol * math.Sqrt(t)
+	d1 := (math.Log(f/k) + sd*sd/2) / sd
+	return d1, d1 - sd
+}
+
+func intrinsic(t OptionType, f, k float64) float64 {
+	if t == Call {
+		return math.Max(f-k, 0)
+	}
+	return math.Max(k-f, 0)
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
@@ -0,0 +1,68 @@
+package options
+
+import "math"
+
+// Chain is a set of options evaluated together, such as the options of an expiry. It's
+// laid out as a structure of arrays: option i has type Types[i], strike Strikes[i], time
+// to expiry T[i] and forward Forwards[i]. The slices must have the same length, as must
+// the slices passed to its methods. The methods write their results into out if it's
+// long enough, to avoid allocating on each evaluation, and otherwise allocate a new slice.
+type Chain struct {
+	Types      []OptionType
+	Strikes    []float64
+	T          []float64
+	Forwards   []float64
+	Settlement Settlement
+}
+
+// Len returns the number of options in the chain.
+func (c Chain) Len() int {
+	return len(c.Strikes)
+}
+
+// Option returns option i of the chain.
+func (c Chain) Option(i int) Option {
+	return Option{Type: c.Types[i], Strike: c.Strikes[i], T: c.T[i], Settlement: c.Settlement}
+}
+
+// Prices returns the price of each option given its volatility.
+func (c Chain) Prices(vols []float64, out []float64) []float64 {
+	out = resize(out, c.Len())
+	for i := range out {
+		out[i] = c.Option(i).Price(c.Forwards[i], vols[i])
+	}
+	return out
+}
+
+// Greeks returns the greeks of each option given its volatility.
+func (c Chain) Greeks(vols []float64, out []Greeks) []Greeks {
+	if cap(out) < c.Len() {
+		out = make([]Greeks, c.Len())
+	}
+	out = out[:c.Len()]
+	for i := range out {
+		out[i] = c.Option(i).Greeks(c.Forwards[i], vols[i])
+	}
+	return out
+}
+
+// ImpliedVols returns the implied volatility of each option given its price. The
+// volatility is NaN for options with no implied volatility, such as when the price is 0.
+func (c Chain) ImpliedVols(prices []float64, out []float64) []float64 {
+	out = resize(out, c.Len())
+	for i := range out {
+		vol, err := c.Option(i).ImpliedVol(prices[i], c.Forwards[i])
+		if err != nil {
+			vol = math.NaN()
+		}
+		out[i] = vol
+	}
+	return out
+}
+
+func resize(s []float64, n int) []float64 {
+	if cap(s) < n {
+		return make([]float64, n)
+	}
+	return s[:n]
+}
@@ -0,0 +1,83 @@
+package options
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrPriceOutOfBounds is returned by [Option.ImpliedVol] when the price is below the
+// option's intrinsic value, or above its maximum value, so that no volatility gives the
+// price.
+var ErrPriceOutOfBounds = errors.New("options: price is outside the no-arbitrage bounds")
+
+// ErrNoConvergence is returned by [Option.ImpliedVol] if the solver doesn't converge.
+var ErrNoConvergence = errors.New("options: implied volatility did not converge")
+
+const (
+	minVol      = 1e-6
+	maxVol      = 20.0
+	maxIters    = 100
+	volTol      = 1e-10
+	priceTolRel = 1e-14
+)
+
+// ImpliedVol returns the volatility which gives the option the price, in the same
+// currency as [Option.Price]. It uses Newton's method, safeguarded by bisection so that
+// it converges for deep in or out of the money options, where vega is small.
+func (o Option) ImpliedVol(price, forward float64) (float64, error) {
+	if o.Settlement == Inverse {
+		price *= forward
+	}
+	return impliedVol(o.Type, price, forward, o.Strike, o.T)
+}
+
+func impliedVol(t OptionType, price, f, k, tau float64) (float64, error) {
+	if tau <= 0 || f <= 0 || k <= 0 || math.IsNaN(price) {
+		return 0, ErrPriceOutOfBounds
+	}
+	lower := intrinsic(t, f, k)
+	upper := f
+	if t == Put {
+		upper = k
+	}
+	if price < lower || price >= upper {
+		return 0, ErrPriceOutOfBounds
+	}
+	// Solve for the time value of the out of the money option, which is the same for the
+	// call and put by put-call parity, and is more accurate for deep in the money options.
+	otm := Call
+	if k < f {
+		otm = Put
+	}
+	target := price - lower
+	if target <= priceTolRel*f {
+		return minVol, nil
+	}
+
+	lo, hi := minVol, maxVol
+	// Newton's method converges monotonically from the initial guess of Manaster and
+	// Koehler, the volatility at which the option's vega is greatest.
+	vol := math.Min(math.Max(math.Sqrt(2*math.Abs(math.Log(f/k))/tau), minVol), maxVol)
+	for i := 0; i < maxIters; i++ {
+		diff := black(otm, f, k, tau, vol) - target
+		if math.Abs(diff) <= priceTolRel*f {
+			return vol, nil
+		}
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		d1, _ := d1d2(f, k, tau, vol)
+		vega := f * normPDF(d1) * math.Sqrt(tau)
+		next := vol - diff/vega
+		if vega == 0 || math.IsNaN(next) || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		if math.Abs(next-vol) < volTol {
+			return next, nil
+		}
+		vol = next
+	}
+	return 0, ErrNoConvergence
+}
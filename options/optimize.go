@@ -0,0 +1,138 @@
+package options
+
+import (
+	"math"
+	"sort"
+)
+
+// nelderMead minimises f with the Nelder-Mead simplex method, starting from x0 with an
+// initial simplex of the given step in each dimension.
+func nelderMead(f func(x []float64) float64, x0, step []float64, maxIters int) []float64 {
+	const (
+		alpha = 1.0
+		gamma = 2.0
+		rho   = 0.5
+		sigma = 0.5
+		tol   = 1e-14
+	)
+	n := len(x0)
+	points := make([][]float64, n+1)
+	values := make([]float64, n+1)
+	for i := range points {
+		points[i] = append([]float64(nil), x0...)
+		if i > 0 {
+			points[i][i-1] += step[i-1]
+		}
+		values[i] = f(points[i])
+	}
+
+	centroid := make([]float64, n)
+	along := func(t float64) []float64 {
+		x := make([]float64, n)
+		for j := range x {
+			x[j] = centroid[j] + t*(points[n][j]-centroid[j])
+		}
+		return x
+	}
+	order := make([]int, n+1)
+	for iter := 0; iter < maxIters; iter++ {
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return less(values[order[a]], values[order[b]]) })
+		sorted := make([][]float64, n+1)
+		sortedValues := make([]float64, n+1)
+		for i, o := range order {
+			sorted[i], sortedValues[i] = points[o], values[o]
+		}
+		points, values = sorted, sortedValues
+
+		if math.Abs(values[n]-values[0]) <= tol*(math.Abs(values[0])+tol) {
+			break
+		}
+
+		for j := range centroid {
+			centroid[j] = 0
+			for i := 0; i < n; i++ {
+				centroid[j] += points[i][j] / float64(n)
+			}
+		}
+
+		reflected := along(-alpha)
+		fr := f(reflected)
+		switch {
+		case less(fr, values[0]):
+			expanded := along(-gamma)
+			if fe := f(expanded); less(fe, fr) {
+				points[n], values[n] = expanded, fe
+			} else {
+				points[n], values[n] = reflected, fr
+			}
+		case less(fr, values[n-1]):
+			points[n], values[n] = reflected, fr
+		default:
+			contracted := along(rho)
+			if fc := f(contracted); less(fc, values[n]) {
+				points[n], values[n] = contracted, fc
+				continue
+			}
+			// Shrink towards the best point.
+			for i := 1; i <= n; i++ {
+				for j := range points[i] {
+					points[i][j] = points[0][j] + sigma*(points[i][j]-points[0][j])
+				}
+				values[i] = f(points[i])
+			}
+		}
+	}
+
+	best := 0
+	for i := range values {
+		if less(values[i], values[best]) {
+			best = i
+		}
+	}
+	return points[best]
+}
+
+// less orders NaN after all other values.
+func less(a, b float64) bool {
+	if math.IsNaN(b) {
+		return !math.IsNaN(a)
+	}
+	return a < b
+}
+
+// solve3 solves the 3x3 linear system ax = b with Gaussian elimination, returning false
+// if a is singular.
+func solve3(a [3][3]float64, b [3]float64) ([3]float64, bool) {
+	for col := 0; col < 3; col++ {
+		pivot := col
+		for row := col + 1; row < 3; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-300 {
+			return [3]float64{}, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < 3; row++ {
+			f := a[row][col] / a[col][col]
+			for j := col; j < 3; j++ {
+				a[row][j] -= f * a[col][j]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	var x [3]float64
+	for row := 2; row >= 0; row-- {
+		x[row] = b[row]
+		for j := row + 1; j < 3; j++ {
+			x[row] -= a[row][j] * x[j]
+		}
+		x[row] /= a[row][row]
+	}
+	return x, true
+}
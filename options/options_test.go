@@ -0,0 +1,190 @@
+package options_test
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/bogdanovich/tradekit/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+// capturedTickers are public/ticker results recorded from Deribit by
+// testdata/capture_tickers.go.
+const capturedTickers = "testdata/deribit_option_tickers.json"
+
+// syntheticTickers are tickers in the format of Deribit's public/ticker result, with
+// prices and greeks computed by this package. They're only used to test the package's
+// consistency, not Deribit's conventions.
+const syntheticTickers = "testdata/synthetic_option_tickers.json"
+
+// loadTickers loads option tickers in the format of Deribit's public/ticker result.
+func loadTickers(t *testing.T, path string) []deribit.DeribitTicker {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := fastjson.ParseBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tickers []deribit.DeribitTicker
+	for _, item := range v.GetArray() {
+		tickers = append(tickers, deribit.ParseDeribitTicker(item))
+	}
+	return tickers
+}
+
+// tickerOption returns the option of a Deribit ticker.
+func tickerOption(t *testing.T, ticker deribit.DeribitTicker) options.Option {
+	name, err := deribit.ParseInstrumentName(ticker.Instrument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settlement := options.Inverse
+	if name.Quote != "" {
+		settlement = options.Linear
+	}
+	return options.Option{
+		Type:       options.OptionType(name.OptionType),
+		Strike:     name.Strike,
+		T:          options.YearsToExpiry(time.UnixMilli(ticker.Timestamp), name.Expiry),
+		Settlement: settlement,
+	}
+}
+
+// ivRounding is the largest error in the volatility of a Deribit ticker from the rounding
+// of its mark IV to 2 decimal places.
+const ivRounding = 0.005 / 100
+
+func TestDeribitTickers(t *testing.T) {
+	if _, err := os.Stat(capturedTickers); os.IsNotExist(err) {
+		t.Skipf("%s is missing, record it with: go run testdata/capture_tickers.go", capturedTickers)
+	}
+	tickers := loadTickers(t, capturedTickers)
+
+	// The tickers include an inverse call and put, and a linear option.
+	type kind struct {
+		settlement options.Settlement
+		typ        options.OptionType
+	}
+	kinds := make(map[kind]bool)
+	for _, ticker := range tickers {
+		o := tickerOption(t, ticker)
+		kinds[kind{o.Settlement, o.Type}] = true
+	}
+	assert.True(t, kinds[kind{options.Inverse, options.Call}], "inverse call")
+	assert.True(t, kinds[kind{options.Inverse, options.Put}], "inverse put")
+	assert.True(t, kinds[kind{options.Linear, options.Call}] || kinds[kind{options.Linear, options.Put}], "linear option")
+
+	for _, ticker := range tickers {
+		o := tickerOption(t, ticker)
+		f := *ticker.UnderlyingPrice
+		vol := *ticker.MarkIV / 100
+
+		// The mark price is between the prices at the bounds of the rounded mark IV, and
+		// its implied volatility rounds to the mark IV.
+		lo, hi := o.Price(f, vol-ivRounding), o.Price(f, vol+ivRounding)
+		assert.True(t, ticker.MarkPrice >= lo-1e-8 && ticker.MarkPrice <= hi+1e-8, ticker.Instrument)
+		iv, err := o.ImpliedVol(ticker.MarkPrice, f)
+		assert.Nil(t, err, ticker.Instrument)
+		assert.InDelta(t, vol, iv, ivRounding+1e-6, ticker.Instrument)
+
+		// Deribit's greeks are rounded to 5 decimal places.
+		g := o.Greeks(f, iv)
+		assert.InDelta(t, ticker.Greeks.Delta, g.Delta, 1e-4, ticker.Instrument)
+		assert.InDelta(t, ticker.Greeks.Gamma, g.Gamma, 1e-5, ticker.Instrument)
+		assert.InEpsilon(t, ticker.Greeks.Vega, g.Vega, 1e-3, ticker.Instrument)
+		assert.InEpsilon(t, ticker.Greeks.Theta, g.Theta, 1e-3, ticker.Instrument)
+		assert.InEpsilon(t, ticker.Greeks.Rho, g.Rho, 1e-3, ticker.Instrument)
+	}
+}
+
+func TestChain(t *testing.T) {
+	tickers := loadTickers(t, syntheticTickers)
+	c := options.Chain{Settlement: options.Inverse}
+	var vols, prices []float64
+	for _, ticker := range tickers {
+		o := tickerOption(t, ticker)
+		if o.Settlement != options.Inverse {
+			continue
+		}
+		c.Types = append(c.Types, o.Type)
+		c.Strikes = append(c.Strikes, o.Strike)
+		c.T = append(c.T, o.T)
+		c.Forwards = append(c.Forwards, *ticker.UnderlyingPrice)
+		vols = append(vols, *ticker.MarkIV/100)
+		prices = append(prices, ticker.MarkPrice)
+	}
+
+	out := make([]float64, c.Len())
+	computed := c.Prices(vols, out)
+	assert.Equal(t, &out[0], &computed[0])
+	for i := range prices {
+		assert.Equal(t, c.Option(i).Price(c.Forwards[i], vols[i]), computed[i])
+	}
+
+	greeks := c.Greeks(vols, nil)
+	assert.Len(t, greeks, c.Len())
+	assert.Equal(t, c.Option(0).Greeks(c.Forwards[0], vols[0]), greeks[0])
+
+	prices[0] = 0
+	ivs := c.ImpliedVols(prices, nil)
+	assert.True(t, math.IsNaN(ivs[0]))
+	for i := 1; i < len(ivs); i++ {
+		assert.InDelta(t, vols[i], ivs[i], ivRounding+1e-6)
+	}
+}
+
+func TestImpliedVol(t *testing.T) {
+	f := 100.0
+	for _, typ := range []options.OptionType{options.Call, options.Put} {
+		for _, k := range []float64{20, 60, 95, 100, 105, 150, 400} {
+			for _, T := range []float64{1.0 / 365, 0.1, 1, 5} {
+				for _, vol := range []float64{0.05, 0.3, 0.8, 2.5} {
+					o := options.Option{Type: typ, Strike: k, T: T, Settlement: options.Linear}
+					p := o.Price(f, vol)
+					// Time values which underflow have no meaningful implied volatility.
+					if p-intrinsic(typ, f, k) < 1e-9 {
+						continue
+					}
+					iv, err := o.ImpliedVol(p, f)
+					assert.Nil(t, err)
+					assert.InDelta(t, vol, iv, 1e-6, "%s k=%v T=%v vol=%v", typ, k, T, vol)
+				}
+			}
+		}
+	}
+
+	o := options.Option{Type: options.Call, Strike: 100, T: 1, Settlement: options.Linear}
+	_, err := o.ImpliedVol(-1, 100)
+	assert.ErrorIs(t, err, options.ErrPriceOutOfBounds)
+	_, err = o.ImpliedVol(100, 100)
+	assert.ErrorIs(t, err, options.ErrPriceOutOfBounds)
+
+	// Inverse prices are in the base currency.
+	inv := options.Option{Type: options.Put, Strike: 50000, T: 0.25, Settlement: options.Inverse}
+	iv, err := inv.ImpliedVol(inv.Price(60000, 0.6), 60000)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.6, iv, 1e-8)
+}
+
+func intrinsic(typ options.OptionType, f, k float64) float64 {
+	if typ == options.Call {
+		return math.Max(f-k, 0)
+	}
+	return math.Max(k-f, 0)
+}
+
+func TestGreeksAtExpiry(t *testing.T) {
+	call := options.Option{Type: options.Call, Strike: 100, Settlement: options.Inverse}
+	assert.Equal(t, options.Greeks{Delta: 1}, call.Greeks(110, 0.5))
+	assert.InDelta(t, 10.0/110, call.Price(110, 0.5), 1e-12)
+	assert.InDelta(t, 1-10.0/110, call.CoinDelta(110, 0.5), 1e-12)
+	put := options.Option{Type: options.Put, Strike: 100, Settlement: options.Linear}
+	assert.Equal(t, options.Greeks{}, put.Greeks(110, 0.5))
+	assert.Equal(t, float64(0), options.YearsToExpiry(time.Unix(10, 0), time.Unix(5, 0)))
+}
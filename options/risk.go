@@ -0,0 +1,244 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bogdanovich/tradekit/deribit"
+)
+
+const (
+	// spotBump is the relative change in the underlying used to calculate delta and gamma.
+	spotBump = 1e-3
+	// volBump is the change in volatility used to calculate vega.
+	volBump = 1e-3
+	// day is a calendar day in years.
+	day = 1.0 / 365
+)
+
+// RiskGreeks are the greeks of a portfolio's value. Whether the value is in the
+// settlement currency or USD, the greeks are in the same currency as the value:
+//   - Delta is the change in value for a 100% change in the underlying, F·dV/dF. In the
+//     base currency of an inverse portfolio it's the portfolio's exposure to the base
+//     currency, as in Deribit's delta, and in USD it's the dollar delta.
+//   - Gamma is the cash gamma for a 1% change in the underlying, F²·d²V/dF²/100. It's
+//     not the change in Delta, which is (F·dV/dF + F²·d²V/dF²)/100.
+//   - Vega is the change in value for a 1% change in volatility.
+//   - Theta is the change in value over a calendar day.
+type RiskGreeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+}
+
+// PortfolioGreeks are the greeks of a portfolio's value in its settlement currency and
+// in USD.
+type PortfolioGreeks struct {
+	Coin RiskGreeks
+	USD  RiskGreeks
+}
+
+// Scenario is the profit or loss of a portfolio after a relative change in the
+// underlying, such as -0.1 for -10%, and an absolute change in volatility, such as 0.05
+// for +5 volatility points.
+type Scenario struct {
+	SpotShock float64
+	VolShock  float64
+	PnLCoin   float64
+	PnLUSD    float64
+}
+
+// VegaBucket is the vega of the options of an expiry.
+type VegaBucket struct {
+	Expiry   time.Time
+	VegaCoin float64
+	VegaUSD  float64
+}
+
+// RiskEngine revalues a Deribit portfolio of options and futures which are settled in
+// the same currency, to calculate its greeks and scenario profit or loss.
+//
+// Options are priced with the Black-76 model on the forward and volatility of their
+// expiry from a [Surface]. Futures are valued at their mark price. Volatilities are
+// sticky-strike: when the underlying moves, each option keeps its volatility. Every
+// forward moves by the same relative change as the index.
+//
+// Inverse positions are valued in the base currency, following Deribit's conventions:
+// inverse options are priced in the base currency, and an inverse future of size N USD
+// has a value of -N/F. Their USD value is their base currency value at the index price,
+// so the USD greeks include the exposure of the portfolio's equity to the base currency.
+type RiskEngine struct {
+	index      float64
+	equity     float64
+	settlement Settlement
+	positions  []riskPosition
+	// value is the current value of the positions in the settlement currency.
+	value float64
+}
+
+type riskPosition struct {
+	name deribit.InstrumentName
+	// size is in USD for inverse futures, and in the base currency otherwise.
+	size       float64
+	settlement Settlement
+	forward    float64
+	option     Option
+	vol        float64
+}
+
+// NewRiskEngine creates a RiskEngine for a portfolio with the given positions, and
+// equity in its settlement currency, such as the Equity of a
+// deribit.DeribitUserPortfolioCurrency. The surface is required if the portfolio has
+// options. It returns an error if a position can't be valued, or the positions are
+// settled in more than one currency or are on more than one index.
+func NewRiskEngine(surface *Surface, indexPrice float64, equity float64, positions []deribit.DeribitPosition) (*RiskEngine, error) {
+	e := &RiskEngine{index: indexPrice, equity: equity}
+	var currency, index string
+	for _, p := range positions {
+		name, err := deribit.ParseInstrumentName(p.InstrumentName)
+		if err != nil {
+			return nil, err
+		}
+		if currency == "" {
+			currency = name.SettlementCurrency()
+		} else if name.SettlementCurrency() != currency {
+			return nil, fmt.Errorf("options: positions are settled in %s and %s", currency, name.SettlementCurrency())
+		}
+		// The positions are valued on one surface and index price.
+		if index == "" {
+			index = name.IndexName()
+		} else if name.IndexName() != index {
+			return nil, fmt.Errorf("options: positions are on the indexes %s and %s", index, name.IndexName())
+		}
+		settlement := Inverse
+		if name.Quote != "" {
+			settlement = Linear
+		}
+		e.settlement = settlement
+
+		rp := riskPosition{name: name, size: p.Size, settlement: settlement}
+		switch name.Kind {
+		case deribit.OptionInstrument:
+			if surface == nil || len(surface.Slices) == 0 {
+				return nil, fmt.Errorf("options: a surface is required to value %s", p.InstrumentName)
+			}
+			t := surface.T(name.Expiry)
+			rp.option = Option{Type: OptionType(name.OptionType), Strike: name.Strike, T: t, Settlement: settlement}
+			rp.forward = surface.Forward(t)
+			rp.vol = surface.VolAtStrike(name.Strike, t)
+		case deribit.FutureInstrument:
+			rp.forward = p.MarkPrice
+			if rp.forward == 0 {
+				rp.forward = indexPrice
+			}
+			if settlement == Linear {
+				rp.size = p.SizeCurrency
+			}
+		default:
+			return nil, fmt.Errorf("options: unsupported instrument %s", p.InstrumentName)
+		}
+		e.positions = append(e.positions, rp)
+		e.value += rp.value(0, 0, 0)
+	}
+	return e, nil
+}
+
+// value returns the value of a position in its settlement currency after the changes to
+// the underlying, volatility and time.
+func (p riskPosition) value(spotShock, volShock, dt float64) float64 {
+	f := p.forward * (1 + spotShock)
+	if p.name.Kind == deribit.FutureInstrument {
+		if p.settlement == Inverse {
+			return -p.size / f
+		}
+		return p.size * f
+	}
+	o := p.option
+	o.T = math.Max(o.T-dt, 0)
+	return p.size * o.Price(f, math.Max(p.vol+volShock, minVol))
+}
+
+// values returns the value of the portfolio's positions in the settlement currency, and
+// the value of its equity in USD, after the changes to the underlying, volatility and
+// time.
+func (e *RiskEngine) values(spotShock, volShock, dt float64) (float64, float64) {
+	var coin float64
+	for _, p := range e.positions {
+		coin += p.value(spotShock, volShock, dt)
+	}
+	return coin, e.usdValue(e.equity+coin-e.value, spotShock)
+}
+
+func (e *RiskEngine) usdValue(equity float64, spotShock float64) float64 {
+	if e.settlement == Linear {
+		return equity
+	}
+	return equity * e.index * (1 + spotShock)
+}
+
+// Greeks returns the greeks of the portfolio in its settlement currency and in USD.
+func (e *RiskEngine) Greeks() PortfolioGreeks {
+	c0, u0 := e.values(0, 0, 0)
+	cUp, uUp := e.values(spotBump, 0, 0)
+	cDown, uDown := e.values(-spotBump, 0, 0)
+	cVolUp, uVolUp := e.values(0, volBump, 0)
+	cVolDown, uVolDown := e.values(0, -volBump, 0)
+	cDay, uDay := e.values(0, 0, day)
+	greeks := func(v0, up, down, volUp, volDown, nextDay float64) RiskGreeks {
+		return RiskGreeks{
+			Delta: (up - down) / (2 * spotBump),
+			Gamma: (up - 2*v0 + down) / (spotBump * spotBump) / 100,
+			Vega:  (volUp - volDown) / (2 * volBump) / 100,
+			Theta: nextDay - v0,
+		}
+	}
+	return PortfolioGreeks{
+		Coin: greeks(c0, cUp, cDown, cVolUp, cVolDown, cDay),
+		USD:  greeks(u0, uUp, uDown, uVolUp, uVolDown, uDay),
+	}
+}
+
+// ScenarioGrid returns the profit or loss of the portfolio in each combination of a
+// relative change in the underlying and an absolute change in volatility. The result is
+// indexed by spot shock, then vol shock.
+func (e *RiskEngine) ScenarioGrid(spotShocks, volShocks []float64) [][]Scenario {
+	c0, u0 := e.values(0, 0, 0)
+	grid := make([][]Scenario, len(spotShocks))
+	for i, s := range spotShocks {
+		grid[i] = make([]Scenario, len(volShocks))
+		for j, v := range volShocks {
+			c, u := e.values(s, v, 0)
+			grid[i][j] = Scenario{SpotShock: s, VolShock: v, PnLCoin: c - c0, PnLUSD: u - u0}
+		}
+	}
+	return grid
+}
+
+// VegaLadder returns the vega of the portfolio's options in each expiry, in order of
+// expiry.
+func (e *RiskEngine) VegaLadder() []VegaBucket {
+	buckets := make(map[int64]*VegaBucket)
+	for _, p := range e.positions {
+		if p.name.Kind != deribit.OptionInstrument {
+			continue
+		}
+		key := p.name.Expiry.UnixMilli()
+		b, ok := buckets[key]
+		if !ok {
+			b = &VegaBucket{Expiry: p.name.Expiry}
+			buckets[key] = b
+		}
+		vega := (p.value(0, volBump, 0) - p.value(0, -volBump, 0)) / (2 * volBump) / 100
+		b.VegaCoin += vega
+		b.VegaUSD += e.usdValue(vega, 0)
+	}
+	ladder := make([]VegaBucket, 0, len(buckets))
+	for _, b := range buckets {
+		ladder = append(ladder, *b)
+	}
+	sort.Slice(ladder, func(i, j int) bool { return ladder[i].Expiry.Before(ladder[j].Expiry) })
+	return ladder
+}
@@ -0,0 +1,139 @@
+package options_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/bogdanovich/tradekit/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// flatSurface returns a surface with a constant volatility and forward.
+func flatSurface(now time.Time, vol, forward float64, expiries ...time.Time) *options.Surface {
+	var slices []options.Slice
+	for _, expiry := range expiries {
+		T := options.YearsToExpiry(now, expiry)
+		slices = append(slices, options.Slice{
+			Expiry:  expiry,
+			T:       T,
+			Forward: forward,
+			Smile:   options.SVI{A: vol * vol * T, Sigma: 0.1},
+			KMin:    -1,
+			KMax:    1,
+		})
+	}
+	return options.NewSurface(now, slices)
+}
+
+func TestRiskEngineGreeks(t *testing.T) {
+	now := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC)
+	dec := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	f, vol := 90000.0, 0.6
+	s := flatSurface(now, vol, f, dec, mar)
+
+	call := options.Option{Type: options.Call, Strike: 100000, T: s.T(dec), Settlement: options.Inverse}
+	size := 2.0
+	price := call.Price(f, vol)
+	g := call.Greeks(f, vol)
+
+	// The equity is the value of the option, as if it were bought with cash.
+	e, err := options.NewRiskEngine(s, f, size*price, []deribit.DeribitPosition{
+		{InstrumentName: "BTC-27DEC24-100000-C", Kind: deribit.OptionInstrument, Size: size},
+	})
+	assert.Nil(t, err)
+	greeks := e.Greeks()
+
+	// In the base currency, delta and vega follow Deribit's inverse conventions.
+	assert.InDelta(t, size*call.CoinDelta(f, vol), greeks.Coin.Delta, 1e-6)
+	assert.InDelta(t, size*g.Vega/f, greeks.Coin.Vega, 1e-8)
+	// In USD, the option is worth its Black-76 price.
+	assert.InEpsilon(t, size*g.Delta*f, greeks.USD.Delta, 1e-5)
+	assert.InDelta(t, size*g.Gamma*f*f/100, greeks.USD.Gamma, 1e-2)
+	assert.InDelta(t, size*g.Vega, greeks.USD.Vega, 1e-3)
+	assert.InDelta(t, size*g.Theta, greeks.USD.Theta, 1)
+
+	// A short inverse future of 90000 USD is an exposure of -1 BTC.
+	e, err = options.NewRiskEngine(s, f, 0, []deribit.DeribitPosition{
+		{InstrumentName: "BTC-PERPETUAL", Kind: deribit.FutureInstrument, Size: -90000, MarkPrice: f},
+	})
+	assert.Nil(t, err)
+	greeks = e.Greeks()
+	assert.InDelta(t, -1, greeks.Coin.Delta, 1e-5)
+	assert.Equal(t, 0.0, greeks.Coin.Vega)
+
+	// Holding 1 BTC of equity, the short future hedges the USD value of the equity.
+	e, err = options.NewRiskEngine(s, f, 1, []deribit.DeribitPosition{
+		{InstrumentName: "BTC-PERPETUAL", Kind: deribit.FutureInstrument, Size: -90000, MarkPrice: f},
+	})
+	assert.Nil(t, err)
+	assert.InDelta(t, 0, e.Greeks().USD.Delta, 1e-3)
+
+	// Linear positions are valued in USDC.
+	e, err = options.NewRiskEngine(nil, 200, 0, []deribit.DeribitPosition{
+		{InstrumentName: "SOL_USDC-PERPETUAL", Kind: deribit.FutureInstrument, Size: 1000, SizeCurrency: 5, MarkPrice: 200},
+	})
+	assert.Nil(t, err)
+	greeks = e.Greeks()
+	assert.InDelta(t, 1000, greeks.Coin.Delta, 1e-6)
+	assert.InDelta(t, 1000, greeks.USD.Delta, 1e-6)
+
+	_, err = options.NewRiskEngine(s, f, 0, []deribit.DeribitPosition{
+		{InstrumentName: "BTC-PERPETUAL", Size: 10},
+		{InstrumentName: "SOL_USDC-PERPETUAL", Size: 10},
+	})
+	assert.ErrorContains(t, err, "settled in BTC and USDC")
+	_, err = options.NewRiskEngine(s, f, 0, []deribit.DeribitPosition{
+		{InstrumentName: "BTC_USDC-PERPETUAL", Size: 10},
+		{InstrumentName: "ETH_USDC-27DEC24-4000-C", Size: 1},
+	})
+	assert.ErrorContains(t, err, "on the indexes btc_usdc and eth_usdc")
+	_, err = options.NewRiskEngine(nil, f, 0, []deribit.DeribitPosition{{InstrumentName: "BTC-27DEC24-100000-C", Size: 1}})
+	assert.ErrorContains(t, err, "surface is required")
+}
+
+func TestRiskEngineScenarios(t *testing.T) {
+	now := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC)
+	dec := time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC)
+	f, vol := 90000.0, 0.6
+	s := flatSurface(now, vol, f, dec, mar)
+
+	positions := []deribit.DeribitPosition{
+		{InstrumentName: "BTC-27DEC24-80000-P", Kind: deribit.OptionInstrument, Size: -3},
+		{InstrumentName: "BTC-28MAR25-120000-C", Kind: deribit.OptionInstrument, Size: 5},
+		{InstrumentName: "BTC-27DEC24-100000-C", Kind: deribit.OptionInstrument, Size: 1},
+		{InstrumentName: "BTC-27DEC24", Kind: deribit.FutureInstrument, Size: 45000, MarkPrice: f},
+	}
+	equity := 10.0
+	e, err := options.NewRiskEngine(s, f, equity, positions)
+	assert.Nil(t, err)
+
+	spotShocks := []float64{-0.1, 0, 0.1}
+	volShocks := []float64{-0.05, 0, 0.05}
+	grid := e.ScenarioGrid(spotShocks, volShocks)
+	assert.Len(t, grid, 3)
+	assert.Equal(t, options.Scenario{}, grid[1][1])
+
+	put := options.Option{Type: options.Put, Strike: 80000, T: s.T(dec), Settlement: options.Inverse}
+	call := options.Option{Type: options.Call, Strike: 100000, T: s.T(dec), Settlement: options.Inverse}
+	longCall := options.Option{Type: options.Call, Strike: 120000, T: s.T(mar), Settlement: options.Inverse}
+	value := func(f, vol float64) float64 {
+		return -3*put.Price(f, vol) + 5*longCall.Price(f, vol) + call.Price(f, vol) - 45000/f
+	}
+	// Spot -10% and vol +5.
+	down := grid[0][2]
+	assert.Equal(t, -0.1, down.SpotShock)
+	assert.Equal(t, 0.05, down.VolShock)
+	pnl := value(0.9*f, vol+0.05) - value(f, vol)
+	assert.InDelta(t, pnl, down.PnLCoin, 1e-12)
+	assert.InDelta(t, (equity+pnl)*0.9*f-equity*f, down.PnLUSD, 1e-6)
+
+	ladder := e.VegaLadder()
+	assert.Len(t, ladder, 2)
+	assert.Equal(t, dec, ladder[0].Expiry)
+	assert.InDelta(t, (-3*put.Greeks(f, vol).Vega+call.Greeks(f, vol).Vega)/f, ladder[0].VegaCoin, 1e-8)
+	assert.InDelta(t, 5*longCall.Greeks(f, vol).Vega, ladder[1].VegaUSD, 1e-3)
+	assert.InDelta(t, ladder[0].VegaCoin+ladder[1].VegaCoin, e.Greeks().Coin.Vega, 1e-9)
+}
@@ -0,0 +1,254 @@
+package options
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Slice is the fitted smile of an expiry.
+type Slice struct {
+	Expiry time.Time
+	// T is the time to expiry in years when the slice was fitted.
+	T       float64
+	Forward float64
+	Smile   SVI
+	// KMin and KMax are the range of log-moneyness of the points the smile was fitted to.
+	KMin float64
+	KMax float64
+}
+
+// Surface is an implied volatility surface made of the fitted smiles of each expiry.
+// Between expiries, total implied variance is interpolated linearly in time at constant
+// log-moneyness. Before the first expiry, total variance is scaled down with time, and
+// after the last expiry the volatility of the last smile is held constant. A Surface is
+// immutable, and safe for concurrent use.
+type Surface struct {
+	// Time is the time the surface was built at, from which times to expiry are measured.
+	Time   time.Time
+	Slices []Slice
+}
+
+// NewSurface creates a Surface from the smiles of each expiry at a time.
+func NewSurface(now time.Time, slices []Slice) *Surface {
+	s := &Surface{Time: now, Slices: append([]Slice(nil), slices...)}
+	sort.Slice(s.Slices, func(i, j int) bool { return s.Slices[i].T < s.Slices[j].T })
+	return s
+}
+
+// T returns the time to an expiry in years from the surface's time.
+func (s *Surface) T(expiry time.Time) float64 {
+	return YearsToExpiry(s.Time, expiry)
+}
+
+// TotalVariance returns the total implied variance at log-moneyness k and time to expiry
+// t.
+func (s *Surface) TotalVariance(k, t float64) float64 {
+	n := len(s.Slices)
+	if n == 0 || t <= 0 {
+		return 0
+	}
+	i := sort.Search(n, func(i int) bool { return s.Slices[i].T >= t })
+	switch {
+	case i == 0:
+		first := s.Slices[0]
+		return math.Max(first.Smile.TotalVariance(k), 0) * t / first.T
+	case i == n:
+		last := s.Slices[n-1]
+		return math.Max(last.Smile.TotalVariance(k), 0) * t / last.T
+	}
+	lo, hi := s.Slices[i-1], s.Slices[i]
+	wLo := math.Max(lo.Smile.TotalVariance(k), 0)
+	wHi := math.Max(hi.Smile.TotalVariance(k), 0)
+	return wLo + (wHi-wLo)*(t-lo.T)/(hi.T-lo.T)
+}
+
+// Vol returns the implied volatility at log-moneyness k and time to expiry t.
+func (s *Surface) Vol(k, t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	return math.Sqrt(s.TotalVariance(k, t) / t)
+}
+
+// Forward returns the forward price at time to expiry t, interpolated linearly between
+// expiries.
+func (s *Surface) Forward(t float64) float64 {
+	n := len(s.Slices)
+	if n == 0 {
+		return 0
+	}
+	i := sort.Search(n, func(i int) bool { return s.Slices[i].T >= t })
+	switch {
+	case i == 0:
+		return s.Slices[0].Forward
+	case i == n:
+		return s.Slices[n-1].Forward
+	}
+	lo, hi := s.Slices[i-1], s.Slices[i]
+	return lo.Forward + (hi.Forward-lo.Forward)*(t-lo.T)/(hi.T-lo.T)
+}
+
+// VolAtStrike returns the implied volatility of a strike at time to expiry t.
+func (s *Surface) VolAtStrike(strike, t float64) float64 {
+	return s.Vol(math.Log(strike/s.Forward(t)), t)
+}
+
+// ATMVol returns the at the money forward implied volatility at time to expiry t.
+func (s *Surface) ATMVol(t float64) float64 {
+	return s.Vol(0, t)
+}
+
+// VolAtDelta returns the implied volatility of the option with the given forward delta
+// at time to expiry t. A positive delta is the delta of a call, and a negative delta the
+// delta of a put, so that VolAtDelta(-0.25, t) is the volatility of the 25 delta put.
+func (s *Surface) VolAtDelta(delta, t float64) float64 {
+	return s.Vol(s.DeltaToLogMoneyness(delta, t), t)
+}
+
+// DeltaToLogMoneyness returns the log-moneyness of the option with the given forward
+// delta at time to expiry t. A positive delta is the delta of a call, and a negative
+// delta the delta of a put. It returns NaN if the delta is not in (-1, 1).
+func (s *Surface) DeltaToLogMoneyness(delta, t float64) float64 {
+	if delta <= -1 || delta >= 1 || delta == 0 || t <= 0 {
+		return math.NaN()
+	}
+	callDelta := delta
+	if delta < 0 {
+		callDelta = 1 + delta
+	}
+	// The call delta N(d1) decreases with log-moneyness on an arbitrage free smile, so
+	// find the log-moneyness by bisection.
+	d := func(k float64) float64 {
+		w := s.TotalVariance(k, t)
+		if w <= 0 {
+			if k < 0 {
+				return 1
+			}
+			return 0
+		}
+		return normCDF((-k + w/2) / math.Sqrt(w))
+	}
+	bound := 1.0
+	for d(-bound) < callDelta && bound < 1e3 {
+		bound *= 2
+	}
+	for d(bound) > callDelta && bound < 1e3 {
+		bound *= 2
+	}
+	lo, hi := -bound, bound
+	for i := 0; i < 100 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if d(mid) > callDelta {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// RiskReversal returns the risk reversal at a delta and time to expiry t: the implied
+// volatility of the call less the volatility of the put with the same absolute delta.
+// For example, RiskReversal(0.25, t) is the 25 delta risk reversal.
+func (s *Surface) RiskReversal(delta, t float64) float64 {
+	delta = math.Abs(delta)
+	return s.VolAtDelta(delta, t) - s.VolAtDelta(-delta, t)
+}
+
+// Butterfly returns the butterfly at a delta and time to expiry t: the mean implied
+// volatility of the call and put with the same absolute delta, less the at the money
+// volatility. For example, Butterfly(0.25, t) is the 25 delta butterfly.
+func (s *Surface) Butterfly(delta, t float64) float64 {
+	delta = math.Abs(delta)
+	return (s.VolAtDelta(delta, t)+s.VolAtDelta(-delta, t))/2 - s.ATMVol(t)
+}
+
+// TermPoint is the at the money volatility and skew of an expiry.
+type TermPoint struct {
+	Expiry  time.Time
+	T       float64
+	Forward float64
+	ATMVol  float64
+	// RR25 is the 25 delta risk reversal.
+	RR25 float64
+	// BF25 is the 25 delta butterfly.
+	BF25 float64
+}
+
+// TermStructure returns the at the money volatility, 25 delta risk reversal and 25 delta
+// butterfly of each expiry of the surface, in order of expiry.
+func (s *Surface) TermStructure() []TermPoint {
+	points := make([]TermPoint, len(s.Slices))
+	for i, sl := range s.Slices {
+		points[i] = TermPoint{
+			Expiry:  sl.Expiry,
+			T:       sl.T,
+			Forward: sl.Forward,
+			ATMVol:  s.ATMVol(sl.T),
+			RR25:    s.RiskReversal(0.25, sl.T),
+			BF25:    s.Butterfly(0.25, sl.T),
+		}
+	}
+	return points
+}
+
+// ArbitrageType is the type of static arbitrage found in a surface.
+type ArbitrageType string
+
+const (
+	// ButterflyArbitrage is a negative risk-neutral density in the smile of an expiry.
+	ButterflyArbitrage ArbitrageType = "butterfly"
+	// CalendarArbitrage is a total variance which decreases between an expiry and the
+	// next.
+	CalendarArbitrage ArbitrageType = "calendar"
+)
+
+// Arbitrage is a static arbitrage in a surface at log-moneyness K of an expiry. For
+// calendar arbitrage, Expiry is the later of the two expiries.
+type Arbitrage struct {
+	Type   ArbitrageType
+	Expiry time.Time
+	K      float64
+}
+
+// arbitrageGridPoints is the number of log-moneyness points checked for arbitrage in
+// each smile.
+const arbitrageGridPoints = 101
+
+// CheckArbitrage checks the smiles of the surface for butterfly arbitrage, and
+// consecutive expiries for calendar arbitrage, over the range of log-moneyness each
+// smile was fitted to. It returns the first arbitrage found of each type in each
+// expiry, or nil if the surface is free of arbitrage.
+func (s *Surface) CheckArbitrage() []Arbitrage {
+	var found []Arbitrage
+	for i, sl := range s.Slices {
+		grid := linspace(sl.KMin, sl.KMax, arbitrageGridPoints)
+		for _, k := range grid {
+			if sl.Smile.Density(k) < -1e-9 {
+				found = append(found, Arbitrage{Type: ButterflyArbitrage, Expiry: sl.Expiry, K: k})
+				break
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		prev := s.Slices[i-1]
+		grid = linspace(math.Min(prev.KMin, sl.KMin), math.Max(prev.KMax, sl.KMax), arbitrageGridPoints)
+		for _, k := range grid {
+			if sl.Smile.TotalVariance(k) < prev.Smile.TotalVariance(k)-1e-9 {
+				found = append(found, Arbitrage{Type: CalendarArbitrage, Expiry: sl.Expiry, K: k})
+				break
+			}
+		}
+	}
+	return found
+}
+
+func linspace(lo, hi float64, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = lo + (hi-lo)*float64(i)/float64(n-1)
+	}
+	return x
+}
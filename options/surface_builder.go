@@ -0,0 +1,307 @@
+package options
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bogdanovich/tradekit/deribit"
+)
+
+// SurfaceModel specifies how a [SurfaceBuilder] fits the smiles of a surface.
+type SurfaceModel int
+
+const (
+	// SVIModel fits an independent SVI smile to each expiry with at least 5 strikes.
+	SVIModel SurfaceModel = iota
+	// SSVIModel fits a single SSVI surface to all expiries with at least 3 strikes, which
+	// is free of static arbitrage by construction.
+	SSVIModel
+)
+
+// minSSVIPoints is the number of strikes an expiry needs to be included in an SSVI fit.
+const minSSVIPoints = 3
+
+// SurfaceBuilder collects the mark implied volatilities of the options on a Deribit
+// index, and builds a [Surface] from them. It's fed with tickers from
+// deribit.NewTickerStream, or mark prices from deribit.NewMarkPriceOptionsStream, and
+// should only be fed the options of a single index. A SurfaceBuilder is safe for
+// concurrent use, so it may be fed by one goroutine and built by another.
+type SurfaceBuilder struct {
+	model SurfaceModel
+
+	mu       sync.Mutex
+	quotes   map[string]surfaceQuote
+	forwards map[int64]float64
+}
+
+type surfaceQuote struct {
+	expiry     time.Time
+	strike     float64
+	typ        OptionType
+	settlement Settlement
+	vol        float64
+	markPrice  float64
+}
+
+// NewSurfaceBuilder creates a SurfaceBuilder which fits surfaces with the given model.
+func NewSurfaceBuilder(model SurfaceModel) *SurfaceBuilder {
+	return &SurfaceBuilder{
+		model:    model,
+		quotes:   make(map[string]surfaceQuote),
+		forwards: make(map[int64]float64),
+	}
+}
+
+// UpdateTicker updates the builder with the mark implied volatility and underlying price
+// of an option ticker. Tickers of other instruments are ignored.
+func (b *SurfaceBuilder) UpdateTicker(t deribit.DeribitTicker) {
+	if t.MarkIV == nil {
+		return
+	}
+	q, ok := newSurfaceQuote(t.Instrument, *t.MarkIV/100, t.MarkPrice)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.quotes[t.Instrument] = q
+	if t.UnderlyingPrice != nil && *t.UnderlyingPrice > 0 {
+		b.forwards[q.expiry.UnixMilli()] = *t.UnderlyingPrice
+	}
+}
+
+// UpdateMarkPrices updates the builder with the mark prices and implied volatilities of
+// options. The markprice.options channel doesn't include forward prices, so if the
+// builder hasn't received a ticker for an expiry its forward is implied from the mark
+// prices of its calls and puts by put-call parity.
+func (b *SurfaceBuilder) UpdateMarkPrices(prices []deribit.DeribitOptionMarkPrice) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, p := range prices {
+		if q, ok := newSurfaceQuote(p.Instrument, p.IV, p.MarkPrice); ok {
+			b.quotes[p.Instrument] = q
+		}
+	}
+}
+
+// Remove removes an option from the builder, such as when it's settled. Expired options
+// are also removed by Build.
+func (b *SurfaceBuilder) Remove(instrument string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.quotes, instrument)
+}
+
+func newSurfaceQuote(instrument string, vol, markPrice float64) (surfaceQuote, bool) {
+	name, err := deribit.ParseInstrumentName(instrument)
+	if err != nil || name.Kind != deribit.OptionInstrument || vol <= 0 {
+		return surfaceQuote{}, false
+	}
+	settlement := Inverse
+	if name.Quote != "" {
+		settlement = Linear
+	}
+	return surfaceQuote{
+		expiry:     name.Expiry,
+		strike:     name.Strike,
+		typ:        OptionType(name.OptionType),
+		settlement: settlement,
+		vol:        vol,
+		markPrice:  markPrice,
+	}, true
+}
+
+// expiryPoints are the log-moneyness and total variance of the strikes of an expiry.
+type expiryPoints struct {
+	expiry  time.Time
+	t       float64
+	forward float64
+	k       []float64
+	w       []float64
+}
+
+// Build fits a surface to the options of each unexpired expiry at a time. The volatility
+// of each strike is the volatility of its out of the money option. Expiries without
+// enough strikes for the builder's model are excluded, and ErrInsufficientData is
+// returned if no expiries can be fitted.
+func (b *SurfaceBuilder) Build(now time.Time) (*Surface, error) {
+	expiries := b.points(now)
+	var slices []Slice
+	switch b.model {
+	case SSVIModel:
+		var thetas []float64
+		var ks, ws [][]float64
+		var fitted []expiryPoints
+		for _, e := range expiries {
+			if len(e.k) < minSSVIPoints {
+				continue
+			}
+			theta := atmTotalVariance(e.k, e.w)
+			// Calendar arbitrage is excluded only if theta increases with expiry.
+			if n := len(thetas); n > 0 && theta < thetas[n-1] {
+				theta = thetas[n-1]
+			}
+			thetas = append(thetas, theta)
+			ks = append(ks, e.k)
+			ws = append(ws, e.w)
+			fitted = append(fitted, e)
+		}
+		if len(fitted) == 0 {
+			return nil, ErrInsufficientData
+		}
+		p, err := FitSSVI(thetas, ks, ws)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range fitted {
+			slices = append(slices, newSlice(e, p.SVI(thetas[i])))
+		}
+	default:
+		for _, e := range expiries {
+			if len(e.k) < minSVIPoints {
+				continue
+			}
+			smile, err := FitSVI(e.k, e.w, nil)
+			if err != nil {
+				continue
+			}
+			slices = append(slices, newSlice(e, smile))
+		}
+	}
+	if len(slices) == 0 {
+		return nil, ErrInsufficientData
+	}
+	return NewSurface(now, slices), nil
+}
+
+// points returns the points of each expiry after now in order of expiry, and removes
+// the options of expired expiries.
+func (b *SurfaceBuilder) points(now time.Time) []expiryPoints {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	byExpiry := make(map[int64][]surfaceQuote)
+	for name, q := range b.quotes {
+		if !q.expiry.After(now) {
+			delete(b.quotes, name)
+			delete(b.forwards, q.expiry.UnixMilli())
+			continue
+		}
+		byExpiry[q.expiry.UnixMilli()] = append(byExpiry[q.expiry.UnixMilli()], q)
+	}
+
+	var expiries []expiryPoints
+	for key, quotes := range byExpiry {
+		forward, ok := b.forwards[key]
+		if !ok {
+			forward = parityForward(quotes)
+		}
+		if !(forward > 0) {
+			continue
+		}
+		e := expiryPoints{expiry: quotes[0].expiry, t: YearsToExpiry(now, quotes[0].expiry), forward: forward}
+		// Use the volatility of the out of the money option of each strike, or the in the
+		// money option if the strike has no out of the money option.
+		vols := make(map[float64]surfaceQuote)
+		for _, q := range quotes {
+			otm := (q.typ == Call) == (q.strike >= forward)
+			if _, ok := vols[q.strike]; !ok || otm {
+				vols[q.strike] = q
+			}
+		}
+		strikes := make([]float64, 0, len(vols))
+		for strike := range vols {
+			strikes = append(strikes, strike)
+		}
+		sort.Float64s(strikes)
+		for _, strike := range strikes {
+			vol := vols[strike].vol
+			e.k = append(e.k, math.Log(strike/forward))
+			e.w = append(e.w, vol*vol*e.t)
+		}
+		expiries = append(expiries, e)
+	}
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].t < expiries[j].t })
+	return expiries
+}
+
+// parityForward returns the median forward implied by put-call parity from the mark
+// prices of the calls and puts of an expiry with the same strike, or 0 if no strike has
+// both. Inverse prices are in the base currency, so C - P = 1 - K/F, while linear prices
+// give C - P = F - K.
+func parityForward(quotes []surfaceQuote) float64 {
+	calls := make(map[float64]surfaceQuote)
+	for _, q := range quotes {
+		if q.typ == Call {
+			calls[q.strike] = q
+		}
+	}
+	var forwards []float64
+	for _, put := range quotes {
+		call, ok := calls[put.strike]
+		if put.typ != Put || !ok || call.markPrice <= 0 || put.markPrice <= 0 {
+			continue
+		}
+		diff := call.markPrice - put.markPrice
+		if put.settlement == Inverse {
+			if diff < 1 {
+				forwards = append(forwards, put.strike/(1-diff))
+			}
+		} else {
+			forwards = append(forwards, put.strike+diff)
+		}
+	}
+	if len(forwards) == 0 {
+		return 0
+	}
+	sort.Float64s(forwards)
+	n := len(forwards)
+	if n%2 == 1 {
+		return forwards[n/2]
+	}
+	return (forwards[n/2-1] + forwards[n/2]) / 2
+}
+
+// atmTotalVariance returns the total variance at log-moneyness 0, interpolated with a
+// quadratic through the 3 points nearest to it. The log-moneyness must be in ascending
+// order, with at least 3 points.
+func atmTotalVariance(k, w []float64) float64 {
+	i := sort.SearchFloat64s(k, 0) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i > len(k)-3 {
+		i = len(k) - 3
+	}
+	if i+2 < len(k)-1 && math.Abs(k[i+3]) < math.Abs(k[i]) {
+		i++
+	}
+	var theta float64
+	for a := i; a < i+3; a++ {
+		l := w[a]
+		for b := i; b < i+3; b++ {
+			if b != a {
+				l *= (0 - k[b]) / (k[a] - k[b])
+			}
+		}
+		theta += l
+	}
+	if !(theta > 0) {
+		// The quadratic may be negative if it's extrapolated.
+		return w[i]
+	}
+	return theta
+}
+
+func newSlice(e expiryPoints, smile SVI) Slice {
+	return Slice{
+		Expiry:  e.expiry,
+		T:       e.t,
+		Forward: e.forward,
+		Smile:   smile,
+		KMin:    e.k[0],
+		KMax:    e.k[len(e.k)-1],
+	}
+}
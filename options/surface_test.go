@@ -0,0 +1,167 @@
+package options_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bogdanovich/tradekit/deribit"
+	"github.com/bogdanovich/tradekit/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitSVI(t *testing.T) {
+	want := options.SVI{A: 0.02, B: 0.1, Rho: -0.4, M: 0.05, Sigma: 0.15}
+	var k, w []float64
+	for x := -0.6; x <= 0.6; x += 0.05 {
+		k = append(k, x)
+		w = append(w, want.TotalVariance(x))
+	}
+	got, err := options.FitSVI(k, w, nil)
+	assert.Nil(t, err)
+	for _, x := range k {
+		assert.InDelta(t, want.TotalVariance(x), got.TotalVariance(x), 1e-6, "k=%v", x)
+		assert.True(t, got.Density(x) >= 0)
+	}
+
+	_, err = options.FitSVI(k[:4], w[:4], nil)
+	assert.ErrorIs(t, err, options.ErrInsufficientData)
+}
+
+func TestSSVI(t *testing.T) {
+	want := options.SSVI{Rho: -0.3, Eta: 1.2, Gamma: 0.4}
+	thetas := []float64{0.01, 0.03, 0.08}
+	var ks, ws [][]float64
+	for _, theta := range thetas {
+		// The raw SVI form of a slice is the same smile.
+		smile := want.SVI(theta)
+		var k, w []float64
+		for x := -0.5; x <= 0.5; x += 0.1 {
+			assert.InDelta(t, want.TotalVariance(x, theta), smile.TotalVariance(x), 1e-12)
+			k = append(k, x)
+			w = append(w, want.TotalVariance(x, theta))
+		}
+		ks = append(ks, k)
+		ws = append(ws, w)
+	}
+	got, err := options.FitSSVI(thetas, ks, ws)
+	assert.Nil(t, err)
+	assert.InDelta(t, want.Rho, got.Rho, 1e-3)
+	assert.InDelta(t, want.Eta, got.Eta, 1e-3)
+	assert.InDelta(t, want.Gamma, got.Gamma, 1e-3)
+}
+
+func TestCheckArbitrage(t *testing.T) {
+	now := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC)
+	expiry := now.Add(365 * 24 * time.Hour)
+	// The smile of Gatheral and Jacquier's example of butterfly arbitrage.
+	arb := options.SVI{A: -0.041, B: 0.1331, Rho: 0.306, M: 0.3586, Sigma: 0.4153}
+	s := options.NewSurface(now, []options.Slice{
+		{Expiry: expiry, T: 1, Forward: 100, Smile: arb, KMin: -1.5, KMax: 1.5},
+	})
+	found := s.CheckArbitrage()
+	assert.Len(t, found, 1)
+	assert.Equal(t, options.ButterflyArbitrage, found[0].Type)
+
+	// A later smile with less total variance.
+	early := options.SVI{A: 0.04, B: 0.1, Rho: -0.3, M: 0, Sigma: 0.2}
+	late := options.SVI{A: 0.02, B: 0.1, Rho: -0.3, M: 0, Sigma: 0.2}
+	s = options.NewSurface(now, []options.Slice{
+		{Expiry: expiry.Add(24 * time.Hour), T: 1, Forward: 100, Smile: late, KMin: -0.5, KMax: 0.5},
+		{Expiry: expiry, T: 0.5, Forward: 100, Smile: early, KMin: -0.5, KMax: 0.5},
+	})
+	found = s.CheckArbitrage()
+	assert.Equal(t, []options.Arbitrage{{Type: options.CalendarArbitrage, Expiry: expiry.Add(24 * time.Hour), K: -0.5}}, found)
+}
+
+// surfaceParams is the SSVI surface used to generate test quotes.
+var surfaceParams = options.SSVI{Rho: -0.35, Eta: 1.1, Gamma: 0.45}
+
+func TestSurfaceBuilder(t *testing.T) {
+	now := time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC)
+	expiries := []time.Time{
+		time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC),
+	}
+	forwards := []float64{91000, 93000}
+	atmVols := []float64{0.55, 0.6}
+
+	for _, model := range []options.SurfaceModel{options.SVIModel, options.SSVIModel} {
+		b := options.NewSurfaceBuilder(model)
+		// An expired option is removed.
+		b.UpdateTicker(deribit.DeribitTicker{Instrument: "BTC-8NOV24-90000-C", MarkIV: ptr(50)})
+
+		for i, expiry := range expiries {
+			T := options.YearsToExpiry(now, expiry)
+			theta := atmVols[i] * atmVols[i] * T
+			var marks []deribit.DeribitOptionMarkPrice
+			for strike := 60000.0; strike <= 140000; strike += 5000 {
+				vol := math.Sqrt(surfaceParams.TotalVariance(math.Log(strike/forwards[i]), theta) / T)
+				for _, typ := range []options.OptionType{options.Call, options.Put} {
+					name := deribit.InstrumentName{Base: "BTC", Kind: deribit.OptionInstrument, Expiry: expiry, Strike: strike, OptionType: deribit.OptionType(typ)}
+					o := options.Option{Type: typ, Strike: strike, T: T, Settlement: options.Inverse}
+					price := o.Price(forwards[i], vol)
+					if i == 0 {
+						b.UpdateTicker(deribit.DeribitTicker{
+							Instrument:      name.String(),
+							MarkPrice:       price,
+							MarkIV:          ptr(vol * 100),
+							UnderlyingPrice: ptr(forwards[i]),
+						})
+					} else {
+						// The forward of mark prices is implied by put-call parity.
+						marks = append(marks, deribit.DeribitOptionMarkPrice{Instrument: name.String(), MarkPrice: price, IV: vol})
+					}
+				}
+			}
+			b.UpdateMarkPrices(marks)
+		}
+
+		s, err := b.Build(now)
+		assert.Nil(t, err)
+		assert.Len(t, s.Slices, 2)
+		assert.Empty(t, s.CheckArbitrage())
+
+		terms := s.TermStructure()
+		for i, term := range terms {
+			msg := fmt.Sprintf("model=%d expiry=%d", model, i)
+			assert.Equal(t, expiries[i], term.Expiry, msg)
+			assert.InDelta(t, forwards[i], term.Forward, 1e-6, msg)
+			assert.InDelta(t, atmVols[i], term.ATMVol, 1e-4, msg)
+			// Negative correlation gives a put skew.
+			assert.True(t, term.RR25 < 0, msg)
+			assert.True(t, term.BF25 > 0, msg)
+
+			theta := atmVols[i] * atmVols[i] * term.T
+			for _, strike := range []float64{70000, 85000, 105000, 130000} {
+				want := math.Sqrt(surfaceParams.TotalVariance(math.Log(strike/forwards[i]), theta) / term.T)
+				assert.InDelta(t, want, s.VolAtStrike(strike, term.T), 1e-4, msg)
+			}
+
+			for _, delta := range []float64{0.25, -0.25, 0.1} {
+				k := s.DeltaToLogMoneyness(delta, term.T)
+				o := options.Option{Type: options.Call, Strike: forwards[i] * math.Exp(k), T: term.T}
+				if delta < 0 {
+					o.Type = options.Put
+				}
+				g := o.Greeks(forwards[i], s.Vol(k, term.T))
+				assert.InDelta(t, delta, g.Delta, 1e-6, msg)
+			}
+		}
+
+		// Total variance is interpolated linearly in time between expiries.
+		mid := (terms[0].T + terms[1].T) / 2
+		assert.InDelta(t, (s.TotalVariance(0.1, terms[0].T)+s.TotalVariance(0.1, terms[1].T))/2, s.TotalVariance(0.1, mid), 1e-12)
+		// The volatility is constant before the first and after the last expiry.
+		assert.InDelta(t, s.Vol(0.1, terms[0].T), s.Vol(0.1, terms[0].T/2), 1e-12)
+		assert.InDelta(t, s.Vol(0.1, terms[1].T), s.Vol(0.1, 2), 1e-12)
+	}
+
+	_, err := options.NewSurfaceBuilder(options.SVIModel).Build(now)
+	assert.ErrorIs(t, err, options.ErrInsufficientData)
+}
+
+func ptr(x float64) *float64 {
+	return &x
+}
@@ -0,0 +1,272 @@
+package options
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInsufficientData is returned when there are too few points to fit a smile or
+// surface.
+var ErrInsufficientData = errors.New("options: insufficient data to fit")
+
+// minSVIPoints is the number of points needed to fit the 5 parameters of an SVI smile.
+const minSVIPoints = 5
+
+// SVI is the raw SVI parameterisation of the total implied variance of an expiry's smile,
+// w(k) = A + B(Rho(k-M) + sqrt((k-M)^2 + Sigma^2)), where k = ln(strike/forward) is the
+// log-moneyness, and the total variance w = vol^2 * T.
+type SVI struct {
+	A     float64
+	B     float64
+	Rho   float64
+	M     float64
+	Sigma float64
+}
+
+// TotalVariance returns the total implied variance at log-moneyness k.
+func (p SVI) TotalVariance(k float64) float64 {
+	x := k - p.M
+	return p.A + p.B*(p.Rho*x+math.Sqrt(x*x+p.Sigma*p.Sigma))
+}
+
+// Vol returns the implied volatility at log-moneyness k of an expiry with time to expiry
+// t.
+func (p SVI) Vol(k, t float64) float64 {
+	return math.Sqrt(math.Max(p.TotalVariance(k), 0) / t)
+}
+
+// derivatives returns the total variance at k, and its first and second derivatives.
+func (p SVI) derivatives(k float64) (w, dw, d2w float64) {
+	x := k - p.M
+	r := math.Sqrt(x*x + p.Sigma*p.Sigma)
+	w = p.A + p.B*(p.Rho*x+r)
+	dw = p.B * (p.Rho + x/r)
+	d2w = p.B * p.Sigma * p.Sigma / (r * r * r)
+	return w, dw, d2w
+}
+
+// Density returns Gatheral's g(k), which is proportional to the risk-neutral density
+// implied by the smile. The smile is free of butterfly arbitrage where g(k) >= 0.
+func (p SVI) Density(k float64) float64 {
+	w, dw, d2w := p.derivatives(k)
+	if w <= 0 {
+		return math.Inf(-1)
+	}
+	a := 1 - k*dw/(2*w)
+	return a*a - dw*dw/4*(1/w+0.25) + d2w/2
+}
+
+// FitSVI fits an SVI smile to the total implied variances w at log-moneyness k. Each
+// point is weighted by weights[i], or equally if weights is nil. At least 5 points are
+// required.
+//
+// The fit uses the quasi-explicit method of De Marco and Martini (Zeliade Systems, 2009):
+// for a given M and Sigma the best A, B and Rho are found by linear least squares, and M
+// and Sigma are found with the Nelder-Mead method. The fitted parameters satisfy B >= 0,
+// |Rho| < 1 and a non-negative minimum total variance.
+func FitSVI(k, w, weights []float64) (SVI, error) {
+	if len(k) < minSVIPoints || len(k) != len(w) {
+		return SVI{}, ErrInsufficientData
+	}
+	if weights == nil {
+		weights = make([]float64, len(k))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+
+	kMin, kMax := k[0], k[0]
+	mid := 0
+	for i := range k {
+		kMin = math.Min(kMin, k[i])
+		kMax = math.Max(kMax, k[i])
+		if w[i] < w[mid] {
+			mid = i
+		}
+	}
+	width := math.Max(kMax-kMin, 1e-4)
+
+	var best SVI
+	bestErr := math.Inf(1)
+	// The objective has local minima, so start from several values of Sigma.
+	for _, s := range []float64{0.05, 0.2, 0.5} {
+		x := nelderMead(func(x []float64) float64 {
+			_, sse := fitSVILinear(k, w, weights, x[0], math.Exp(x[1]))
+			return sse
+		}, []float64{k[mid], math.Log(s * width)}, []float64{0.1 * width, 0.5}, 400)
+		p, sse := fitSVILinear(k, w, weights, x[0], math.Exp(x[1]))
+		if sse < bestErr {
+			best, bestErr = p, sse
+		}
+	}
+	if math.IsInf(bestErr, 1) || math.IsNaN(bestErr) {
+		return SVI{}, ErrNoConvergence
+	}
+	return best, nil
+}
+
+// fitSVILinear fits A, B and Rho for a given M and Sigma, and returns the smile with its
+// weighted sum of squared errors. Smiles which violate the parameter constraints have an
+// infinite error.
+func fitSVILinear(k, w, weights []float64, m, sigma float64) (SVI, float64) {
+	// w = A + C*x + B*r, where x = k - M, r = sqrt(x^2 + Sigma^2) and C = B*Rho.
+	var ata [3][3]float64
+	var atb [3]float64
+	for i := range k {
+		x := k[i] - m
+		row := [3]float64{1, x, math.Sqrt(x*x + sigma*sigma)}
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				ata[a][b] += weights[i] * row[a] * row[b]
+			}
+			atb[a] += weights[i] * row[a] * w[i]
+		}
+	}
+	sol, ok := solve3(ata, atb)
+	if !ok {
+		return SVI{}, math.Inf(1)
+	}
+	p := SVI{A: sol[0], B: sol[2], M: m, Sigma: sigma}
+	if p.B > 0 {
+		p.Rho = sol[1] / p.B
+	}
+	const maxRho = 0.999
+	if p.B < 0 || math.Abs(p.Rho) > maxRho {
+		// Refit A and B with Rho on its bound.
+		p.Rho = math.Max(-maxRho, math.Min(maxRho, p.Rho))
+		if p.B < 0 {
+			p.Rho = 0
+		}
+		p.A, p.B = fitSVIAB(k, w, weights, m, sigma, p.Rho)
+		if p.B < 0 {
+			p.B = 0
+			p.A = weightedMean(w, weights)
+		}
+	}
+	if p.A+p.B*p.Sigma*math.Sqrt(1-p.Rho*p.Rho) < 0 {
+		return p, math.Inf(1)
+	}
+	var sse float64
+	for i := range k {
+		d := p.TotalVariance(k[i]) - w[i]
+		sse += weights[i] * d * d
+	}
+	return p, sse
+}
+
+// fitSVIAB fits A and B for given M, Sigma and Rho.
+func fitSVIAB(k, w, weights []float64, m, sigma, rho float64) (float64, float64) {
+	var sw, sz, szz, sy, szy float64
+	for i := range k {
+		x := k[i] - m
+		z := rho*x + math.Sqrt(x*x+sigma*sigma)
+		sw += weights[i]
+		sz += weights[i] * z
+		szz += weights[i] * z * z
+		sy += weights[i] * w[i]
+		szy += weights[i] * z * w[i]
+	}
+	det := sw*szz - sz*sz
+	if det == 0 {
+		return sy / sw, 0
+	}
+	b := (sw*szy - sz*sy) / det
+	return (sy - b*sz) / sw, b
+}
+
+// SSVI is the surface SVI parameterisation of Gatheral and Jacquier, which gives the
+// total implied variance of every expiry from its at the money total variance theta:
+// w(k, theta) = theta/2 * (1 + Rho*phi*k + sqrt((phi*k + Rho)^2 + 1 - Rho^2)), with the
+// power-law phi(theta) = Eta / (theta^Gamma * (1+theta)^(1-Gamma)).
+//
+// The surface is free of static arbitrage if theta increases with time to expiry,
+// Eta*(1+|Rho|) <= 2, and 0 < Gamma <= 1/2.
+type SSVI struct {
+	Rho   float64
+	Eta   float64
+	Gamma float64
+}
+
+// Phi returns the curvature of the smile with at the money total variance theta.
+func (p SSVI) Phi(theta float64) float64 {
+	return p.Eta / (math.Pow(theta, p.Gamma) * math.Pow(1+theta, 1-p.Gamma))
+}
+
+// TotalVariance returns the total implied variance at log-moneyness k of the expiry with
+// at the money total variance theta.
+func (p SSVI) TotalVariance(k, theta float64) float64 {
+	pk := p.Phi(theta) * k
+	return theta / 2 * (1 + p.Rho*pk + math.Sqrt((pk+p.Rho)*(pk+p.Rho)+1-p.Rho*p.Rho))
+}
+
+// SVI returns the raw SVI smile of the expiry with at the money total variance theta.
+func (p SSVI) SVI(theta float64) SVI {
+	phi := p.Phi(theta)
+	return SVI{
+		A:     theta / 2 * (1 - p.Rho*p.Rho),
+		B:     theta * phi / 2,
+		Rho:   p.Rho,
+		M:     -p.Rho / phi,
+		Sigma: math.Sqrt(1-p.Rho*p.Rho) / phi,
+	}
+}
+
+// FitSSVI fits an SSVI surface to the total implied variances w[i] at log-moneyness k[i]
+// of each expiry i, which has at the money total variance thetas[i]. The parameters are
+// constrained so that the surface is free of butterfly arbitrage, and of calendar
+// arbitrage if the thetas increase with time to expiry.
+func FitSSVI(thetas []float64, k, w [][]float64) (SSVI, error) {
+	var n int
+	for i := range k {
+		n += len(k[i])
+	}
+	if n < 3 || len(thetas) != len(k) || len(k) != len(w) {
+		return SSVI{}, ErrInsufficientData
+	}
+	// Map the unconstrained parameters onto |Rho| < 1, 0 < Gamma <= 1/2 and
+	// 0 < Eta <= 2/(1+|Rho|).
+	params := func(x []float64) SSVI {
+		rho := math.Tanh(x[0])
+		return SSVI{
+			Rho:   rho,
+			Eta:   2 / (1 + math.Abs(rho)) * logistic(x[1]),
+			Gamma: 0.5 * logistic(x[2]),
+		}
+	}
+	sse := func(x []float64) float64 {
+		p := params(x)
+		var sum float64
+		for i, theta := range thetas {
+			for j := range k[i] {
+				d := p.TotalVariance(k[i][j], theta) - w[i][j]
+				sum += d * d
+			}
+		}
+		return sum
+	}
+	var best []float64
+	bestErr := math.Inf(1)
+	for _, rho := range []float64{-0.5, 0, 0.5} {
+		x := nelderMead(sse, []float64{math.Atanh(rho), 0, 0}, []float64{0.3, 1, 1}, 600)
+		if e := sse(x); e < bestErr {
+			best, bestErr = x, e
+		}
+	}
+	if math.IsNaN(bestErr) {
+		return SSVI{}, ErrNoConvergence
+	}
+	return params(best), nil
+}
+
+func logistic(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func weightedMean(x, weights []float64) float64 {
+	var sum, sw float64
+	for i := range x {
+		sum += weights[i] * x[i]
+		sw += weights[i]
+	}
+	return sum / sw
+}
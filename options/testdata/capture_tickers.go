@@ -0,0 +1,129 @@
+//go:build ignore
+
+// capture_tickers records the public/ticker results of an inverse call, an inverse put
+// and a linear USDC option from Deribit into deribit_option_tickers.json, for the tests
+// of Deribit's pricing conventions. Run it from the options directory with:
+//
+//	go run testdata/capture_tickers.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const apiUrl = "https://www.deribit.com/api/v2/"
+
+type instrument struct {
+	Name                string  `json:"instrument_name"`
+	Strike              float64 `json:"strike"`
+	OptionType          string  `json:"option_type"`
+	ExpirationTimestamp int64   `json:"expiration_timestamp"`
+	PriceIndex          string  `json:"price_index"`
+}
+
+func get(method string, params url.Values, result interface{}) error {
+	resp, err := http.Get(apiUrl + method + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	if body.Error != nil {
+		return fmt.Errorf("%s: [%d] %s", method, body.Error.Code, body.Error.Message)
+	}
+	return json.Unmarshal(body.Result, result)
+}
+
+// pick returns the option of a type with the strike nearest to moneyness times the index
+// price, on the first expiry at least a week away.
+func pick(instruments []instrument, optionType string, moneyness float64) (instrument, error) {
+	minExpiry := time.Now().Add(7 * 24 * time.Hour).UnixMilli()
+	var expiry int64
+	for _, inst := range instruments {
+		if inst.ExpirationTimestamp >= minExpiry && (expiry == 0 || inst.ExpirationTimestamp < expiry) {
+			expiry = inst.ExpirationTimestamp
+		}
+	}
+	var best instrument
+	var index struct {
+		IndexPrice float64 `json:"index_price"`
+	}
+	for _, inst := range instruments {
+		if inst.ExpirationTimestamp != expiry || inst.OptionType != optionType {
+			continue
+		}
+		if index.IndexPrice == 0 {
+			params := url.Values{"index_name": {inst.PriceIndex}}
+			if err := get("public/get_index_price", params, &index); err != nil {
+				return best, err
+			}
+		}
+		target := moneyness * index.IndexPrice
+		if best.Name == "" || math.Abs(inst.Strike-target) < math.Abs(best.Strike-target) {
+			best = inst
+		}
+	}
+	if best.Name == "" {
+		return best, fmt.Errorf("no %s option found", optionType)
+	}
+	return best, nil
+}
+
+func main() {
+	var inverse, linear []instrument
+	if err := get("public/get_instruments", url.Values{"currency": {"BTC"}, "kind": {"option"}}, &inverse); err != nil {
+		log.Fatal(err)
+	}
+	if err := get("public/get_instruments", url.Values{"currency": {"USDC"}, "kind": {"option"}}, &linear); err != nil {
+		log.Fatal(err)
+	}
+
+	var picked []instrument
+	for _, p := range []struct {
+		instruments []instrument
+		optionType  string
+		moneyness   float64
+	}{
+		{inverse, "call", 1.1},
+		{inverse, "put", 0.9},
+		{linear, "call", 1.05},
+	} {
+		inst, err := pick(p.instruments, p.optionType, p.moneyness)
+		if err != nil {
+			log.Fatal(err)
+		}
+		picked = append(picked, inst)
+	}
+
+	var tickers []json.RawMessage
+	for _, inst := range picked {
+		var ticker json.RawMessage
+		if err := get("public/ticker", url.Values{"instrument_name": {inst.Name}}, &ticker); err != nil {
+			log.Fatal(err)
+		}
+		tickers = append(tickers, ticker)
+	}
+	data, err := json.MarshalIndent(tickers, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("testdata/deribit_option_tickers.json", append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
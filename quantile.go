@@ -0,0 +1,332 @@
+package tradekit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxSketchBins is the maximum number of bins of each sign in a DDSketch. With a relative
+// accuracy of 1%, it covers values over 17 orders of magnitude before the lowest bins are
+// collapsed.
+const maxSketchBins = 2048
+
+// DDSketch estimates quantiles of a stream of values with a relative accuracy, such that
+// an estimated quantile x̂ of the true quantile x satisfies |x̂-x| <= accuracy·|x|. Values
+// are counted in bins with logarithmically spaced bounds, so a sketch uses little
+// memory however many values it's given. See https://arxiv.org/abs/1908.10693
+//
+// Sketches with the same relative accuracy are mergeable: the merged sketch is the
+// sketch of the values of both, with the same accuracy. A DDSketch isn't safe for
+// concurrent use.
+type DDSketch struct {
+	accuracy float64
+	gamma    float64
+	logGamma float64
+	positive sketchStore
+	negative sketchStore
+	zeros    float64
+}
+
+// NewDDSketch creates a DDSketch with a relative accuracy, such as 0.01 for 1%.
+func NewDDSketch(relativeAccuracy float64) *DDSketch {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		panic(fmt.Sprintf("tradekit: invalid relative accuracy %v", relativeAccuracy))
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &DDSketch{accuracy: relativeAccuracy, gamma: gamma, logGamma: math.Log(gamma)}
+}
+
+// RelativeAccuracy returns the relative accuracy of the sketch.
+func (s *DDSketch) RelativeAccuracy() float64 {
+	return s.accuracy
+}
+
+func (s *DDSketch) key(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value returns the estimate of the values in the bin of a key, which is within the
+// relative accuracy of the bounds of the bin.
+func (s *DDSketch) value(key int) float64 {
+	return 2 * math.Pow(s.gamma, float64(key)) / (s.gamma + 1)
+}
+
+func (s *DDSketch) add(v float64, w float64) {
+	switch {
+	case math.IsInf(v, 0):
+		return
+	case v > 0:
+		s.positive.add(s.key(v), w)
+	case v < 0:
+		s.negative.add(s.key(-v), w)
+	case v == 0:
+		s.zeros = math.Max(s.zeros+w, 0)
+	}
+}
+
+// Add adds a value to the sketch. NaN and infinite values are ignored.
+func (s *DDSketch) Add(v float64) {
+	s.add(v, 1)
+}
+
+// Remove removes a value which was added to the sketch.
+func (s *DDSketch) Remove(v float64) {
+	s.add(v, -1)
+}
+
+// Count returns the number of values in the sketch.
+func (s *DDSketch) Count() int {
+	return int(math.Round(s.count()))
+}
+
+func (s *DDSketch) count() float64 {
+	return s.positive.count + s.negative.count + s.zeros
+}
+
+// Quantile returns an estimate of the q-quantile of the values, for q in [0, 1], such as
+// 0.99 for the 99th percentile. It returns NaN if the sketch is empty.
+func (s *DDSketch) Quantile(q float64) float64 {
+	n := s.count()
+	if n < 0.5 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	rank := q * (n - 1)
+
+	// Negative values in increasing order are their keys in decreasing order.
+	var cum float64
+	for i := len(s.negative.bins) - 1; i >= 0; i-- {
+		cum += s.negative.bins[i]
+		if cum > rank {
+			return -s.value(s.negative.offset + i)
+		}
+	}
+	cum += s.zeros
+	if cum > rank {
+		return 0
+	}
+	for i, c := range s.positive.bins {
+		cum += c
+		if cum > rank {
+			return s.value(s.positive.offset + i)
+		}
+	}
+	// The rank is past the last bin by a rounding error.
+	if len(s.positive.bins) > 0 {
+		return s.value(s.positive.offset + len(s.positive.bins) - 1)
+	}
+	if s.zeros > 0 {
+		return 0
+	}
+	return -s.value(s.negative.offset)
+}
+
+// Merge adds the values of another sketch to the sketch. The sketches must have the same
+// relative accuracy.
+func (s *DDSketch) Merge(other *DDSketch) error {
+	if other.gamma != s.gamma {
+		return fmt.Errorf("tradekit: can't merge sketches with relative accuracy %v and %v", s.accuracy, other.accuracy)
+	}
+	s.positive.merge(&other.positive)
+	s.negative.merge(&other.negative)
+	s.zeros += other.zeros
+	return nil
+}
+
+// Clone returns a copy of the sketch.
+func (s *DDSketch) Clone() *DDSketch {
+	c := *s
+	c.positive.bins = append([]float64(nil), s.positive.bins...)
+	c.negative.bins = append([]float64(nil), s.negative.bins...)
+	return &c
+}
+
+// Reset removes all values from the sketch.
+func (s *DDSketch) Reset() {
+	s.positive.reset()
+	s.negative.reset()
+	s.zeros = 0
+}
+
+// sketchStore is a dense array of the counts of contiguous bin keys. When the range of
+// keys is wider than maxSketchBins, the lowest bins are collapsed into one, and later
+// values below it are counted in that bin.
+type sketchStore struct {
+	bins []float64
+	// offset is the key of the first bin.
+	offset int
+	count  float64
+	// floor is the lowest key once bins have collapsed.
+	floor     int
+	collapsed bool
+}
+
+func (st *sketchStore) add(key int, w float64) {
+	if st.collapsed && key < st.floor {
+		key = st.floor
+	}
+	if w < 0 {
+		// Only values which were added are removed.
+		i := key - st.offset
+		if i < 0 || i >= len(st.bins) {
+			return
+		}
+		w = math.Max(w, -st.bins[i])
+		st.bins[i] += w
+		st.count += w
+		if st.count < 0.5 {
+			st.reset()
+		} else {
+			st.trim()
+		}
+		return
+	}
+
+	if len(st.bins) == 0 {
+		st.bins = append(st.bins[:0], 0)
+		st.offset = key
+	} else if key < st.offset || key >= st.offset+len(st.bins) {
+		lo, hi := st.offset, st.offset+len(st.bins)-1
+		if key < lo {
+			lo = key
+		} else {
+			hi = key
+		}
+		st.resize(lo, hi)
+		if key < st.offset {
+			key = st.offset
+		}
+	}
+	st.bins[key-st.offset] += w
+	st.count += w
+}
+
+// resize extends the bins to the keys [lo, hi], collapsing the lowest bins if the range
+// is too wide.
+func (st *sketchStore) resize(lo, hi int) {
+	if hi-lo+1 > maxSketchBins {
+		lo = hi - maxSketchBins + 1
+		st.floor, st.collapsed = lo, true
+	}
+	if lo == st.offset && hi-lo+1 <= cap(st.bins) {
+		// Extend the bins in place.
+		n := len(st.bins)
+		st.bins = st.bins[:hi-lo+1]
+		for i := n; i < len(st.bins); i++ {
+			st.bins[i] = 0
+		}
+		return
+	}
+	bins := make([]float64, hi-lo+1)
+	for i, c := range st.bins {
+		key := st.offset + i
+		if key < lo {
+			key = lo
+		}
+		if key <= hi {
+			bins[key-lo] += c
+		}
+	}
+	st.bins, st.offset = bins, lo
+}
+
+// trim removes empty bins at either end.
+func (st *sketchStore) trim() {
+	start := 0
+	for start < len(st.bins) && st.bins[start] <= 0 {
+		start++
+	}
+	end := len(st.bins)
+	for end > start && st.bins[end-1] <= 0 {
+		end--
+	}
+	st.bins = st.bins[start:end]
+	st.offset += start
+}
+
+func (st *sketchStore) merge(other *sketchStore) {
+	if other.collapsed && (!st.collapsed || other.floor > st.floor) {
+		st.floor, st.collapsed = other.floor, true
+		if len(st.bins) > 0 && st.offset < st.floor {
+			hi := st.offset + len(st.bins) - 1
+			if hi < st.floor {
+				hi = st.floor
+			}
+			st.resize(st.floor, hi)
+		}
+	}
+	for i, c := range other.bins {
+		if c > 0 {
+			st.add(other.offset+i, c)
+		}
+	}
+}
+
+func (st *sketchStore) reset() {
+	st.bins = st.bins[:0]
+	st.offset, st.count = 0, 0
+	st.floor, st.collapsed = 0, false
+}
+
+// RollingQuantile estimates quantiles of the values over a time window with a
+// [DDSketch]. Values are removed from the sketch as they expire.
+type RollingQuantile struct {
+	rollingWindow[float64]
+	sketch *DDSketch
+}
+
+// NewRollingQuantile creates a RollingQuantile with a specified time window and relative
+// accuracy.
+func NewRollingQuantile(window time.Duration, relativeAccuracy float64) *RollingQuantile {
+	return &RollingQuantile{rollingWindow: newRollingWindow[float64](window), sketch: NewDDSketch(relativeAccuracy)}
+}
+
+func (rq *RollingQuantile) refreshWindow(ts int64) {
+	rq.expire(ts, rq.sketch.Remove)
+}
+
+func (rq *RollingQuantile) update(v float64, ts int64) {
+	if math.IsNaN(v) {
+		return
+	}
+	rq.push(ts, v)
+	rq.sketch.Add(v)
+	rq.refreshWindow(ts)
+}
+
+// Update the rolling quantiles with a new value.
+func (rq *RollingQuantile) Update(v float64) {
+	rq.update(v, rq.now())
+}
+
+// UpdateAt updates the rolling quantiles with a new value produced at timestamp in
+// millisecond units.
+func (rq *RollingQuantile) UpdateAt(v float64, tsMillis int64) {
+	rq.update(v, millisToMicros(tsMillis))
+}
+
+// Quantile returns an estimate of the current q-quantile, or NaN if the window is empty.
+func (rq *RollingQuantile) Quantile(q float64) float64 {
+	rq.refreshWindow(rq.now())
+	return rq.sketch.Quantile(q)
+}
+
+// QuantileAt returns an estimate of the q-quantile at timestamp in millisecond units, or
+// NaN if the window is empty.
+func (rq *RollingQuantile) QuantileAt(q float64, tsMillis int64) float64 {
+	rq.refreshWindow(millisToMicros(tsMillis))
+	return rq.sketch.Quantile(q)
+}
+
+// Sketch returns a copy of the current sketch of the window, which may be merged with the
+// sketches of other windows.
+func (rq *RollingQuantile) Sketch() *DDSketch {
+	rq.refreshWindow(rq.now())
+	return rq.sketch.Clone()
+}
+
+// SketchAt returns a copy of the sketch of the window at timestamp in millisecond units.
+func (rq *RollingQuantile) SketchAt(tsMillis int64) *DDSketch {
+	rq.refreshWindow(millisToMicros(tsMillis))
+	return rq.sketch.Clone()
+}
@@ -0,0 +1,121 @@
+package tradekit
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exactQuantile returns the q-quantile of sorted values, with the rank used by DDSketch.
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestDDSketch(t *testing.T) {
+	accuracy := 0.01
+	rng := rand.New(rand.NewSource(1))
+	s := NewDDSketch(accuracy)
+	assert.True(t, math.IsNaN(s.Quantile(0.5)))
+
+	var values []float64
+	for i := 0; i < 10000; i++ {
+		// Lognormal trade sizes, with some sells as negative values and some zeros.
+		v := math.Exp(rng.NormFloat64() * 2)
+		switch i % 10 {
+		case 0:
+			v = -v
+		case 1:
+			v = 0
+		}
+		values = append(values, v)
+		s.Add(v)
+	}
+	s.Add(math.NaN())
+	assert.Equal(t, len(values), s.Count())
+
+	sort.Float64s(values)
+	for _, q := range []float64{0, 0.01, 0.05, 0.1, 0.15, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1} {
+		want := exactQuantile(values, q)
+		assert.InDelta(t, want, s.Quantile(q), accuracy*math.Abs(want)+1e-12, "q=%v", q)
+	}
+}
+
+func TestDDSketchMerge(t *testing.T) {
+	accuracy := 0.02
+	rng := rand.New(rand.NewSource(2))
+	merged := NewDDSketch(accuracy)
+	var values []float64
+	for venue := 0; venue < 3; venue++ {
+		s := NewDDSketch(accuracy)
+		for i := 0; i < 1000; i++ {
+			// The venues have different distributions of latency, in milliseconds.
+			v := float64(venue+1) * 10 * math.Exp(rng.NormFloat64())
+			values = append(values, v)
+			s.Add(v)
+		}
+		assert.Nil(t, merged.Merge(s))
+	}
+	assert.Equal(t, len(values), merged.Count())
+
+	sort.Float64s(values)
+	for _, q := range []float64{0.01, 0.5, 0.9, 0.99} {
+		want := exactQuantile(values, q)
+		assert.InDelta(t, want, merged.Quantile(q), accuracy*want, "q=%v", q)
+	}
+
+	assert.Error(t, merged.Merge(NewDDSketch(0.01)))
+
+	// Removing the values of a sketch leaves it empty.
+	c := merged.Clone()
+	for _, v := range values {
+		c.Remove(v)
+	}
+	assert.Equal(t, 0, c.Count())
+	assert.Equal(t, len(values), merged.Count())
+}
+
+func TestDDSketchCollapse(t *testing.T) {
+	s := NewDDSketch(0.01)
+	// The range of values is wider than the bins cover, so the lowest bins collapse, and
+	// only the highest quantiles keep their accuracy.
+	for i := -200; i <= 200; i++ {
+		s.Add(math.Pow(10, float64(i)/4))
+	}
+	assert.LessOrEqual(t, len(s.positive.bins), maxSketchBins)
+	assert.Equal(t, 401, s.Count())
+	assert.InEpsilon(t, 1e50, s.Quantile(1), 0.01)
+	assert.InEpsilon(t, 1e40, s.Quantile(0.9), 0.01)
+	assert.Greater(t, s.Quantile(0.5), 1.0)
+}
+
+func TestRollingQuantile(t *testing.T) {
+	accuracy := 0.01
+	rq := NewRollingQuantile(10*time.Second, accuracy)
+	assert.True(t, math.IsNaN(rq.QuantileAt(0.5, 0)))
+
+	// Spreads widen over time.
+	for i := 0; i < 100; i++ {
+		rq.UpdateAt(float64(i+1), int64(i)*1000)
+	}
+	// The window holds the values at 89s to 99s, 90 to 100.
+	assert.InDelta(t, 95, rq.QuantileAt(0.5, 99000), 95*accuracy)
+	assert.InDelta(t, 90, rq.QuantileAt(0, 99000), 90*accuracy)
+	assert.InDelta(t, 100, rq.QuantileAt(1, 99000), 100*accuracy)
+	assert.Equal(t, 11, rq.SketchAt(99000).Count())
+
+	assert.InDelta(t, 100, rq.QuantileAt(0.5, 109000), 100*accuracy)
+	assert.True(t, math.IsNaN(rq.QuantileAt(0.5, 110000)))
+
+	// The clock drives Update and Quantile.
+	now := time.UnixMilli(200000)
+	rq.SetClock(func() time.Time { return now })
+	rq.Update(5)
+	rq.Update(7)
+	assert.InDelta(t, 7, rq.Quantile(1), 7*accuracy)
+	now = now.Add(11 * time.Second)
+	assert.Equal(t, 0, rq.Sketch().Count())
+}
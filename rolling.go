@@ -1,54 +1,449 @@
 package tradekit
 
 import (
+	"math"
 	"time"
 
 	"github.com/edwingeng/deque/v2"
 )
 
-// RollingSum calculates a rolling sum over a specified time window.
-type RollingSum struct {
-	events *deque.Deque[event]
-	window time.Duration
-	value  float64
+// Clock returns the current time. Rolling statistics use the clock to timestamp values
+// given to Update and to expire values in Value. Set a clock with SetClock to drive them
+// from another source of time, such as the timestamps of a replay.
+type Clock func() time.Time
+
+// rollingWindow is a time window of timestamped items, in the order they were added.
+// Timestamps are in microseconds.
+type rollingWindow[T any] struct {
+	events *deque.Deque[timed[T]]
+	window int64
+	clock  Clock
+}
+
+type timed[T any] struct {
+	ts   int64
+	item T
+}
+
+func newRollingWindow[T any](window time.Duration) rollingWindow[T] {
+	return rollingWindow[T]{events: deque.NewDeque[timed[T]](), window: window.Microseconds(), clock: time.Now}
+}
+
+// SetClock sets the clock used by Update and Value, which is time.Now by default.
+func (w *rollingWindow[T]) SetClock(clock Clock) {
+	w.clock = clock
+}
+
+func (w *rollingWindow[T]) now() int64 {
+	return w.clock().UTC().UnixMicro()
+}
+
+func (w *rollingWindow[T]) push(ts int64, item T) {
+	w.events.PushBack(timed[T]{ts, item})
+}
+
+// expire removes the items which are older than the window at a timestamp, calling
+// remove with each of them.
+func (w *rollingWindow[T]) expire(ts int64, remove func(T)) {
+	for {
+		e, ok := w.events.Front()
+		if !ok || e.ts >= ts-w.window {
+			return
+		}
+		w.events.PopFront()
+		remove(e.item)
+	}
+}
+
+// millisToMicros converts a timestamp in milliseconds to microseconds.
+func millisToMicros(tsMillis int64) int64 {
+	return tsMillis * 1000
 }
 
-type event struct {
-	ts    int64
+// RollingSum calculates a rolling sum over a specified time window.
+//
+// The values of a rolling statistic expire once they're older than the window, relative
+// to the latest timestamp. Update and Value use the current time of the clock, while
+// UpdateAt and ValueAt take the timestamp of an event, for replays and backtests. The
+// timestamps of updates must not decrease.
+type RollingSum struct {
+	rollingWindow[float64]
 	value float64
 }
 
 // NewRollingSum creates a RollingSum with a specified time window.
 func NewRollingSum(window time.Duration) *RollingSum {
-	events := deque.NewDeque[event]()
-	return &RollingSum{events: events, window: window, value: 0}
+	return &RollingSum{rollingWindow: newRollingWindow[float64](window), value: 0}
 }
 
 func (rs *RollingSum) refreshWindow(ts int64) {
-	for {
-		e, ok := rs.events.Front()
-		if !ok {
-			break
-		}
-		if e.ts < ts-rs.window.Microseconds() {
-			rs.events.PopFront()
-			rs.value -= e.value
-		} else {
-			break
-		}
-	}
+	rs.expire(ts, func(v float64) { rs.value -= v })
 }
 
-// Update the rolling sum with a new value.
-func (rs *RollingSum) Update(v float64) {
-	ts := time.Now().UTC().UnixMicro()
-	rs.events.PushBack(event{ts, v})
+func (rs *RollingSum) update(v float64, ts int64) {
+	rs.push(ts, v)
 	rs.value += v
 	rs.refreshWindow(ts)
 }
 
+// Update the rolling sum with a new value.
+func (rs *RollingSum) Update(v float64) {
+	rs.update(v, rs.now())
+}
+
+// UpdateAt updates the rolling sum with a new value produced at timestamp in millisecond
+// units.
+func (rs *RollingSum) UpdateAt(v float64, tsMillis int64) {
+	rs.update(v, millisToMicros(tsMillis))
+}
+
 // Get the current value of the rolling sum.
 func (rs *RollingSum) Value() float64 {
-	rs.refreshWindow(time.Now().UTC().UnixMicro())
+	rs.refreshWindow(rs.now())
 	return rs.value
 }
+
+// ValueAt returns the rolling sum at timestamp in millisecond units.
+func (rs *RollingSum) ValueAt(tsMillis int64) float64 {
+	rs.refreshWindow(millisToMicros(tsMillis))
+	return rs.value
+}
+
+// RollingCount counts the updates over a time window.
+type RollingCount struct {
+	rollingWindow[struct{}]
+}
+
+// NewRollingCount creates a RollingCount with a specified time window.
+func NewRollingCount(window time.Duration) *RollingCount {
+	return &RollingCount{rollingWindow: newRollingWindow[struct{}](window)}
+}
+
+func (rc *RollingCount) refreshWindow(ts int64) {
+	rc.expire(ts, func(struct{}) {})
+}
+
+func (rc *RollingCount) update(ts int64) {
+	rc.push(ts, struct{}{})
+	rc.refreshWindow(ts)
+}
+
+// Update the rolling count with a new event.
+func (rc *RollingCount) Update() {
+	rc.update(rc.now())
+}
+
+// UpdateAt updates the rolling count with an event at timestamp in millisecond units.
+func (rc *RollingCount) UpdateAt(tsMillis int64) {
+	rc.update(millisToMicros(tsMillis))
+}
+
+// Value returns the current number of events in the window.
+func (rc *RollingCount) Value() int {
+	rc.refreshWindow(rc.now())
+	return rc.events.Len()
+}
+
+// ValueAt returns the number of events in the window at timestamp in millisecond units.
+func (rc *RollingCount) ValueAt(tsMillis int64) int {
+	rc.refreshWindow(millisToMicros(tsMillis))
+	return rc.events.Len()
+}
+
+// RollingVariance calculates the rolling mean, sample variance and standard deviation of
+// values over a time window, with Welford's algorithm.
+type RollingVariance struct {
+	rollingWindow[float64]
+	mean float64
+	m2   float64
+}
+
+// NewRollingVariance creates a RollingVariance with a specified time window.
+func NewRollingVariance(window time.Duration) *RollingVariance {
+	return &RollingVariance{rollingWindow: newRollingWindow[float64](window)}
+}
+
+func (rv *RollingVariance) refreshWindow(ts int64) {
+	rv.expire(ts, rv.remove)
+}
+
+func (rv *RollingVariance) remove(v float64) {
+	n := float64(rv.events.Len())
+	if n == 0 {
+		rv.mean, rv.m2 = 0, 0
+		return
+	}
+	d := v - rv.mean
+	rv.mean -= d / n
+	rv.m2 = math.Max(rv.m2-d*(v-rv.mean), 0)
+}
+
+func (rv *RollingVariance) update(v float64, ts int64) {
+	rv.push(ts, v)
+	d := v - rv.mean
+	rv.mean += d / float64(rv.events.Len())
+	rv.m2 += d * (v - rv.mean)
+	rv.refreshWindow(ts)
+}
+
+// Update the rolling variance with a new value.
+func (rv *RollingVariance) Update(v float64) {
+	rv.update(v, rv.now())
+}
+
+// UpdateAt updates the rolling variance with a new value produced at timestamp in
+// millisecond units.
+func (rv *RollingVariance) UpdateAt(v float64, tsMillis int64) {
+	rv.update(v, millisToMicros(tsMillis))
+}
+
+// Count returns the current number of values in the window.
+func (rv *RollingVariance) Count() int {
+	rv.refreshWindow(rv.now())
+	return rv.events.Len()
+}
+
+// Mean returns the current mean, or NaN if the window is empty.
+func (rv *RollingVariance) Mean() float64 {
+	return rv.meanAt(rv.now())
+}
+
+// MeanAt returns the mean at timestamp in millisecond units, or NaN if the window is
+// empty.
+func (rv *RollingVariance) MeanAt(tsMillis int64) float64 {
+	return rv.meanAt(millisToMicros(tsMillis))
+}
+
+func (rv *RollingVariance) meanAt(ts int64) float64 {
+	rv.refreshWindow(ts)
+	if rv.events.Len() == 0 {
+		return math.NaN()
+	}
+	return rv.mean
+}
+
+// Variance returns the current sample variance, or NaN if the window has fewer than
+// two values.
+func (rv *RollingVariance) Variance() float64 {
+	return rv.varianceAt(rv.now())
+}
+
+// VarianceAt returns the sample variance at timestamp in millisecond units, or NaN if the
+// window has fewer than two values.
+func (rv *RollingVariance) VarianceAt(tsMillis int64) float64 {
+	return rv.varianceAt(millisToMicros(tsMillis))
+}
+
+func (rv *RollingVariance) varianceAt(ts int64) float64 {
+	rv.refreshWindow(ts)
+	n := rv.events.Len()
+	if n < 2 {
+		return math.NaN()
+	}
+	return rv.m2 / float64(n-1)
+}
+
+// StdDev returns the current sample standard deviation, or NaN if the window has fewer
+// than two values.
+func (rv *RollingVariance) StdDev() float64 {
+	return math.Sqrt(rv.Variance())
+}
+
+// StdDevAt returns the sample standard deviation at timestamp in millisecond units, or
+// NaN if the window has fewer than two values.
+func (rv *RollingVariance) StdDevAt(tsMillis int64) float64 {
+	return math.Sqrt(rv.VarianceAt(tsMillis))
+}
+
+// RollingMean calculates the rolling mean of values over a time window.
+type RollingMean struct {
+	sum RollingSum
+}
+
+// NewRollingMean creates a RollingMean with a specified time window.
+func NewRollingMean(window time.Duration) *RollingMean {
+	return &RollingMean{sum: *NewRollingSum(window)}
+}
+
+// SetClock sets the clock used by Update and Value, which is time.Now by default.
+func (rm *RollingMean) SetClock(clock Clock) {
+	rm.sum.SetClock(clock)
+}
+
+// Update the rolling mean with a new value.
+func (rm *RollingMean) Update(v float64) {
+	rm.sum.Update(v)
+}
+
+// UpdateAt updates the rolling mean with a new value produced at timestamp in
+// millisecond units.
+func (rm *RollingMean) UpdateAt(v float64, tsMillis int64) {
+	rm.sum.UpdateAt(v, tsMillis)
+}
+
+// Value returns the current mean, or NaN if the window is empty.
+func (rm *RollingMean) Value() float64 {
+	return rm.mean(rm.sum.Value())
+}
+
+// ValueAt returns the mean at timestamp in millisecond units, or NaN if the window is
+// empty.
+func (rm *RollingMean) ValueAt(tsMillis int64) float64 {
+	return rm.mean(rm.sum.ValueAt(tsMillis))
+}
+
+// Count returns the current number of values in the window.
+func (rm *RollingMean) Count() int {
+	rm.sum.Value()
+	return rm.sum.events.Len()
+}
+
+func (rm *RollingMean) mean(sum float64) float64 {
+	n := rm.sum.events.Len()
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
+
+// RollingExtremum calculates the rolling minimum or maximum of values over a time window.
+// It keeps a monotonic deque of the values which may yet become the extremum, so an
+// update takes amortised constant time.
+type RollingExtremum struct {
+	rollingWindow[float64]
+	// replaces reports whether a new value replaces an older value as a candidate.
+	replaces func(v, old float64) bool
+}
+
+// NewRollingMin creates a RollingExtremum of the minimum over a specified time window.
+func NewRollingMin(window time.Duration) *RollingExtremum {
+	return &RollingExtremum{
+		rollingWindow: newRollingWindow[float64](window),
+		replaces:      func(v, old float64) bool { return v <= old },
+	}
+}
+
+// NewRollingMax creates a RollingExtremum of the maximum over a specified time window.
+func NewRollingMax(window time.Duration) *RollingExtremum {
+	return &RollingExtremum{
+		rollingWindow: newRollingWindow[float64](window),
+		replaces:      func(v, old float64) bool { return v >= old },
+	}
+}
+
+func (re *RollingExtremum) refreshWindow(ts int64) {
+	re.expire(ts, func(float64) {})
+}
+
+func (re *RollingExtremum) update(v float64, ts int64) {
+	for {
+		e, ok := re.events.Back()
+		if !ok || !re.replaces(v, e.item) {
+			break
+		}
+		re.events.PopBack()
+	}
+	re.push(ts, v)
+	re.refreshWindow(ts)
+}
+
+// Update the rolling extremum with a new value.
+func (re *RollingExtremum) Update(v float64) {
+	re.update(v, re.now())
+}
+
+// UpdateAt updates the rolling extremum with a new value produced at timestamp in
+// millisecond units.
+func (re *RollingExtremum) UpdateAt(v float64, tsMillis int64) {
+	re.update(v, millisToMicros(tsMillis))
+}
+
+// Value returns the current extremum, or NaN if the window is empty.
+func (re *RollingExtremum) Value() float64 {
+	return re.valueAt(re.now())
+}
+
+// ValueAt returns the extremum at timestamp in millisecond units, or NaN if the window
+// is empty.
+func (re *RollingExtremum) ValueAt(tsMillis int64) float64 {
+	return re.valueAt(millisToMicros(tsMillis))
+}
+
+func (re *RollingExtremum) valueAt(ts int64) float64 {
+	re.refreshWindow(ts)
+	e, ok := re.events.Front()
+	if !ok {
+		return math.NaN()
+	}
+	return e.item
+}
+
+// RollingVWAP calculates the rolling volume weighted average price over a time window.
+type RollingVWAP struct {
+	rollingWindow[priceVolume]
+	notional float64
+	volume   float64
+}
+
+type priceVolume struct {
+	price  float64
+	volume float64
+}
+
+// NewRollingVWAP creates a RollingVWAP with a specified time window.
+func NewRollingVWAP(window time.Duration) *RollingVWAP {
+	return &RollingVWAP{rollingWindow: newRollingWindow[priceVolume](window)}
+}
+
+func (rv *RollingVWAP) refreshWindow(ts int64) {
+	rv.expire(ts, func(t priceVolume) {
+		rv.notional -= t.price * t.volume
+		rv.volume -= t.volume
+	})
+	if rv.events.Len() == 0 {
+		// Reset the accumulated floating-point error.
+		rv.notional, rv.volume = 0, 0
+	}
+}
+
+func (rv *RollingVWAP) update(price, volume float64, ts int64) {
+	rv.push(ts, priceVolume{price, volume})
+	rv.notional += price * volume
+	rv.volume += volume
+	rv.refreshWindow(ts)
+}
+
+// Update the rolling VWAP with a trade.
+func (rv *RollingVWAP) Update(price, volume float64) {
+	rv.update(price, volume, rv.now())
+}
+
+// UpdateAt updates the rolling VWAP with a trade at timestamp in millisecond units.
+func (rv *RollingVWAP) UpdateAt(price, volume float64, tsMillis int64) {
+	rv.update(price, volume, millisToMicros(tsMillis))
+}
+
+// Value returns the current VWAP, or NaN if the window has no volume.
+func (rv *RollingVWAP) Value() float64 {
+	return rv.valueAt(rv.now())
+}
+
+// ValueAt returns the VWAP at timestamp in millisecond units, or NaN if the window has
+// no volume.
+func (rv *RollingVWAP) ValueAt(tsMillis int64) float64 {
+	return rv.valueAt(millisToMicros(tsMillis))
+}
+
+func (rv *RollingVWAP) valueAt(ts int64) float64 {
+	rv.refreshWindow(ts)
+	if rv.volume <= 0 {
+		return math.NaN()
+	}
+	return rv.notional / rv.volume
+}
+
+// Volume returns the current volume in the window.
+func (rv *RollingVWAP) Volume() float64 {
+	rv.refreshWindow(rv.now())
+	return rv.volume
+}
@@ -0,0 +1,119 @@
+package tradekit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingSum(t *testing.T) {
+	rs := NewRollingSum(10 * time.Second)
+	rs.UpdateAt(1, 1000)
+	rs.UpdateAt(2, 5000)
+	rs.UpdateAt(3, 11000)
+	// The window is inclusive of its start.
+	assert.Equal(t, 6.0, rs.ValueAt(11000))
+	assert.Equal(t, 5.0, rs.ValueAt(11001))
+	assert.Equal(t, 3.0, rs.ValueAt(15001))
+	assert.Equal(t, 0.0, rs.ValueAt(30000))
+
+	// The clock drives Update and Value.
+	now := time.UnixMilli(100000)
+	rs.SetClock(func() time.Time { return now })
+	rs.Update(4)
+	now = now.Add(5 * time.Second)
+	rs.Update(5)
+	assert.Equal(t, 9.0, rs.Value())
+	now = now.Add(6 * time.Second)
+	assert.Equal(t, 5.0, rs.Value())
+}
+
+func TestRollingCount(t *testing.T) {
+	rc := NewRollingCount(time.Second)
+	rc.UpdateAt(0)
+	rc.UpdateAt(500)
+	rc.UpdateAt(900)
+	assert.Equal(t, 3, rc.ValueAt(1000))
+	assert.Equal(t, 2, rc.ValueAt(1001))
+	assert.Equal(t, 0, rc.ValueAt(2000))
+}
+
+func TestRollingVariance(t *testing.T) {
+	window := 5 * time.Second
+	rv := NewRollingVariance(window)
+	rm := NewRollingMean(window)
+	assert.True(t, math.IsNaN(rv.MeanAt(0)))
+	assert.True(t, math.IsNaN(rm.ValueAt(0)))
+
+	values := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5}
+	for i, v := range values {
+		ts := int64(i) * 1000
+		rv.UpdateAt(v, ts)
+		rm.UpdateAt(v, ts)
+
+		// The window holds the values of the last 5 seconds, inclusive.
+		start := i - 5
+		if start < 0 {
+			start = 0
+		}
+		var sum float64
+		for _, x := range values[start : i+1] {
+			sum += x
+		}
+		n := float64(i + 1 - start)
+		mean := sum / n
+		var ss float64
+		for _, x := range values[start : i+1] {
+			ss += (x - mean) * (x - mean)
+		}
+
+		assert.InDelta(t, mean, rv.MeanAt(ts), 1e-12)
+		assert.InDelta(t, mean, rm.ValueAt(ts), 1e-12)
+		if n > 1 {
+			assert.InDelta(t, ss/(n-1), rv.VarianceAt(ts), 1e-12)
+			assert.InDelta(t, math.Sqrt(ss/(n-1)), rv.StdDevAt(ts), 1e-12)
+		} else {
+			assert.True(t, math.IsNaN(rv.VarianceAt(ts)))
+		}
+	}
+	assert.True(t, math.IsNaN(rv.VarianceAt(100000)))
+}
+
+func TestRollingExtremum(t *testing.T) {
+	window := 3 * time.Second
+	rmin := NewRollingMin(window)
+	rmax := NewRollingMax(window)
+	assert.True(t, math.IsNaN(rmax.ValueAt(0)))
+
+	values := []float64{5, 3, 4, 4, 8, 1, 2, 2, 7, 6}
+	for i, v := range values {
+		ts := int64(i) * 1000
+		rmin.UpdateAt(v, ts)
+		rmax.UpdateAt(v, ts)
+
+		start := i - 3
+		if start < 0 {
+			start = 0
+		}
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, x := range values[start : i+1] {
+			lo, hi = math.Min(lo, x), math.Max(hi, x)
+		}
+		assert.Equal(t, lo, rmin.ValueAt(ts), "i=%d", i)
+		assert.Equal(t, hi, rmax.ValueAt(ts), "i=%d", i)
+	}
+	// Only the candidates are kept.
+	assert.LessOrEqual(t, rmax.events.Len(), 3)
+}
+
+func TestRollingVWAP(t *testing.T) {
+	rv := NewRollingVWAP(time.Minute)
+	rv.UpdateAt(100, 2, 0)
+	rv.UpdateAt(110, 1, 30000)
+	assert.InDelta(t, 310.0/3, rv.ValueAt(30000), 1e-12)
+	rv.UpdateAt(120, 1, 61000)
+	assert.InDelta(t, 115, rv.ValueAt(61000), 1e-12)
+	assert.True(t, math.IsNaN(rv.ValueAt(200000)))
+}